@@ -2,28 +2,55 @@ package api
 
 import (
 	"errors"
+	"io"
 	"time"
 
+	"github.com/Oabraham1/open-blogger/server/auth"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
 	logger "github.com/Oabraham1/open-blogger/server/log"
 	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+/* RenewTokenRequest carries the refresh token, browsers in cookie auth mode send it in a cookie instead */
 type RenewTokenRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type RenewTokenResponse struct {
-	AccessToken string    `json:"access_token"`
-	ExpiresAt   time.Time `json:"expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token,omitempty"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+	CSRFToken             string    `json:"csrf_token,omitempty"`
 }
 
 func (server *Server) RenewTokenRequest(ctx *gin.Context) {
+	// browsers renewing with the refresh token cookie may send no body at all
 	var req RenewTokenRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		logger.LogError(err.Error(), "RenewTokenRequest")
-		server.BadRequestError(ctx)
-		return
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			logger.LogError(err.Error(), "RenewTokenRequest")
+			server.BadRequestError(ctx)
+			return
+		}
+	}
+
+	if req.RefreshToken == "" {
+		refreshToken, err := ctx.Cookie(refreshTokenCookieName)
+		if err != nil || refreshToken == "" {
+			logger.LogError("refresh token is not provided", "RenewTokenRequest")
+			server.BadRequestError(ctx)
+			return
+		}
+		if err := verifyCSRFToken(ctx); err != nil {
+			logger.LogError(err.Error(), "RenewTokenRequest")
+			server.ForbiddenError(ctx)
+			return
+		}
+		req.RefreshToken = refreshToken
 	}
 
 	payload, err := server.Authenticator.VerifyToken(req.RefreshToken)
@@ -32,8 +59,13 @@ func (server *Server) RenewTokenRequest(ctx *gin.Context) {
 		server.UnauthorizedError(ctx)
 		return
 	}
+	if payload.TokenType != auth.TokenTypeRefresh {
+		logger.LogError("token is not a refresh token", "RenewTokenRequest")
+		server.UnauthorizedError(ctx)
+		return
+	}
 
-	userSession, err := server.DataStore.GetSessionById(ctx, payload.ID)
+	userSession, err := server.DataStore.GetSessionById(ctx, payload.SessionID)
 	if err != nil {
 		logger.LogError(err.Error(), "RenewTokenRequest")
 		if errors.Is(err, util.ErrRecordNotFound) {
@@ -68,7 +100,64 @@ func (server *Server) RenewTokenRequest(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.Authenticator.CreateToken(userSession.Username, server.Configurations.AccessTokenDuration)
+	// read the role again so that role changes take effect on renewal
+	user, err := server.DataStore.GetUserByUsername(ctx, userSession.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "RenewTokenRequest")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	sessionID, err := uuid.NewRandom()
+	if err != nil {
+		logger.LogError(err.Error(), "RenewTokenRequest")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	accessToken, accessPayload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeAccess, server.Configurations.AccessTokenDuration)
+	if err != nil {
+		logger.LogError(err.Error(), "RenewTokenRequest")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeRefresh, server.Configurations.RefreshTokenDuration)
+	if err != nil {
+		logger.LogError(err.Error(), "RenewTokenRequest")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	// rotate the refresh token, the old session can never be renewed again
+	result, err := server.DataStore.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		PreviousSessionID: userSession.ID,
+		CreateNewUserSessionParams: db.CreateNewUserSessionParams{
+			ID:           sessionID,
+			FamilyID:     userSession.FamilyID,
+			Username:     userSession.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			ExpiresAt:    refreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "RenewTokenRequest")
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			server.SessionCache.InvalidateUser(userSession.Username)
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	csrfToken, err := server.setAuthCookies(ctx, accessToken, accessPayload.ExpiredAt, refreshToken, refreshPayload.ExpiredAt)
 	if err != nil {
 		logger.LogError(err.Error(), "RenewTokenRequest")
 		server.InternalServerError(ctx)
@@ -76,8 +165,16 @@ func (server *Server) RenewTokenRequest(ctx *gin.Context) {
 	}
 
 	rsp := RenewTokenResponse{
-		AccessToken: accessToken,
-		ExpiresAt:   accessPayload.ExpiredAt,
+		SessionID:             result.Session.ID,
+		ExpiresAt:             accessPayload.ExpiredAt,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+	}
+	// in cookie auth mode the tokens are only kept in the HttpOnly cookies
+	if csrfToken != "" {
+		rsp.CSRFToken = csrfToken
+	} else {
+		rsp.AccessToken = accessToken
+		rsp.RefreshToken = refreshToken
 	}
 	server.ReturnOK(ctx, rsp)
 }
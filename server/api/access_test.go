@@ -0,0 +1,338 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRenewTokenRequest(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	server := newTestServer(t, nil)
+
+	sessionID := uuid.New()
+	refreshToken, refreshPayload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeRefresh, time.Minute)
+	require.NoError(t, err)
+
+	accessToken, _, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	session := db.Session{
+		ID:           sessionID,
+		FamilyID:     uuid.New(),
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+						require.Equal(t, session.ID, arg.PreviousSessionID)
+						require.Equal(t, session.FamilyID, arg.FamilyID)
+						require.NotEqual(t, session.ID, arg.ID)
+						require.NotEqual(t, session.RefreshToken, arg.RefreshToken)
+						return db.RotateSessionTxResult{Session: db.Session{ID: arg.ID}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp RenewTokenResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.NotEqual(t, refreshToken, rsp.RefreshToken)
+				require.NotEqual(t, session.ID, rsp.SessionID)
+			},
+		},
+		{
+			name: "AccessToken",
+			body: gin.H{
+				"refresh_token": accessToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RoleReloaded",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				promotedUser := user
+				promotedUser.Role = db.UserRoleModerator
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(promotedUser, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+						return db.RotateSessionTxResult{Session: db.Session{ID: arg.ID}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp RenewTokenResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+
+				payload, err := server.Authenticator.VerifyToken(rsp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, auth.RoleModerator, payload.Role)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshTokenReused",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				usedSession := session
+				usedSession.IsUsed = true
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(usedSession, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RotateSessionTxResult{}, db.ErrRefreshTokenReused)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				blockedSession := session
+				blockedSession.IsBlocked = true
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(blockedSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshTokenMismatch",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				otherSession := session
+				otherSession.RefreshToken = "anotherToken"
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(otherSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredSession := session
+				expiredSession.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(expiredSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"refresh_token": refreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RotateSessionTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidToken",
+			body: gin.H{
+				"refresh_token": "invalidToken",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingToken",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/token/renew"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UpdateUserRoleRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=reader author moderator admin"`
+}
+
+func (server *Server) ModerateDeleteComment(ctx *gin.Context) {
+	var req DeleteCommentByIDRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "ModerateDeleteComment")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// convert commentId string to uuid
+	commentId, err := uuid.Parse(req.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "ModerateDeleteComment")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// find the comment
+	_, err = server.DataStore.GetCommentByID(ctx, commentId)
+	if err != nil {
+		logger.LogError(err.Error(), "ModerateDeleteComment")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	err = server.DataStore.DeleteCommentByID(ctx, commentId)
+	if err != nil {
+		logger.LogError(err.Error(), "ModerateDeleteComment")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Comment deleted successfully"})
+}
+
+func (server *Server) AdminDeletePost(ctx *gin.Context) {
+	var req DeletePostRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "AdminDeletePost")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// convert postId string to uuid
+	postId, err := uuid.Parse(req.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "AdminDeletePost")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// find the post
+	_, err = server.DataStore.GetPostById(ctx, postId)
+	if err != nil {
+		logger.LogError(err.Error(), "AdminDeletePost")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if !server.deletePostAndComments(ctx, postId, "AdminDeletePost") {
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Post deleted successfully"})
+}
+
+func (server *Server) AdminDeleteUserAccount(ctx *gin.Context) {
+	var req DeleteUserAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "AdminDeleteUserAccount")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	user, err := server.DataStore.GetUserByUsername(ctx, req.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "AdminDeleteUserAccount")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if !server.deleteUserAccountAndContent(ctx, user.Username, "AdminDeleteUserAccount") {
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "User account deleted successfully"})
+}
+
+func (server *Server) UpdateUserRole(ctx *gin.Context) {
+	var req UpdateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "UpdateUserRole")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	user, err := server.DataStore.UpdateUserRoleTx(ctx, db.UpdateUserRoleParams{
+		Role:     db.UserRole(req.Role),
+		Username: req.Username,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "UpdateUserRole")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		// someone has to be left to manage the roles
+		if errors.Is(err, db.ErrLastAdmin) {
+			server.ConflictError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	// the sessions of the user were blocked when the role changed, drop them from the cache as well
+	server.SessionCache.InvalidateUser(user.Username)
+	server.ReturnOK(ctx, GetUserAccountResponse(user))
+}
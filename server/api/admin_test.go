@@ -0,0 +1,494 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestModerateDeleteComment(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	comment := generateDummyComment(t, user, post)
+	comment.ID = uuid.New()
+
+	testCases := []struct {
+		name               string
+		commentID          string
+		setUpAuthenticator func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs         func(store *mockdb.MockStore)
+		checkResponse      func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			commentID: comment.ID.String(),
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCommentByID(gomock.Any(), gomock.Eq(comment.ID)).
+					Times(1).
+					Return(comment, nil)
+				store.EXPECT().
+					DeleteCommentByID(gomock.Any(), gomock.Eq(comment.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "Forbidden",
+			commentID: comment.ID.String(),
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "author", auth.RoleAuthor, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteCommentByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NotFound",
+			commentID: comment.ID.String(),
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCommentByID(gomock.Any(), gomock.Eq(comment.ID)).
+					Times(1).
+					Return(db.Comment{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					DeleteCommentByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			commentID: "invalid",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCommentByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			commentID: comment.ID.String(),
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCommentByID(gomock.Any(), gomock.Eq(comment.ID)).
+					Times(1).
+					Return(comment, nil)
+				store.EXPECT().
+					DeleteCommentByID(gomock.Any(), gomock.Eq(comment.ID)).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/moderation/comment/delete/%s", tc.commentID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			tc.setUpAuthenticator(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAdminDeletePost(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+
+	testCases := []struct {
+		name               string
+		setUpAuthenticator func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs         func(store *mockdb.MockStore)
+		checkResponse      func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					DeleteCommentsByPostID(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					DeletePostByID(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ModeratorForbidden",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeletePostByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					DeletePostByID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/admin/post/delete/%s", post.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			tc.setUpAuthenticator(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestAdminDeleteUserAccount(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	testCases := []struct {
+		name               string
+		setUpAuthenticator func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs         func(store *mockdb.MockStore)
+		checkResponse      func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					DeleteUserAccountTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteUserAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					DeleteUserAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "LastAdmin",
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					DeleteUserAccountTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.ErrLastAdmin)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/admin/user/delete/%s", user.Username)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			tc.setUpAuthenticator(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	testCases := []struct {
+		name               string
+		body               gin.H
+		setUpAuthenticator func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs         func(store *mockdb.MockStore)
+		checkResponse      func(recoder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"username": user.Username,
+				"role":     auth.RoleModerator,
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				updatedUser := user
+				updatedUser.Role = db.UserRoleModerator
+				arg := db.UpdateUserRoleParams{
+					Role:     db.UserRoleModerator,
+					Username: user.Username,
+				}
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updatedUser, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp UserAccountResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.Equal(t, auth.RoleModerator, rsp.Role)
+			},
+		},
+		{
+			name: "Forbidden",
+			body: gin.H{
+				"username": user.Username,
+				"role":     auth.RoleAdmin,
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRole",
+			body: gin.H{
+				"username": user.Username,
+				"role":     "superuser",
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			body: gin.H{
+				"username": user.Username,
+				"role":     auth.RoleReader,
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "LastAdmin",
+			body: gin.H{
+				"username": "admin",
+				"role":     auth.RoleAuthor,
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserRoleTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrLastAdmin)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/admin/user/updateRole"
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setUpAuthenticator(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
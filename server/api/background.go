@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	logger "github.com/Oabraham1/open-blogger/server/log"
+)
+
+// a background task must not outlive a stuck mail server or database
+const backgroundTaskTimeout = time.Minute
+
+/*
+runInBackground runs the task without holding up the response. The task gets its own context because the
+request context is done as soon as the response is written, failures are logged.
+*/
+func (server *Server) runInBackground(pointOfFailure string, task func(ctx context.Context) error) {
+	server.backgroundTasks.Add(1)
+	go func() {
+		defer server.backgroundTasks.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), backgroundTaskTimeout)
+		defer cancel()
+
+		if err := task(ctx); err != nil {
+			logger.LogError(err.Error(), pointOfFailure)
+		}
+	}()
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxCategorySlugLength = 50
+
+/* Errors returned to clients when a category cannot be used or changed */
+var (
+	errInvalidCategorySlug   = errors.New("category slugs must have letters or digits and at most 50 characters")
+	errUnknownCategory       = errors.New("the category does not exist")
+	errUnknownParentCategory = errors.New("the parent category does not exist")
+	errCategoryExists        = errors.New("a category with this slug already exists")
+	errCategoryCycle         = errors.New("a category cannot be nested under itself or its descendants")
+	errCategoryInUse         = errors.New("the category still has posts or subcategories")
+)
+
+/* CreateCategoryRequest creates a category, the slug is made from the name when it is left out */
+type CreateCategoryRequest struct {
+	Slug        string `json:"slug" binding:"max=100"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	Parent      string `json:"parent" binding:"max=100"`
+}
+
+/* UpdateCategoryRequest replaces the fields of a category, leaving out the slug keeps it and leaving out the parent makes it top level */
+type UpdateCategoryRequest struct {
+	Slug        string `json:"slug" binding:"max=100"`
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=1000"`
+	Parent      string `json:"parent" binding:"max=100"`
+}
+
+type CategorySlugRequest struct {
+	Slug string `uri:"slug" binding:"required,max=100"`
+}
+
+type CategoryResponse struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Parent      string    `json:"parent,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ListCategoriesResponse struct {
+	Categories []CategoryResponse `json:"categories"`
+}
+
+func GetCategoryResponse(category db.Category, parentSlug string) CategoryResponse {
+	return CategoryResponse{
+		Slug:        category.Slug,
+		Name:        category.Name,
+		Description: category.Description,
+		Parent:      parentSlug,
+		CreatedAt:   category.CreatedAt,
+	}
+}
+
+/* categorySlug normalizes the slug of a category the same way as tags */
+func categorySlug(text string) (string, error) {
+	slug := util.Slugify(text)
+	if slug == "" || len(slug) > maxCategorySlugLength {
+		return "", errInvalidCategorySlug
+	}
+	return slug, nil
+}
+
+/*
+resolveParentCategory looks up the parent of a category by slug, an empty slug is a top level category.
+It writes the response and returns false when the parent does not exist or cannot be read.
+*/
+func (server *Server) resolveParentCategory(ctx *gin.Context, parent string, pointOfFailure string) (db.Category, bool) {
+	if parent == "" {
+		return db.Category{}, true
+	}
+
+	category, err := server.DataStore.GetCategoryBySlug(ctx, util.Slugify(parent))
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errUnknownParentCategory))
+			return db.Category{}, false
+		}
+		server.InternalServerError(ctx)
+		return db.Category{}, false
+	}
+	return category, true
+}
+
+/* parentCategoryID is the parent_id column of a category nested under parent, null when parent is the zero category */
+func parentCategoryID(parent db.Category) pgtype.UUID {
+	return pgtype.UUID{Bytes: parent.ID, Valid: parent.ID != uuid.Nil}
+}
+
+func (server *Server) GetCategories(ctx *gin.Context) {
+	categories, err := server.DataStore.ListCategories(ctx)
+	if err != nil {
+		logger.LogError(err.Error(), "GetCategories")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	slugs := make(map[uuid.UUID]string)
+	for _, category := range categories {
+		slugs[category.ID] = category.Slug
+	}
+
+	rsp := ListCategoriesResponse{Categories: []CategoryResponse{}}
+	for _, category := range categories {
+		rsp.Categories = append(rsp.Categories, GetCategoryResponse(category, slugs[category.ParentID.Bytes]))
+	}
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) CreateCategory(ctx *gin.Context) {
+	var req CreateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "CreateCategory")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	slug := req.Slug
+	if slug == "" {
+		slug = req.Name
+	}
+	slug, err := categorySlug(slug)
+	if err != nil {
+		logger.LogError(err.Error(), "CreateCategory")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	parent, ok := server.resolveParentCategory(ctx, req.Parent, "CreateCategory")
+	if !ok {
+		return
+	}
+
+	category, err := server.DataStore.CreateCategory(ctx, db.CreateCategoryParams{
+		Slug:        slug,
+		Name:        req.Name,
+		Description: req.Description,
+		ParentID:    parentCategoryID(parent),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "CreateCategory")
+		if util.ErrorCode(err) == util.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errCategoryExists))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetCategoryResponse(category, parent.Slug))
+}
+
+func (server *Server) UpdateCategory(ctx *gin.Context) {
+	var uri CategorySlugRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		logger.LogError(err.Error(), "UpdateCategory")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "UpdateCategory")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	category, err := server.DataStore.GetCategoryBySlug(ctx, util.Slugify(uri.Slug))
+	if err != nil {
+		logger.LogError(err.Error(), "UpdateCategory")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	slug := category.Slug
+	if req.Slug != "" {
+		slug, err = categorySlug(req.Slug)
+		if err != nil {
+			logger.LogError(err.Error(), "UpdateCategory")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	parent, ok := server.resolveParentCategory(ctx, req.Parent, "UpdateCategory")
+	if !ok {
+		return
+	}
+
+	// the new parent must not be the category or one of its descendants
+	if parent.ID != uuid.Nil {
+		ancestors, err := server.DataStore.GetCategoryAncestorIDs(ctx, parent.ID)
+		if err != nil {
+			logger.LogError(err.Error(), "UpdateCategory")
+			server.InternalServerError(ctx)
+			return
+		}
+		for _, ancestor := range ancestors {
+			if ancestor == category.ID {
+				ctx.JSON(http.StatusBadRequest, errorResponse(errCategoryCycle))
+				return
+			}
+		}
+	}
+
+	// posts follow a new slug through the ON UPDATE CASCADE of their foreign key
+	category, err = server.DataStore.UpdateCategory(ctx, db.UpdateCategoryParams{
+		ID:          category.ID,
+		Slug:        slug,
+		Name:        req.Name,
+		Description: req.Description,
+		ParentID:    parentCategoryID(parent),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "UpdateCategory")
+		if util.ErrorCode(err) == util.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errCategoryExists))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetCategoryResponse(category, parent.Slug))
+}
+
+func (server *Server) DeleteCategory(ctx *gin.Context) {
+	var req CategorySlugRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "DeleteCategory")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	category, err := server.DataStore.GetCategoryBySlug(ctx, util.Slugify(req.Slug))
+	if err != nil {
+		logger.LogError(err.Error(), "DeleteCategory")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	_, err = server.DataStore.DeleteCategory(ctx, category.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "DeleteCategory")
+		if util.ErrorCode(err) == util.ForeignKeyViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errCategoryInUse))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Category deleted successfully"})
+}
@@ -0,0 +1,515 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func generateDummyCategory(slug string, parent *db.Category) db.Category {
+	category := db.Category{
+		ID:          uuid.New(),
+		Slug:        slug,
+		Name:        slug,
+		Description: "A test category",
+		CreatedAt:   time.Now(),
+	}
+	if parent != nil {
+		category.ParentID = pgtype.UUID{Bytes: parent.ID, Valid: true}
+	}
+	return category
+}
+
+func addAdminAuth(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+	addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "admin", auth.RoleAdmin, uuid.New(), time.Minute)
+}
+
+func TestGetCategories(t *testing.T) {
+	parent := generateDummyCategory("programming", nil)
+	child := generateDummyCategory("golang", &parent)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListCategories(gomock.Any()).
+		Times(1).
+		Return([]db.Category{child, parent}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/category/getAll", nil)
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp ListCategoriesResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Categories, 2)
+	require.Equal(t, "programming", rsp.Categories[0].Parent)
+	require.Empty(t, rsp.Categories[1].Parent)
+}
+
+func TestCreateCategory(t *testing.T) {
+	parent := generateDummyCategory("programming", nil)
+	category := generateDummyCategory("golang", &parent)
+
+	testCases := []struct {
+		name               string
+		body               gin.H
+		setUpAuthenticator func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs         func(store *mockdb.MockStore)
+		checkResponse      func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:               "OK",
+			body:               gin.H{"name": "Golang", "description": category.Description, "parent": "Programming"},
+			setUpAuthenticator: addAdminAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq("programming")).
+					Times(1).
+					Return(parent, nil)
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Eq(db.CreateCategoryParams{
+						Slug:        "golang",
+						Name:        "Golang",
+						Description: category.Description,
+						ParentID:    pgtype.UUID{Bytes: parent.ID, Valid: true},
+					})).
+					Times(1).
+					Return(category, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp CategoryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "golang", rsp.Slug)
+				require.Equal(t, "programming", rsp.Parent)
+			},
+		},
+		{
+			name:               "TopLevelWithSlug",
+			body:               gin.H{"name": "Go", "slug": "Go Lang"},
+			setUpAuthenticator: addAdminAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Eq(db.CreateCategoryParams{Slug: "go-lang", Name: "Go"})).
+					Times(1).
+					Return(db.Category{Slug: "go-lang", Name: "Go"}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:               "InvalidSlug",
+			body:               gin.H{"name": "???"},
+			setUpAuthenticator: addAdminAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCategorySlug.Error())
+			},
+		},
+		{
+			name:               "UnknownParent",
+			body:               gin.H{"name": "Golang", "parent": "missing"},
+			setUpAuthenticator: addAdminAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq("missing")).
+					Times(1).
+					Return(db.Category{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errUnknownParentCategory.Error())
+			},
+		},
+		{
+			name:               "DuplicateSlug",
+			body:               gin.H{"name": "Golang"},
+			setUpAuthenticator: addAdminAuth,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Category{}, util.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errCategoryExists.Error())
+			},
+		},
+		{
+			name: "ModeratorForbidden",
+			body: gin.H{"name": "Golang"},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithRole(t, request, authenticator, authorizationTypeBearer, "moderator", auth.RoleModerator, uuid.New(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/admin/category/create", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setUpAuthenticator(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	parent := generateDummyCategory("programming", nil)
+	category := generateDummyCategory("golang", &parent)
+	child := generateDummyCategory("generics", &category)
+
+	testCases := []struct {
+		name          string
+		slug          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Rename",
+			slug: category.Slug,
+			body: gin.H{"slug": "go", "name": "Go", "parent": parent.Slug},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(category, nil)
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(parent.Slug)).
+					Times(1).
+					Return(parent, nil)
+				store.EXPECT().
+					GetCategoryAncestorIDs(gomock.Any(), gomock.Eq(parent.ID)).
+					Times(1).
+					Return([]uuid.UUID{parent.ID}, nil)
+				store.EXPECT().
+					UpdateCategory(gomock.Any(), gomock.Eq(db.UpdateCategoryParams{
+						ID:       category.ID,
+						Slug:     "go",
+						Name:     "Go",
+						ParentID: pgtype.UUID{Bytes: parent.ID, Valid: true},
+					})).
+					Times(1).
+					Return(db.Category{ID: category.ID, Slug: "go", Name: "Go", ParentID: category.ParentID}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp CategoryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "go", rsp.Slug)
+				require.Equal(t, parent.Slug, rsp.Parent)
+			},
+		},
+		{
+			name: "MoveToTopLevel",
+			slug: category.Slug,
+			body: gin.H{"name": category.Name},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(category, nil)
+				store.EXPECT().
+					GetCategoryAncestorIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					UpdateCategory(gomock.Any(), gomock.Eq(db.UpdateCategoryParams{
+						ID:   category.ID,
+						Slug: category.Slug,
+						Name: category.Name,
+					})).
+					Times(1).
+					Return(category, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NestedUnderDescendant",
+			slug: category.Slug,
+			body: gin.H{"name": category.Name, "parent": child.Slug},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(category, nil)
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(child.Slug)).
+					Times(1).
+					Return(child, nil)
+				store.EXPECT().
+					GetCategoryAncestorIDs(gomock.Any(), gomock.Eq(child.ID)).
+					Times(1).
+					Return([]uuid.UUID{child.ID, category.ID, parent.ID}, nil)
+				store.EXPECT().
+					UpdateCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errCategoryCycle.Error())
+			},
+		},
+		{
+			name: "NotFound",
+			slug: "missing",
+			body: gin.H{"name": "Missing"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq("missing")).
+					Times(1).
+					Return(db.Category{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					UpdateCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/api/admin/category/update/%s", tc.slug)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAdminAuth(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	category := generateDummyCategory("golang", nil)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(category, nil)
+				store.EXPECT().
+					DeleteCategory(gomock.Any(), gomock.Eq(category.ID)).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InUse",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(category, nil)
+				store.EXPECT().
+					DeleteCategory(gomock.Any(), gomock.Eq(category.ID)).
+					Times(1).
+					Return(int64(0), util.ErrForeignKeyViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errCategoryInUse.Error())
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(category.Slug)).
+					Times(1).
+					Return(db.Category{}, sql.ErrConnDone)
+				store.EXPECT().
+					DeleteCategory(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/admin/category/delete/%s", category.Slug)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAdminAuth(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreatePostWithUnknownCategory(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetCategoryBySlug(gomock.Any(), gomock.Eq("go")).
+		Times(1).
+		Return(db.Category{}, util.ErrRecordNotFound)
+	store.EXPECT().
+		CreatePostTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"title":    post.Title,
+		"body":     post.Body,
+		"category": "Go",
+		"username": post.Username,
+		"status":   db.StatusDraft,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/post/create", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	requireBodyMatchError(t, recorder.Body, errUnknownCategory.Error())
+}
+
+func TestGetPostsByCategoryWithDescendants(t *testing.T) {
+	posts := generateDummyPublishedPosts(t, 1)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListPublishedPostsInCategoryTree(gomock.Any(), gomock.Eq(db.ListPublishedPostsInCategoryTreeParams{
+			Category:  "programming",
+			PageLimit: defaultPageLimit + 1,
+		})).
+		Times(1).
+		Return(posts, nil)
+	store.EXPECT().
+		ListPublishedPostsByCategory(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.GetTagsByPostIDsRow{}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/post/getByCategory/programming?include_descendants=true", nil)
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp ListPostsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Posts, 1)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	accessTokenCookieName  = "access_token"
+	refreshTokenCookieName = "refresh_token"
+	csrfTokenCookieName    = "csrf_token"
+	csrfTokenHeaderKey     = "X-CSRF-Token"
+	// the refresh token cookie is only sent to the token renewal route
+	refreshTokenCookiePath = "/api/token"
+	csrfTokenSize          = 32
+)
+
+/* Errors returned when a cookie authenticated request fails the double-submit check */
+var (
+	ErrCSRFTokenMissing  = errors.New("csrf token is missing")
+	ErrCSRFTokenMismatch = errors.New("csrf token does not match")
+)
+
+/*
+setAuthCookies stores the access and refresh token in HttpOnly cookies when cookie auth mode is enabled.
+A new CSRF token is stored in a cookie that scripts can read, they have to send it back in the X-CSRF-Token header.
+It returns the CSRF token, which is empty when cookie auth mode is disabled.
+*/
+func (server *Server) setAuthCookies(ctx *gin.Context, accessToken string, accessTokenExpiresAt time.Time, refreshToken string, refreshTokenExpiresAt time.Time) (string, error) {
+	if !server.Configurations.AuthCookieMode {
+		return "", nil
+	}
+
+	csrfToken, err := util.RandomToken(csrfTokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	server.setCookie(ctx, accessTokenCookieName, accessToken, "/", accessTokenExpiresAt, true)
+	server.setCookie(ctx, refreshTokenCookieName, refreshToken, refreshTokenCookiePath, refreshTokenExpiresAt, true)
+	server.setCookie(ctx, csrfTokenCookieName, csrfToken, "/", refreshTokenExpiresAt, false)
+	return csrfToken, nil
+}
+
+/* clearAuthCookies expires the auth cookies of the client */
+func (server *Server) clearAuthCookies(ctx *gin.Context) {
+	if !server.Configurations.AuthCookieMode {
+		return
+	}
+
+	server.setCookie(ctx, accessTokenCookieName, "", "/", time.Unix(0, 0), true)
+	server.setCookie(ctx, refreshTokenCookieName, "", refreshTokenCookiePath, time.Unix(0, 0), true)
+	server.setCookie(ctx, csrfTokenCookieName, "", "/", time.Unix(0, 0), false)
+}
+
+func (server *Server) setCookie(ctx *gin.Context, name string, value string, path string, expiresAt time.Time, httpOnly bool) {
+	maxAge := int(time.Until(expiresAt).Seconds())
+	if maxAge <= 0 {
+		maxAge = -1
+	}
+
+	http.SetCookie(ctx.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   server.Configurations.AuthCookieDomain,
+		Expires:  expiresAt,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: httpOnly,
+		SameSite: sameSiteMode(server.Configurations.AuthCookieSameSite),
+	})
+}
+
+/* sameSiteMode parses the SameSite setting, anything but lax or none is strict */
+func sameSiteMode(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+/* isSafeMethod reports whether the method cannot change state and needs no CSRF token */
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+/* verifyCSRFToken checks that the CSRF header of the request matches the CSRF cookie */
+func verifyCSRFToken(ctx *gin.Context) error {
+	if isSafeMethod(ctx.Request.Method) {
+		return nil
+	}
+
+	cookie, err := ctx.Cookie(csrfTokenCookieName)
+	header := ctx.GetHeader(csrfTokenHeaderKey)
+	if err != nil || cookie == "" || header == "" {
+		return ErrCSRFTokenMissing
+	}
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 {
+		return ErrCSRFTokenMismatch
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newCookieTestServer(t *testing.T, store db.Store) *Server {
+	server := newTestServer(t, store)
+	server.Configurations.AuthCookieMode = true
+	return server
+}
+
+func getCookie(t *testing.T, recorder *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	require.FailNowf(t, "cookie not set", "cookie %s is not set", name)
+	return nil
+}
+
+func addCookies(request *http.Request, cookies ...*http.Cookie) {
+	for _, cookie := range cookies {
+		request.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+}
+
+func TestLoginUserWithCookies(t *testing.T) {
+	user, password := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(db.UserTotp{}, util.ErrRecordNotFound)
+	store.EXPECT().
+		CreateNewUserSession(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+			return db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username, ExpiresAt: arg.ExpiresAt}, nil
+		})
+	server := newCookieTestServer(t, store)
+
+	/* Test login sets the token cookies and returns only the CSRF token in the body */
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(data))
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp LoginUserAccountResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+	require.NotZero(t, rsp.ExpiresAt)
+	require.NotZero(t, rsp.RefreshTokenExpiresAt)
+	require.Equal(t, user.Username, rsp.UserAccount.Username)
+
+	accessTokenCookie := getCookie(t, recorder, accessTokenCookieName)
+	require.NotEmpty(t, accessTokenCookie.Value)
+	require.True(t, accessTokenCookie.HttpOnly)
+	require.True(t, accessTokenCookie.Secure)
+	require.Equal(t, http.SameSiteStrictMode, accessTokenCookie.SameSite)
+	require.Equal(t, "/", accessTokenCookie.Path)
+
+	refreshTokenCookie := getCookie(t, recorder, refreshTokenCookieName)
+	require.NotEmpty(t, refreshTokenCookie.Value)
+	require.True(t, refreshTokenCookie.HttpOnly)
+	require.Equal(t, refreshTokenCookiePath, refreshTokenCookie.Path)
+
+	csrfTokenCookie := getCookie(t, recorder, csrfTokenCookieName)
+	require.Equal(t, rsp.CSRFToken, csrfTokenCookie.Value)
+	require.NotEmpty(t, csrfTokenCookie.Value)
+	require.False(t, csrfTokenCookie.HttpOnly)
+
+	/* Test safe methods are authenticated by the cookie alone */
+	store.EXPECT().
+		GetUserSessionsByUsername(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Session{}, nil)
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/api/user/sessions", nil)
+	require.NoError(t, err)
+	addCookies(request, accessTokenCookie)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	/* Test unsafe methods need the CSRF header to match the CSRF cookie */
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/user/logout", nil)
+	require.NoError(t, err)
+	addCookies(request, accessTokenCookie, csrfTokenCookie)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/user/logout", nil)
+	require.NoError(t, err)
+	addCookies(request, accessTokenCookie, csrfTokenCookie)
+	request.Header.Set(csrfTokenHeaderKey, "wrongToken")
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	/* Test logout clears the cookies */
+	store.EXPECT().
+		BlockSessionFamily(gomock.Any(), gomock.Eq(rsp.SessionID)).
+		Times(1).
+		Return(nil)
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/user/logout", nil)
+	require.NoError(t, err)
+	addCookies(request, accessTokenCookie, csrfTokenCookie)
+	request.Header.Set(csrfTokenHeaderKey, csrfTokenCookie.Value)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, getCookie(t, recorder, accessTokenCookieName).Value)
+	require.Negative(t, getCookie(t, recorder, accessTokenCookieName).MaxAge)
+	require.Empty(t, getCookie(t, recorder, refreshTokenCookieName).Value)
+}
+
+func TestLoginUserWithoutCookieMode(t *testing.T) {
+	user, password := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(db.UserTotp{}, util.ErrRecordNotFound)
+	store.EXPECT().
+		CreateNewUserSession(gomock.Any(), gomock.Any()).
+		Times(1)
+	server := newTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/user/login", bytes.NewReader(data))
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Empty(t, recorder.Result().Cookies())
+}
+
+func TestChangePasswordWithCookies(t *testing.T) {
+	user, password := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+	store.EXPECT().
+		ChangePasswordTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ChangePasswordTxParams) (db.ChangePasswordTxResult, error) {
+			return db.ChangePasswordTxResult{Session: db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}}, nil
+		})
+	server := newCookieTestServer(t, store)
+
+	data, err := json.Marshal(gin.H{"current_password": password, "new_password": "correct horse battery staple"})
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, "/api/user/password", bytes.NewReader(data))
+	require.NoError(t, err)
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp LoginUserAccountResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Empty(t, rsp.AccessToken)
+	require.Empty(t, rsp.RefreshToken)
+	require.NotEmpty(t, getCookie(t, recorder, accessTokenCookieName).Value)
+	require.Equal(t, rsp.CSRFToken, getCookie(t, recorder, csrfTokenCookieName).Value)
+}
+
+func TestRenewTokenRequestWithCookies(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	server := newTestServer(t, nil)
+
+	sessionID := uuid.New()
+	refreshToken, refreshPayload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeRefresh, time.Minute)
+	require.NoError(t, err)
+
+	session := db.Session{
+		ID:           sessionID,
+		FamilyID:     uuid.New(),
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	}
+	csrfToken := "testCSRFToken"
+
+	testCases := []struct {
+		name          string
+		setupRequest  func(request *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupRequest: func(request *http.Request) {
+				request.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+				request.AddCookie(&http.Cookie{Name: csrfTokenCookieName, Value: csrfToken})
+				request.Header.Set(csrfTokenHeaderKey, csrfToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+						return db.RotateSessionTxResult{Session: db.Session{ID: arg.ID}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp RenewTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Empty(t, rsp.AccessToken)
+				require.Empty(t, rsp.RefreshToken)
+				require.NotEmpty(t, getCookie(t, recorder, accessTokenCookieName).Value)
+				require.NotEmpty(t, getCookie(t, recorder, refreshTokenCookieName).Value)
+				require.NotEqual(t, csrfToken, getCookie(t, recorder, csrfTokenCookieName).Value)
+				require.Equal(t, rsp.CSRFToken, getCookie(t, recorder, csrfTokenCookieName).Value)
+			},
+		},
+		{
+			name: "MissingCSRFToken",
+			setupRequest: func(request *http.Request) {
+				request.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+				request.AddCookie(&http.Cookie{Name: csrfTokenCookieName, Value: csrfToken})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "CSRFTokenMismatch",
+			setupRequest: func(request *http.Request) {
+				request.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: refreshToken})
+				request.AddCookie(&http.Cookie{Name: csrfTokenCookieName, Value: csrfToken})
+				request.Header.Set(csrfTokenHeaderKey, "otherCSRFToken")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:         "MissingRefreshToken",
+			setupRequest: func(request *http.Request) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newCookieTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/api/token/renew", nil)
+			require.NoError(t, err)
+			tc.setupRequest(request)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSameSiteMode(t *testing.T) {
+	require.Equal(t, http.SameSiteStrictMode, sameSiteMode(""))
+	require.Equal(t, http.SameSiteLaxMode, sameSiteMode("Lax"))
+	require.Equal(t, http.SameSiteNoneMode, sameSiteMode("none"))
+	require.Equal(t, http.SameSiteStrictMode, sameSiteMode("unknown"))
+}
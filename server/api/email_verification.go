@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultEmailVerificationTokenDuration  = 24 * time.Hour
+	defaultEmailVerificationResendInterval = time.Minute
+	emailVerificationTokenSize             = 32
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+/* sendVerificationEmail stores a new verification token with the querier and emails it to the user */
+func (server *Server) sendVerificationEmail(ctx *gin.Context, querier db.Querier, user db.User) error {
+	token, err := server.createVerificationToken(ctx, querier, user)
+	if err != nil {
+		return err
+	}
+	return server.mailVerificationToken(ctx, user, token)
+}
+
+/*
+createVerificationToken stores a new verification token for the user with the querier and returns it.
+Signups store the token within the transaction of the new user and email it after the commit.
+*/
+func (server *Server) createVerificationToken(ctx *gin.Context, querier db.Querier, user db.User) (string, error) {
+	token, err := util.RandomToken(emailVerificationTokenSize)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = querier.CreateEmailVerificationToken(ctx, db.CreateEmailVerificationTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(server.emailVerificationTokenDuration()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+/* mailVerificationToken emails the verification link of the token to the user */
+func (server *Server) mailVerificationToken(ctx *gin.Context, user db.User, token string) error {
+	return server.Mailer.SendEmail(ctx, mail.Email{
+		To:      []string{user.Email},
+		Subject: "Verify your Open Blogger email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nWelcome to Open Blogger! Use the link below to verify your email address. The link expires in %s.\n\n%s/verify-email?token=%s\n",
+			user.FirstName, server.emailVerificationTokenDuration(), server.Configurations.AppBaseURL, token,
+		),
+	})
+}
+
+func (server *Server) emailVerificationTokenDuration() time.Duration {
+	duration := server.Configurations.EmailVerificationTokenDuration
+	if duration <= 0 {
+		duration = defaultEmailVerificationTokenDuration
+	}
+	return duration
+}
+
+/*
+requireVerifiedEmail writes a 403 response and returns false when email verification is
+required and the user has not verified their email address yet.
+*/
+func (server *Server) requireVerifiedEmail(ctx *gin.Context, username string, pointOfFailure string) bool {
+	if !server.Configurations.RequireEmailVerification {
+		return true
+	}
+
+	user, err := server.DataStore.GetUserByUsername(ctx, username)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return false
+		}
+		server.InternalServerError(ctx)
+		return false
+	}
+
+	if !user.EmailVerifiedAt.Valid {
+		logger.LogError("email address is not verified", pointOfFailure)
+		server.ForbiddenError(ctx)
+		return false
+	}
+	return true
+}
+
+func (server *Server) VerifyEmail(ctx *gin.Context) {
+	var req VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "VerifyEmail")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	verificationToken, err := server.DataStore.GetEmailVerificationTokenByHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		logger.LogError(err.Error(), "VerifyEmail")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if verificationToken.ExpiresAt.Before(time.Now()) {
+		logger.LogError("email verification token expired", "VerifyEmail")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	user, err := server.DataStore.VerifyEmailTx(ctx, verificationToken.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "VerifyEmail")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetUserAccountResponse(user))
+}
+
+func (server *Server) ResendVerificationEmail(ctx *gin.Context) {
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "ResendVerificationEmail")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	user, err := server.DataStore.GetUserByUsername(ctx, authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "ResendVerificationEmail")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		logger.LogError("email address is already verified", "ResendVerificationEmail")
+		server.ConflictError(ctx)
+		return
+	}
+
+	interval := server.Configurations.EmailVerificationResendInterval
+	if interval <= 0 {
+		interval = defaultEmailVerificationResendInterval
+	}
+
+	latestToken, err := server.DataStore.GetLatestEmailVerificationToken(ctx, user.Username)
+	if err != nil && !errors.Is(err, util.ErrRecordNotFound) {
+		logger.LogError(err.Error(), "ResendVerificationEmail")
+		server.InternalServerError(ctx)
+		return
+	}
+	if err == nil {
+		if wait := time.Until(latestToken.CreatedAt.Add(interval)); wait > 0 {
+			logger.LogError("verification email requested too soon", "ResendVerificationEmail")
+			server.TooManyRequestsError(ctx, wait)
+			return
+		}
+	}
+
+	err = server.sendVerificationEmail(ctx, server.DataStore, user)
+	if err != nil {
+		logger.LogError(err.Error(), "ResendVerificationEmail")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Verification email sent"})
+}
@@ -0,0 +1,479 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var verificationLinkPattern = regexp.MustCompile(`/verify-email\?token=([A-Za-z0-9_-]+)`)
+
+func TestVerifyEmail(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	token, err := util.RandomToken(emailVerificationTokenSize)
+	require.NoError(t, err)
+
+	verificationToken := db.EmailVerificationToken{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"token": token,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.EmailVerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetEmailVerificationTokenByHash(gomock.Any(), gomock.Eq(verificationToken.TokenHash)).
+					Times(1).
+					Return(verificationToken, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp UserAccountResponse
+				err = json.Unmarshal(data, &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.EmailVerified)
+			},
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{
+				"token": "unknownToken",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationTokenByHash(gomock.Any(), gomock.Eq(util.HashToken("unknownToken"))).
+					Times(1).
+					Return(db.EmailVerificationToken{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{
+				"token": token,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredToken := verificationToken
+				expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetEmailVerificationTokenByHash(gomock.Any(), gomock.Eq(verificationToken.TokenHash)).
+					Times(1).
+					Return(expiredToken, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingToken",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationTokenByHash(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"token": token,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEmailVerificationTokenByHash(gomock.Any(), gomock.Eq(verificationToken.TokenHash)).
+					Times(1).
+					Return(verificationToken, nil)
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/email/verify"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSignupVerificationEmailAfterCommit(t *testing.T) {
+	user, password := generateDummyUser(t)
+	body := gin.H{
+		"username":   user.Username,
+		"password":   password,
+		"email":      user.Email,
+		"first_name": user.FirstName,
+		"last_name":  user.LastName,
+	}
+
+	testCases := []struct {
+		name          string
+		commitErr     error
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "Committed",
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Len(t, mailer.Emails(), 1)
+				require.Regexp(t, verificationLinkPattern, mailer.Emails()[0].Body)
+			},
+		},
+		{
+			name:      "CommitFailed",
+			commitErr: sql.ErrConnDone,
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Empty(t, mailer.Emails())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			mailer := server.Mailer.(*mail.MemoryMailer)
+
+			store.EXPECT().
+				CreateUserTx(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+					err := arg.AfterCreate(store, user)
+					require.NoError(t, err)
+					/* Nothing is sent while the transaction is open */
+					require.Empty(t, mailer.Emails())
+					return db.CreateUserTxResult{User: user}, tc.commitErr
+				})
+			store.EXPECT().
+				CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.EmailVerificationToken{}, nil)
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/api/user/create", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, mailer)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	var storedTokenHash string
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestEmailVerificationToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.EmailVerificationToken{CreatedAt: time.Now().Add(-2 * defaultEmailVerificationResendInterval)}, nil)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
+						storedTokenHash = arg.TokenHash
+						return db.EmailVerificationToken{Username: arg.Username, TokenHash: arg.TokenHash}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				emails := mailer.Emails()
+				require.Len(t, emails, 1)
+				require.Equal(t, []string{user.Email}, emails[0].To)
+
+				match := verificationLinkPattern.FindStringSubmatch(emails[0].Body)
+				require.Len(t, match, 2)
+				require.Equal(t, util.HashToken(match[1]), storedTokenHash)
+			},
+		},
+		{
+			name: "NoPreviousToken",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestEmailVerificationToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.EmailVerificationToken{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerificationToken{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Len(t, mailer.Emails(), 1)
+			},
+		},
+		{
+			name: "TooSoon",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestEmailVerificationToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.EmailVerificationToken{CreatedAt: time.Now().Add(-defaultEmailVerificationResendInterval / 2)}, nil)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+				require.Empty(t, mailer.Emails())
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				verifiedUser := user
+				verifiedUser.EmailVerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+				store.EXPECT().
+					GetLatestEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Empty(t, mailer.Emails())
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestEmailVerificationToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.EmailVerificationToken{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/user/email/resend"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server.Mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	verifiedUser := user
+	verifiedUser.EmailVerifiedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	postID := uuid.New()
+
+	testCases := []struct {
+		name                     string
+		requireEmailVerification bool
+		buildStubs               func(store *mockdb.MockStore)
+		checkResponse            func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:                     "Unverified",
+			requireEmailVerification: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:                     "Verified",
+			requireEmailVerification: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verifiedUser, nil)
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(postID)).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:                     "VerificationNotRequired",
+			requireEmailVerification: false,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(postID)).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.Configurations.RequireEmailVerification = tc.requireEmailVerification
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"post_id":  postID.String(),
+				"username": user.Username,
+			})
+			require.NoError(t, err)
+
+			url := "/api/post/publish"
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthWithRole(t, request, server.Authenticator, authorizationTypeBearer, user.Username, auth.RoleAuthor, uuid.New(), time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+/* Registration modes, an empty mode is open */
+const (
+	RegistrationModeOpen   = "open"
+	RegistrationModeInvite = "invite"
+	RegistrationModeClosed = "closed"
+)
+
+const (
+	defaultInviteDuration = 7 * 24 * time.Hour
+	defaultInviteQuota    = 5
+	inviteCodeSize        = 24
+)
+
+/* Errors returned to clients when an account cannot be registered or an invite cannot be created */
+var (
+	errRegistrationClosed  = errors.New("registration is closed")
+	errInviteRequired      = errors.New("an invite code is required to register")
+	errInvalidInvite       = errors.New("the invite code is invalid, expired or used up")
+	errInviteQuotaExceeded = errors.New("the invite quota is used up")
+)
+
+type CreateInviteRequest struct {
+	MaxUses int32 `json:"max_uses" binding:"omitempty,min=1,max=1000"`
+}
+
+type DeleteInviteRequest struct {
+	ID string `uri:"id" binding:"required,min=1"`
+}
+
+type InviteResponse struct {
+	ID        uuid.UUID `json:"id"`
+	MaxUses   int32     `json:"max_uses"`
+	Uses      int32     `json:"uses"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateInviteResponse struct {
+	// Code is only returned once, the server keeps a hash of it
+	Code   string         `json:"code"`
+	Invite InviteResponse `json:"invite"`
+}
+
+func GetInviteResponse(invite db.Invite) InviteResponse {
+	return InviteResponse{
+		ID:        invite.ID,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedAt: invite.CreatedAt,
+	}
+}
+
+/* checkRegistrationMode returns an error when the configured registration mode is unknown */
+func checkRegistrationMode(mode string) error {
+	switch mode {
+	case "", RegistrationModeOpen, RegistrationModeInvite, RegistrationModeClosed:
+		return nil
+	default:
+		return fmt.Errorf("unsupported registration mode %q", mode)
+	}
+}
+
+/*
+CreateInvite creates an invite code that registers up to max_uses accounts before it expires.
+Users without the manage invites permission can only have a limited number of unused invites.
+*/
+func (server *Server) CreateInvite(ctx *gin.Context) {
+	var req CreateInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "CreateInvite")
+		server.BadRequestError(ctx)
+		return
+	}
+	if req.MaxUses == 0 {
+		req.MaxUses = 1
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "CreateInvite")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	// users who manage invites have no quota
+	quota := 0
+	if !auth.HasPermission(authenticationPayload.Role, auth.PermissionManageInvites) {
+		quota = server.Configurations.InviteQuota
+		if quota <= 0 {
+			quota = defaultInviteQuota
+		}
+	}
+
+	code, err := util.RandomToken(inviteCodeSize)
+	if err != nil {
+		logger.LogError(err.Error(), "CreateInvite")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	duration := server.Configurations.InviteDuration
+	if duration <= 0 {
+		duration = defaultInviteDuration
+	}
+
+	invite, err := server.DataStore.CreateInviteTx(ctx, db.CreateInviteTxParams{
+		CreateInviteParams: db.CreateInviteParams{
+			CodeHash:        util.HashToken(code),
+			InviterUsername: authenticationPayload.Username,
+			MaxUses:         req.MaxUses,
+			ExpiresAt:       time.Now().Add(duration),
+		},
+		Quota: int32(quota),
+	})
+	if errors.Is(err, db.ErrInviteQuotaExceeded) {
+		logger.LogError(errInviteQuotaExceeded.Error(), "CreateInvite")
+		ctx.JSON(http.StatusForbidden, errorResponse(errInviteQuotaExceeded))
+		return
+	}
+	if err != nil {
+		logger.LogError(err.Error(), "CreateInvite")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, CreateInviteResponse{
+		Code:   code,
+		Invite: GetInviteResponse(invite),
+	})
+}
+
+func (server *Server) GetInvites(ctx *gin.Context) {
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "GetInvites")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	invites, err := server.DataStore.GetInvitesByInviter(ctx, authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "GetInvites")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp := []InviteResponse{}
+	for _, invite := range invites {
+		rsp = append(rsp, GetInviteResponse(invite))
+	}
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) DeleteInvite(ctx *gin.Context) {
+	var req DeleteInviteRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "DeleteInvite")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	inviteID, err := uuid.Parse(req.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "DeleteInvite")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "DeleteInvite")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	// invites of other users are reported as not found
+	rows, err := server.DataStore.DeleteInvite(ctx, db.DeleteInviteParams{
+		ID:              inviteID,
+		InviterUsername: authenticationPayload.Username,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "DeleteInvite")
+		server.InternalServerError(ctx)
+		return
+	}
+	if rows == 0 {
+		logger.LogError("invite not found", "DeleteInvite")
+		server.NotFoundError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Invite deleted successfully"})
+}
+
+/*
+checkRegistrationAllowed writes a 403 response and returns false when the registration mode does not let
+a new account be created with the invite code. The code itself is only checked when it is consumed.
+*/
+func (server *Server) checkRegistrationAllowed(ctx *gin.Context, inviteCode string, pointOfFailure string) bool {
+	switch server.Configurations.RegistrationMode {
+	case RegistrationModeClosed:
+		logger.LogError(errRegistrationClosed.Error(), pointOfFailure)
+		ctx.JSON(http.StatusForbidden, errorResponse(errRegistrationClosed))
+		return false
+	case RegistrationModeInvite:
+		if inviteCode == "" {
+			logger.LogError(errInviteRequired.Error(), pointOfFailure)
+			ctx.JSON(http.StatusForbidden, errorResponse(errInviteRequired))
+			return false
+		}
+	}
+	return true
+}
+
+/*
+consumeInvite uses up one registration of the invite code with the querier of the CreateUserTx transaction,
+so that the invite is only spent when the account is created. It does nothing outside of invite-only mode.
+*/
+func (server *Server) consumeInvite(ctx *gin.Context, querier db.Querier, inviteCode string) error {
+	if server.Configurations.RegistrationMode != RegistrationModeInvite {
+		return nil
+	}
+
+	rows, err := querier.UseInvite(ctx, util.HashToken(inviteCode))
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errInvalidInvite
+	}
+	return nil
+}
@@ -0,0 +1,459 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateInvite(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	var storedCodeHash string
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: auth.RoleAuthor,
+			body: gin.H{"max_uses": 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateInviteTxParams) (db.Invite, error) {
+						require.Equal(t, user.Username, arg.InviterUsername)
+						require.Equal(t, int32(2), arg.MaxUses)
+						require.Equal(t, int32(defaultInviteQuota), arg.Quota)
+						require.WithinDuration(t, time.Now().Add(defaultInviteDuration), arg.ExpiresAt, time.Second)
+						storedCodeHash = arg.CodeHash
+						return db.Invite{
+							ID:              uuid.New(),
+							CodeHash:        arg.CodeHash,
+							InviterUsername: arg.InviterUsername,
+							MaxUses:         arg.MaxUses,
+							ExpiresAt:       arg.ExpiresAt,
+							CreatedAt:       time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				var rsp CreateInviteResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+
+				/* Only the hash of the returned code is stored */
+				require.NotEmpty(t, rsp.Code)
+				require.Equal(t, util.HashToken(rsp.Code), storedCodeHash)
+				require.Equal(t, int32(2), rsp.Invite.MaxUses)
+				require.Zero(t, rsp.Invite.Uses)
+			},
+		},
+		{
+			name: "DefaultMaxUses",
+			role: auth.RoleReader,
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateInviteTxParams) (db.Invite, error) {
+						require.Equal(t, int32(1), arg.MaxUses)
+						return db.Invite{ID: uuid.New(), MaxUses: arg.MaxUses}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "QuotaExceeded",
+			role: auth.RoleAuthor,
+			body: gin.H{"max_uses": 2},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Invite{}, db.ErrInviteQuotaExceeded)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInviteQuotaExceeded.Error())
+			},
+		},
+		{
+			name: "AdminHasNoQuota",
+			role: auth.RoleAdmin,
+			body: gin.H{"max_uses": 100},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateInviteTxParams) (db.Invite, error) {
+						require.Zero(t, arg.Quota)
+						return db.Invite{ID: uuid.New(), MaxUses: arg.MaxUses}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidMaxUses",
+			role: auth.RoleAuthor,
+			body: gin.H{"max_uses": -1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: auth.RoleAuthor,
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInviteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Invite{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/invites"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthWithRole(t, request, server.Authenticator, authorizationTypeBearer, user.Username, tc.role, uuid.New(), time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetInvites(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetInvitesByInviter(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.Invite{
+			{ID: uuid.New(), CodeHash: "secretHash", InviterUsername: user.Username, MaxUses: 1, Uses: 1},
+			{ID: uuid.New(), CodeHash: "otherSecretHash", InviterUsername: user.Username, MaxUses: 3},
+		}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/user/invites", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	/* Code hashes are never returned */
+	require.NotContains(t, recorder.Body.String(), "secretHash")
+
+	var rsp []InviteResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Equal(t, int32(1), rsp[0].Uses)
+	require.Equal(t, int32(3), rsp[1].MaxUses)
+}
+
+func TestDeleteInvite(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	inviteID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		inviteID      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			inviteID: inviteID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteInvite(gomock.Any(), gomock.Eq(db.DeleteInviteParams{ID: inviteID, InviterUsername: user.Username})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			inviteID: inviteID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteInvite(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidID",
+			inviteID: "invalid",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteInvite(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			inviteID: inviteID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteInvite(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/user/invites/%s", tc.inviteID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateNewUserAccountWithRegistrationMode(t *testing.T) {
+	user, password := generateDummyUser(t)
+	inviteCode := "invite-code"
+
+	testCases := []struct {
+		name             string
+		registrationMode string
+		inviteCode       string
+		buildStubs       func(store *mockdb.MockStore)
+		checkResponse    func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:             "InviteOK",
+			registrationMode: RegistrationModeInvite,
+			inviteCode:       inviteCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						err := arg.AfterCreate(store, user)
+						return db.CreateUserTxResult{User: user}, err
+					})
+				store.EXPECT().
+					UseInvite(gomock.Any(), gomock.Eq(util.HashToken(inviteCode))).
+					Times(1).
+					Return(int64(1), nil)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerificationToken{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:             "InviteRequired",
+			registrationMode: RegistrationModeInvite,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInviteRequired.Error())
+			},
+		},
+		{
+			name:             "InvalidInvite",
+			registrationMode: RegistrationModeInvite,
+			inviteCode:       inviteCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						err := arg.AfterCreate(store, user)
+						return db.CreateUserTxResult{}, err
+					})
+				store.EXPECT().
+					UseInvite(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidInvite.Error())
+			},
+		},
+		{
+			name:             "OpenIgnoresInvite",
+			registrationMode: RegistrationModeOpen,
+			inviteCode:       inviteCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						err := arg.AfterCreate(store, user)
+						return db.CreateUserTxResult{User: user}, err
+					})
+				store.EXPECT().
+					UseInvite(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerificationToken{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:             "Closed",
+			registrationMode: RegistrationModeClosed,
+			inviteCode:       inviteCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errRegistrationClosed.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.Configurations.RegistrationMode = tc.registrationMode
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username":    user.Username,
+				"password":    password,
+				"email":       user.Email,
+				"first_name":  user.FirstName,
+				"last_name":   user.LastName,
+				"invite_code": tc.inviteCode,
+			})
+			require.NoError(t, err)
+
+			url := "/api/user/create"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestNewServerWithUnknownRegistrationMode(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey: "01234567890123456789012345678901",
+		TOTPEncryptionKey: "10987654321098765432109876543210",
+		RegistrationMode:  "invite-only",
+	}
+
+	_, err := NewServer(mockdb.NewMockStore(gomock.NewController(t)), config)
+	require.ErrorContains(t, err, "unsupported registration mode")
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/lockout"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLoginMaxFailuresPerUsername = 5
+	defaultLoginMaxFailuresPerIP       = 20
+	defaultLoginBackoffBase            = time.Second
+	defaultLoginLockoutDuration        = 15 * time.Minute
+
+	auditActionLoginLockout = "login_lockout"
+)
+
+func usernameLoginKey(username string) string {
+	return "username:" + strings.ToLower(username)
+}
+
+func ipLoginKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+/* loginPolicies returns the lockout policies for usernames and client IPs, falling back to the defaults for unset values */
+func (server *Server) loginPolicies() (lockout.Policy, lockout.Policy) {
+	backoffBase := server.Configurations.LoginBackoffBase
+	if backoffBase <= 0 {
+		backoffBase = defaultLoginBackoffBase
+	}
+	lockoutDuration := server.Configurations.LoginLockoutDuration
+	if lockoutDuration <= 0 {
+		lockoutDuration = defaultLoginLockoutDuration
+	}
+	maxFailuresPerUsername := server.Configurations.LoginMaxFailuresPerUsername
+	if maxFailuresPerUsername <= 0 {
+		maxFailuresPerUsername = defaultLoginMaxFailuresPerUsername
+	}
+	maxFailuresPerIP := server.Configurations.LoginMaxFailuresPerIP
+	if maxFailuresPerIP <= 0 {
+		maxFailuresPerIP = defaultLoginMaxFailuresPerIP
+	}
+
+	usernamePolicy := lockout.Policy{
+		MaxFailures:     maxFailuresPerUsername,
+		BaseBackoff:     backoffBase,
+		LockoutDuration: lockoutDuration,
+	}
+	ipPolicy := lockout.Policy{
+		MaxFailures:     maxFailuresPerIP,
+		BaseBackoff:     backoffBase,
+		LockoutDuration: lockoutDuration,
+	}
+	return usernamePolicy, ipPolicy
+}
+
+/* checkLoginAllowed writes a 429 response and returns false while the username or the client IP is backing off or locked out */
+func (server *Server) checkLoginAllowed(ctx *gin.Context, username string, pointOfFailure string) bool {
+	retryAfter, err := server.LoginLimiter.RetryAfter(ctx, usernameLoginKey(username), ipLoginKey(ctx.ClientIP()))
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return false
+	}
+	if retryAfter > 0 {
+		logger.LogError("login attempted while backing off", pointOfFailure)
+		server.TooManyRequestsError(ctx, retryAfter)
+		return false
+	}
+	return true
+}
+
+/* recordLoginFailure counts a failed login for the username and the client IP and writes an audit entry for every lockout */
+func (server *Server) recordLoginFailure(ctx *gin.Context, username string, pointOfFailure string) {
+	usernamePolicy, ipPolicy := server.loginPolicies()
+	keys := []struct {
+		key    string
+		policy lockout.Policy
+	}{
+		{key: usernameLoginKey(username), policy: usernamePolicy},
+		{key: ipLoginKey(ctx.ClientIP()), policy: ipPolicy},
+	}
+
+	for _, k := range keys {
+		result, err := server.LoginLimiter.RecordFailure(ctx, k.key, k.policy)
+		if err != nil {
+			logger.LogError(err.Error(), pointOfFailure)
+			continue
+		}
+		if result.LockedOut {
+			server.writeAuditLog(ctx, auditActionLoginLockout, username, fmt.Sprintf(
+				"%s locked out for %s after %d failed logins", k.key, result.RetryAfter, result.Failures,
+			), pointOfFailure)
+		}
+	}
+}
+
+/* resetLoginFailures forgets the failed logins of the username after a successful login */
+func (server *Server) resetLoginFailures(ctx *gin.Context, username string, pointOfFailure string) {
+	err := server.LoginLimiter.Reset(ctx, usernameLoginKey(username))
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+	}
+}
+
+/* writeAuditLog stores an audit entry, failures are logged without failing the request */
+func (server *Server) writeAuditLog(ctx *gin.Context, action string, username string, details string, pointOfFailure string) {
+	_, err := server.DataStore.CreateAuditLog(ctx, db.CreateAuditLogParams{
+		Action:   action,
+		Username: username,
+		ClientIp: ctx.ClientIP(),
+		Details:  details,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+	}
+}
@@ -0,0 +1,471 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/lockout"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testClientIP = "192.0.2.1"
+
+/* seedLoginFailures records failures for the key without blocking it */
+func seedLoginFailures(t *testing.T, store *lockout.MemoryStore, key string, failures int) {
+	now := time.Now()
+	for i := 0; i < failures; i++ {
+		_, err := store.RecordFailure(context.Background(), key, now, now.Add(-time.Hour))
+		require.NoError(t, err)
+	}
+}
+
+func TestLoginUserLockout(t *testing.T) {
+	user, password := generateDummyUser(t)
+
+	testCases := []struct {
+		name string
+		// login defaults to the username of the user
+		login         string
+		password      string
+		seedStore     func(store *lockout.MemoryStore)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore)
+	}{
+		{
+			name:     "UsernameLockedOut",
+			password: "incorrect",
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 2)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAuditLog(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+						require.Equal(t, auditActionLoginLockout, arg.Action)
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, testClientIP, arg.ClientIp)
+						require.Contains(t, arg.Details, usernameLoginKey(user.Username))
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Equal(t, 3, attempt.Failures)
+				require.WithinDuration(t, time.Now().Add(time.Hour), attempt.BlockedUntil, time.Second)
+			},
+		},
+		{
+			name:     "IPLockedOut",
+			password: "incorrect",
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, ipLoginKey(testClientIP), 9)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAuditLog(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+						require.Equal(t, auditActionLoginLockout, arg.Action)
+						require.Contains(t, arg.Details, ipLoginKey(testClientIP))
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Blocked",
+			password: password,
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 3)
+				err := store.Block(context.Background(), usernameLoginKey(user.Username), time.Now().Add(time.Hour))
+				require.NoError(t, err)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "3600", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:     "BlockedByClientIP",
+			password: password,
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, ipLoginKey(testClientIP), 1)
+				err := store.Block(context.Background(), ipLoginKey(testClientIP), time.Now().Add(time.Second))
+				require.NoError(t, err)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+			},
+		},
+		{
+			name:     "BlockedUsingEmail",
+			login:    user.Email,
+			password: password,
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 3)
+				err := store.Block(context.Background(), usernameLoginKey(user.Username), time.Now().Add(time.Hour))
+				require.NoError(t, err)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name:      "FailureUsingEmailCountsForUsername",
+			login:     user.Email,
+			password:  "incorrect",
+			seedStore: func(store *lockout.MemoryStore) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Equal(t, 1, attempt.Failures)
+			},
+		},
+		{
+			name:      "BackoffAfterFailure",
+			password:  "incorrect",
+			seedStore: func(store *lockout.MemoryStore) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateAuditLog(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Equal(t, 1, attempt.Failures)
+				require.WithinDuration(t, time.Now().Add(time.Second), attempt.BlockedUntil, 500*time.Millisecond)
+			},
+		},
+		{
+			name:     "SuccessResetsUsername",
+			password: password,
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 2)
+				seedLoginFailures(t, store, ipLoginKey(testClientIP), 2)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Zero(t, attempt.Failures)
+
+				/* Failures of the client IP are kept so that one valid account cannot reset them */
+				attempt, err = store.GetAttempt(context.Background(), ipLoginKey(testClientIP))
+				require.NoError(t, err)
+				require.Equal(t, 2, attempt.Failures)
+			},
+		},
+		{
+			/* The password alone does not reset the failures of an account with two-factor authentication */
+			name:     "PasswordWithMFAKeepsFailures",
+			password: password,
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 2)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				userTOTP, _ := generateDummyUserTOTP(t, user.Username, true)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTOTP, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MfaChallenge{ExpiresAt: time.Now().Add(time.Minute)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Equal(t, 2, attempt.Failures)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.Configurations.LoginMaxFailuresPerUsername = 3
+			server.Configurations.LoginMaxFailuresPerIP = 10
+			server.Configurations.LoginLockoutDuration = time.Hour
+
+			attemptStore := lockout.NewMemoryStore()
+			tc.seedStore(attemptStore)
+			server.LoginLimiter = lockout.NewLimiter(attemptStore)
+
+			recorder := httptest.NewRecorder()
+
+			login := tc.login
+			if login == "" {
+				login = user.Username
+			}
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(gin.H{
+				"username": login,
+				"password": tc.password,
+			})
+			require.NoError(t, err)
+
+			url := "/api/user/login"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			request.RemoteAddr = testClientIP + ":12345"
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, attemptStore)
+		})
+	}
+}
+
+func TestLoginUserWithMFALockout(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	userTOTP, secret := generateDummyUserTOTP(t, user.Username, true)
+
+	challengeToken, err := util.RandomToken(mfaChallengeTokenSize)
+	require.NoError(t, err)
+
+	/* Each challenge is fresh, the failures of the username are what limit the guesses */
+	challenge := db.MfaChallenge{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		TokenHash: util.HashToken(challengeToken),
+		ExpiresAt: time.Now().Add(time.Minute),
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		code          func() string
+		seedStore     func(store *lockout.MemoryStore)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore)
+	}{
+		{
+			name: "InvalidCodeCountsForUsername",
+			code: func() string { return invalidTOTPCode(t, secret, time.Now()) },
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 2)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTOTP, nil)
+				store.EXPECT().
+					IncrementMFAChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1)
+				store.EXPECT().
+					CreateAuditLog(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+						require.Equal(t, auditActionLoginLockout, arg.Action)
+						require.Equal(t, user.Username, arg.Username)
+						return db.AuditLog{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Equal(t, 3, attempt.Failures)
+				require.WithinDuration(t, time.Now().Add(time.Hour), attempt.BlockedUntil, time.Second)
+			},
+		},
+		{
+			name: "Blocked",
+			code: func() string {
+				code, err := totp.GenerateCode(secret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 3)
+				err := store.Block(context.Background(), usernameLoginKey(user.Username), time.Now().Add(time.Hour))
+				require.NoError(t, err)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "SuccessResetsUsername",
+			code: func() string {
+				code, err := totp.GenerateCode(secret, time.Now())
+				require.NoError(t, err)
+				return code
+			},
+			seedStore: func(store *lockout.MemoryStore) {
+				seedLoginFailures(t, store, usernameLoginKey(user.Username), 2)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTOTP, nil)
+				store.EXPECT().
+					UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(1), nil)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, store *lockout.MemoryStore) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				attempt, err := store.GetAttempt(context.Background(), usernameLoginKey(user.Username))
+				require.NoError(t, err)
+				require.Zero(t, attempt.Failures)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.Configurations.LoginMaxFailuresPerUsername = 3
+			server.Configurations.LoginMaxFailuresPerIP = 10
+			server.Configurations.LoginLockoutDuration = time.Hour
+
+			attemptStore := lockout.NewMemoryStore()
+			tc.seedStore(attemptStore)
+			server.LoginLimiter = lockout.NewLimiter(attemptStore)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"challenge_token": challengeToken,
+				"code":            tc.code(),
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/user/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.RemoteAddr = testClientIP + ":12345"
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, attemptStore)
+		})
+	}
+}
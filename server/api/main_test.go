@@ -1,20 +1,38 @@
 package api
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/mail"
 	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   "01234567890123456789012345678901",
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:    "01234567890123456789012345678901",
+		TOTPEncryptionKey:    "10987654321098765432109876543210",
+		Mailer:               mail.MailerMemory,
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+
+	// unless a test stubs it explicitly, every session referenced by an access token is active
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetSessionById(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			DoAndReturn(func(_ context.Context, id uuid.UUID) (db.Session, error) {
+				return db.Session{ID: id, FamilyID: id, ExpiresAt: time.Now().Add(time.Hour)}, nil
+			})
 	}
 
 	server, err := NewServer(store, config)
@@ -0,0 +1,384 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMFAChallengeDuration   = 5 * time.Minute
+	mfaChallengeTokenSize         = 32
+	maxMFAChallengeFailedAttempts = 5
+	totpRecoveryCodeCount         = 10
+)
+
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,numeric,len=6"`
+}
+
+type LoginUserWithMFARequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code"`
+}
+
+type EnrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired    bool      `json:"mfa_required"`
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+/* isMFAAvailable reports whether TOTP secrets can be encrypted, it writes a 503 response when no encryption key is configured */
+func (server *Server) isMFAAvailable(ctx *gin.Context, pointOfFailure string) bool {
+	if server.SecretCipher == nil {
+		logger.LogError("totp encryption key is not configured", pointOfFailure)
+		server.ServiceUnavailableError(ctx)
+		return false
+	}
+	return true
+}
+
+/* isTOTPEnabled reports whether the user has a confirmed TOTP enrollment, it writes the error response and returns false on failure */
+func (server *Server) isTOTPEnabled(ctx *gin.Context, username string, pointOfFailure string) (bool, bool) {
+	userTOTP, err := server.DataStore.GetUserTOTP(ctx, username)
+	if err != nil {
+		if errors.Is(err, util.ErrRecordNotFound) {
+			return false, true
+		}
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return false, false
+	}
+	return userTOTP.ConfirmedAt.Valid, true
+}
+
+/* createMFAChallenge stores a short lived challenge for the user and returns its token in place of a session */
+func (server *Server) createMFAChallenge(ctx *gin.Context, user db.User, pointOfFailure string) {
+	token, err := util.RandomToken(mfaChallengeTokenSize)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	duration := server.Configurations.MFAChallengeDuration
+	if duration <= 0 {
+		duration = defaultMFAChallengeDuration
+	}
+
+	challenge, err := server.DataStore.CreateMFAChallenge(ctx, db.CreateMFAChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(duration),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, MFAChallengeResponse{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresAt:      challenge.ExpiresAt,
+	})
+}
+
+/* decryptTOTPSecret decrypts the stored TOTP secret of the user */
+func (server *Server) decryptTOTPSecret(userTOTP db.UserTotp) (string, error) {
+	secret, err := server.SecretCipher.Decrypt(userTOTP.SecretCiphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (server *Server) EnrollTOTP(ctx *gin.Context) {
+	if !server.isMFAAvailable(ctx, "EnrollTOTP") {
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "EnrollTOTP")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	enabled, ok := server.isTOTPEnabled(ctx, authenticationPayload.Username, "EnrollTOTP")
+	if !ok {
+		return
+	}
+	if enabled {
+		logger.LogError("totp is already enabled", "EnrollTOTP")
+		server.ConflictError(ctx)
+		return
+	}
+
+	secret, uri, err := auth.GenerateTOTPKey(authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "EnrollTOTP")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	secretCiphertext, err := server.SecretCipher.Encrypt([]byte(secret))
+	if err != nil {
+		logger.LogError(err.Error(), "EnrollTOTP")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	_, err = server.DataStore.UpsertUnconfirmedUserTOTP(ctx, db.UpsertUnconfirmedUserTOTPParams{
+		Username:         authenticationPayload.Username,
+		SecretCiphertext: secretCiphertext,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "EnrollTOTP")
+		// the enrollment was confirmed concurrently
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.ConflictError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, EnrollTOTPResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+	})
+}
+
+func (server *Server) ConfirmTOTP(ctx *gin.Context) {
+	var req ConfirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	if !server.isMFAAvailable(ctx, "ConfirmTOTP") {
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "ConfirmTOTP")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	userTOTP, err := server.DataStore.GetUserTOTP(ctx, authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if userTOTP.ConfirmedAt.Valid {
+		logger.LogError("totp is already enabled", "ConfirmTOTP")
+		server.ConflictError(ctx)
+		return
+	}
+
+	secret, err := server.decryptTOTPSecret(userTOTP)
+	if err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	step, err := auth.ValidateTOTPCode(secret, req.Code, time.Now())
+	if err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		if errors.Is(err, auth.ErrInvalidTOTPCode) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	recoveryCodes, err := auth.GenerateRecoveryCodes(totpRecoveryCodeCount)
+	if err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	// only hashes of the recovery codes are stored, the codes are shown to the user once
+	recoveryCodeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		recoveryCodeHashes[i] = util.HashToken(auth.NormalizeRecoveryCode(code))
+	}
+
+	_, err = server.DataStore.ConfirmTOTPTx(ctx, db.ConfirmTOTPTxParams{
+		Username:           authenticationPayload.Username,
+		Step:               step,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "ConfirmTOTP")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.ConflictError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, ConfirmTOTPResponse{RecoveryCodes: recoveryCodes})
+}
+
+func (server *Server) LoginUserWithMFA(ctx *gin.Context) {
+	var req LoginUserWithMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "LoginUserWithMFA")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	if !server.isMFAAvailable(ctx, "LoginUserWithMFA") {
+		return
+	}
+
+	challenge, err := server.DataStore.GetMFAChallengeByHash(ctx, util.HashToken(req.ChallengeToken))
+	if err != nil {
+		logger.LogError(err.Error(), "LoginUserWithMFA")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if challenge.IsUsed || challenge.ExpiresAt.Before(time.Now()) || challenge.FailedAttempts >= maxMFAChallengeFailedAttempts {
+		logger.LogError("mfa challenge is no longer valid", "LoginUserWithMFA")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	// every challenge of the username shares its failure count, so new challenges do not give new guesses
+	if !server.checkLoginAllowed(ctx, challenge.Username, "LoginUserWithMFA") {
+		return
+	}
+
+	// the challenge is claimed before the second factor is consumed, a rejected factor rolls the claim back
+	err = server.DataStore.UseMFAChallengeTx(ctx, db.UseMFAChallengeTxParams{
+		ChallengeID: challenge.ID,
+		VerifySecondFactor: func(querier db.Querier) (bool, error) {
+			return server.verifySecondFactor(ctx, querier, challenge.Username, req)
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrSecondFactorInvalid):
+			_, err = server.DataStore.IncrementMFAChallengeFailedAttempts(ctx, challenge.ID)
+			if err != nil {
+				logger.LogError(err.Error(), "LoginUserWithMFA")
+				server.InternalServerError(ctx)
+				return
+			}
+			server.recordLoginFailure(ctx, challenge.Username, "LoginUserWithMFA")
+			logger.LogError("second factor is invalid", "LoginUserWithMFA")
+			server.UnauthorizedError(ctx)
+		case errors.Is(err, db.ErrMFAChallengeUsed):
+			logger.LogError("mfa challenge already used", "LoginUserWithMFA")
+			server.UnauthorizedError(ctx)
+		default:
+			logger.LogError(err.Error(), "LoginUserWithMFA")
+			server.InternalServerError(ctx)
+		}
+		return
+	}
+
+	user, err := server.DataStore.GetUserByUsername(ctx, challenge.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "LoginUserWithMFA")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp, ok := server.createLoginSession(ctx, user, "LoginUserWithMFA")
+	if !ok {
+		return
+	}
+	server.resetLoginFailures(ctx, user.Username, "LoginUserWithMFA")
+	server.ReturnOK(ctx, rsp)
+}
+
+/*
+verifySecondFactor checks the TOTP code or recovery code of the request through the querier. Each TOTP
+time step and each recovery code is accepted at most once.
+*/
+func (server *Server) verifySecondFactor(ctx context.Context, querier db.Querier, username string, req LoginUserWithMFARequest) (bool, error) {
+	if req.Code == "" {
+		rows, err := querier.UseTOTPRecoveryCode(ctx, db.UseTOTPRecoveryCodeParams{
+			Username: username,
+			CodeHash: util.HashToken(auth.NormalizeRecoveryCode(req.RecoveryCode)),
+		})
+		if err != nil {
+			return false, err
+		}
+		return rows > 0, nil
+	}
+
+	userTOTP, err := querier.GetUserTOTP(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	if !userTOTP.ConfirmedAt.Valid {
+		return false, nil
+	}
+
+	secret, err := server.decryptTOTPSecret(userTOTP)
+	if err != nil {
+		return false, err
+	}
+
+	step, err := auth.ValidateTOTPCode(secret, req.Code, time.Now())
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidTOTPCode) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// a code whose time step is not newer than the last accepted one is a replay
+	rows, err := querier.UpdateUserTOTPLastUsedStep(ctx, db.UpdateUserTOTPLastUsedStepParams{
+		Username:     username,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,702 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+/* generateDummyUserTOTP creates a TOTP enrollment encrypted with the key of the test server */
+func generateDummyUserTOTP(t *testing.T, username string, confirmed bool) (db.UserTotp, string) {
+	secret, _, err := auth.GenerateTOTPKey(username)
+	require.NoError(t, err)
+
+	secretCipher, err := util.NewSecretCipher("10987654321098765432109876543210")
+	require.NoError(t, err)
+	secretCiphertext, err := secretCipher.Encrypt([]byte(secret))
+	require.NoError(t, err)
+
+	userTOTP := db.UserTotp{
+		Username:         username,
+		SecretCiphertext: secretCiphertext,
+		CreatedAt:        time.Now(),
+	}
+	if confirmed {
+		userTOTP.ConfirmedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	}
+	return userTOTP, secret
+}
+
+/* invalidTOTPCode returns a well formed code that is not accepted for the secret around now */
+func invalidTOTPCode(t *testing.T, secret string, now time.Time) string {
+	for _, candidate := range []string{"000000", "111111", "222222", "333333"} {
+		if _, err := auth.ValidateTOTPCode(secret, candidate, now); err != nil {
+			return candidate
+		}
+	}
+	t.Fatal("cannot find an invalid totp code")
+	return ""
+}
+
+/* expectUseMFAChallengeTx runs the second factor check of UseMFAChallengeTx against the mock store */
+func expectUseMFAChallengeTx(t *testing.T, store *mockdb.MockStore, challengeID uuid.UUID) {
+	store.EXPECT().
+		UseMFAChallengeTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.UseMFAChallengeTxParams) error {
+			require.Equal(t, challengeID, arg.ChallengeID)
+			verified, err := arg.VerifySecondFactor(store)
+			if err != nil {
+				return err
+			}
+			if !verified {
+				return db.ErrSecondFactorInvalid
+			}
+			return nil
+		})
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	confirmedTOTP, _ := generateDummyUserTOTP(t, user.Username, true)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuth(t, request, authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					UpsertUnconfirmedUserTOTP(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpsertUnconfirmedUserTOTPParams) (db.UserTotp, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.SecretCiphertext)
+						return db.UserTotp{Username: arg.Username, SecretCiphertext: arg.SecretCiphertext}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp EnrollTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.OTPAuthURI, "otpauth://totp/")
+				require.Contains(t, rsp.OTPAuthURI, "secret="+rsp.Secret)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuth(t, request, authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(confirmedTOTP, nil)
+				store.EXPECT().
+					UpsertUnconfirmedUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:      "Unauthorized",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuth(t, request, authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/api/user/mfa/totp/enroll"
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.Authenticator)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	pendingTOTP, secret := generateDummyUserTOTP(t, user.Username, false)
+	confirmedTOTP, _ := generateDummyUserTOTP(t, user.Username, true)
+
+	now := time.Now()
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+	codeStep := now.Unix() / 30
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"code": code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(pendingTOTP, nil)
+				store.EXPECT().
+					ConfirmTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ConfirmTOTPTxParams) (db.UserTotp, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, codeStep, arg.Step)
+						require.Len(t, arg.RecoveryCodeHashes, totpRecoveryCodeCount)
+						return confirmedTOTP, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ConfirmTOTPResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, totpRecoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{
+				"code": invalidTOTPCode(t, secret, now),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(pendingTOTP, nil)
+				store.EXPECT().
+					ConfirmTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			body: gin.H{
+				"code": code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			body: gin.H{
+				"code": code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(confirmedTOTP, nil)
+				store.EXPECT().
+					ConfirmTOTPTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "MalformedCode",
+			body: gin.H{
+				"code": "12ab",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/mfa/totp/confirm"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoginUserWithMFA(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	userTOTP, secret := generateDummyUserTOTP(t, user.Username, true)
+
+	now := time.Now()
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+	codeStep := now.Unix() / 30
+
+	challengeToken, err := util.RandomToken(mfaChallengeTokenSize)
+	require.NoError(t, err)
+
+	challenge := db.MfaChallenge{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		TokenHash: util.HashToken(challengeToken),
+		ExpiresAt: time.Now().Add(time.Minute),
+		CreatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTOTP, nil)
+				store.EXPECT().
+					UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Eq(db.UpdateUserTOTPLastUsedStepParams{
+						Username:     user.Username,
+						LastUsedStep: codeStep,
+					})).
+					Times(1).
+					Return(int64(1), nil)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp LoginUserAccountResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"recovery_code":   "ABCDE-FGHJK",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					UseTOTPRecoveryCode(gomock.Any(), gomock.Eq(db.UseTOTPRecoveryCodeParams{
+						Username: user.Username,
+						CodeHash: util.HashToken("abcdefghjk"),
+					})).
+					Times(1).
+					Return(int64(1), nil)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UsedRecoveryCode",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"recovery_code":   "abcde-fghjk",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					UseTOTPRecoveryCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					IncrementMFAChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTOTP, nil)
+				store.EXPECT().
+					UpdateUserTOTPLastUsedStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+				store.EXPECT().
+					IncrementMFAChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).
+					Times(1)
+				expectUseMFAChallengeTx(t, store, challenge.ID)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyFailedAttempts",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				lockedChallenge := challenge
+				lockedChallenge.FailedAttempts = maxMFAChallengeFailedAttempts
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(lockedChallenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ChallengeClaimedConcurrently",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"recovery_code":   "abcde-fghjk",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(challenge, nil)
+				store.EXPECT().
+					UseMFAChallengeTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ErrMFAChallengeUsed)
+				/* Test the recovery code is not consumed when another request claimed the challenge */
+				store.EXPECT().
+					UseTOTPRecoveryCode(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					IncrementMFAChallengeFailedAttempts(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredChallenge",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredChallenge := challenge
+				expiredChallenge.ExpiresAt = time.Now().Add(-time.Second)
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(expiredChallenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UsedChallenge",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				usedChallenge := challenge
+				usedChallenge.IsUsed = true
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(challenge.TokenHash)).
+					Times(1).
+					Return(usedChallenge, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownChallenge",
+			body: gin.H{
+				"challenge_token": "unknownChallenge",
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Eq(util.HashToken("unknownChallenge"))).
+					Times(1).
+					Return(db.MfaChallenge{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingSecondFactor",
+			body: gin.H{
+				"challenge_token": challengeToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"challenge_token": challengeToken,
+				"code":            code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetMFAChallengeByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.MfaChallenge{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/login/mfa"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestMFAWithoutEncryptionKey(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	/* Without a TOTP encryption key the server starts and MFA answers 503 */
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetSessionById(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, id uuid.UUID) (db.Session, error) {
+			return db.Session{ID: id, FamilyID: id, ExpiresAt: time.Now().Add(time.Hour)}, nil
+		})
+	store.EXPECT().
+		GetUserTOTP(gomock.Any(), gomock.Any()).
+		Times(0)
+	store.EXPECT().
+		GetMFAChallengeByHash(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server, err := NewServer(store, util.Config{
+		TokenSymmetricKey:    "01234567890123456789012345678901",
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	})
+	require.NoError(t, err)
+	require.Nil(t, server.SecretCipher)
+
+	requests := []struct {
+		url  string
+		body gin.H
+	}{
+		{url: "/api/user/mfa/totp/enroll", body: gin.H{}},
+		{url: "/api/user/mfa/totp/confirm", body: gin.H{"code": "123456"}},
+		{url: "/api/user/login/mfa", body: gin.H{"challenge_token": "token", "code": "123456"}},
+	}
+	for _, req := range requests {
+		data, err := json.Marshal(req.body)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, req.url, bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.Router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusServiceUnavailable, recorder.Code, req.url)
+	}
+}
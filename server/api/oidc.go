@@ -0,0 +1,504 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/oidc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultOIDCProviderName       = "oidc"
+	defaultOIDCLoginStateDuration = 10 * time.Minute
+	oidcStateSize                 = 32
+	oidcNonceSize                 = 32
+	oidcPasswordSize              = 32
+	maxOIDCUsernameLength         = 30
+	maxOIDCUsernameAttempts       = 5
+)
+
+/* Errors returned to clients when an identity cannot be turned into an account */
+var (
+	errOIDCEmailRequired = errors.New("the provider did not share an email address")
+	errOIDCAccountExists = errors.New("an account with this email address already exists, sign in and link the provider to it")
+	errOIDCAlreadyLinked = errors.New("this identity is already linked to an account")
+)
+
+type OIDCProviderRequest struct {
+	Provider string `uri:"provider" binding:"required,alphanum"`
+}
+
+type FinishOIDCLoginRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+	// InviteCode is required when a new account is created while registration is invite-only
+	InviteCode string `json:"invite_code"`
+}
+
+type FinishOIDCLinkRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string    `json:"authorization_url"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type UserIdentityResponse struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func GetUserIdentityResponse(identity db.UserIdentity) UserIdentityResponse {
+	return UserIdentityResponse{
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
+
+/* StartOIDCLogin returns the URL that signs the user in with the provider */
+func (server *Server) StartOIDCLogin(ctx *gin.Context) {
+	server.startOIDCFlow(ctx, pgtype.Text{}, "StartOIDCLogin")
+}
+
+/*
+StartOIDCLink returns the URL that links an identity of the provider to the signed in user.
+The flow is finished with FinishOIDCLink by the same user, the public callback does not accept it.
+*/
+func (server *Server) StartOIDCLink(ctx *gin.Context) {
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "StartOIDCLink")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	server.startOIDCFlow(ctx, pgtype.Text{String: authenticationPayload.Username, Valid: true}, "StartOIDCLink")
+}
+
+/* startOIDCFlow stores the state, nonce and PKCE code verifier of a new authorization request */
+func (server *Server) startOIDCFlow(ctx *gin.Context, linkUsername pgtype.Text, pointOfFailure string) {
+	provider, ok := server.getOIDCProvider(ctx, pointOfFailure)
+	if !ok {
+		return
+	}
+
+	state, err := util.RandomToken(oidcStateSize)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+	nonce, err := util.RandomToken(oidcNonceSize)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+	codeVerifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(codeVerifier))
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	duration := server.Configurations.OIDCLoginStateDuration
+	if duration <= 0 {
+		duration = defaultOIDCLoginStateDuration
+	}
+
+	loginState, err := server.DataStore.CreateOIDCLoginState(ctx, db.CreateOIDCLoginStateParams{
+		StateHash:    util.HashToken(state),
+		Provider:     provider.Name(),
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		LinkUsername: linkUsername,
+		ExpiresAt:    time.Now().Add(duration),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, OIDCAuthorizationResponse{
+		AuthorizationURL: authorizationURL,
+		ExpiresAt:        loginState.ExpiresAt,
+	})
+}
+
+/*
+FinishOIDCLogin redeems the authorization code the provider redirected back with.
+Known identities sign in to their account and new identities get a new account unless their email address
+is already taken. Link requests are refused here since nothing ties them to the caller.
+*/
+func (server *Server) FinishOIDCLogin(ctx *gin.Context) {
+	var req FinishOIDCLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	provider, ok := server.getOIDCProvider(ctx, "FinishOIDCLogin")
+	if !ok {
+		return
+	}
+
+	loginState, ok := server.consumeOIDCLoginState(ctx, provider, req.State, "FinishOIDCLogin")
+	if !ok {
+		return
+	}
+
+	if loginState.LinkUsername.Valid {
+		logger.LogError("oidc link state redeemed at the login callback", "FinishOIDCLogin")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	var user db.User
+	identity, err := server.DataStore.GetUserIdentity(ctx, db.GetUserIdentityParams{
+		Provider: provider.Name(),
+		Subject:  claims.Subject,
+	})
+	switch {
+	case err == nil:
+		user, err = server.DataStore.GetUserByUsername(ctx, identity.Username)
+		if err != nil {
+			logger.LogError(err.Error(), "FinishOIDCLogin")
+			server.InternalServerError(ctx)
+			return
+		}
+	case errors.Is(err, util.ErrRecordNotFound):
+		user, ok = server.createOIDCUser(ctx, provider.Name(), claims, req.InviteCode)
+		if !ok {
+			return
+		}
+	default:
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	// accounts with two-factor authentication get a challenge instead of a session
+	mfaEnabled, ok := server.isTOTPEnabled(ctx, user.Username, "FinishOIDCLogin")
+	if !ok {
+		return
+	}
+	if mfaEnabled {
+		server.createMFAChallenge(ctx, user, "FinishOIDCLogin")
+		return
+	}
+
+	rsp, ok := server.createLoginSession(ctx, user, "FinishOIDCLogin")
+	if !ok {
+		return
+	}
+	server.ReturnOK(ctx, rsp)
+}
+
+/*
+FinishOIDCLink redeems the authorization code of a link request and attaches the identity to the signed in user.
+The request has to come from the user that started the link, so a link URL sent to someone else cannot attach
+their identity to the account that started it.
+*/
+func (server *Server) FinishOIDCLink(ctx *gin.Context) {
+	var req FinishOIDCLinkRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLink")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "FinishOIDCLink")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	provider, ok := server.getOIDCProvider(ctx, "FinishOIDCLink")
+	if !ok {
+		return
+	}
+
+	loginState, ok := server.consumeOIDCLoginState(ctx, provider, req.State, "FinishOIDCLink")
+	if !ok {
+		return
+	}
+
+	if !loginState.LinkUsername.Valid || !isSameUsername(loginState.LinkUsername.String, authenticationPayload.Username) {
+		logger.LogError("oidc link state was not started by the authenticated user", "FinishOIDCLink")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLink")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	server.linkOIDCIdentity(ctx, provider.Name(), loginState.LinkUsername.String, claims)
+}
+
+/*
+consumeOIDCLoginState redeems the state of an authorization request of the provider. The state can only be used once,
+whether or not the code exchange succeeds. It writes the error response and returns false on failure.
+*/
+func (server *Server) consumeOIDCLoginState(ctx *gin.Context, provider *oidc.Provider, state string, pointOfFailure string) (db.OidcLoginState, bool) {
+	loginState, err := server.DataStore.ConsumeOIDCLoginState(ctx, util.HashToken(state))
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.UnauthorizedError(ctx)
+			return db.OidcLoginState{}, false
+		}
+		server.InternalServerError(ctx)
+		return db.OidcLoginState{}, false
+	}
+
+	if loginState.Provider != provider.Name() {
+		logger.LogError("oidc login state belongs to another provider", pointOfFailure)
+		server.UnauthorizedError(ctx)
+		return db.OidcLoginState{}, false
+	}
+
+	if loginState.ExpiresAt.Before(time.Now()) {
+		logger.LogError("oidc login state expired", pointOfFailure)
+		server.UnauthorizedError(ctx)
+		return db.OidcLoginState{}, false
+	}
+	return loginState, true
+}
+
+/* getOIDCProvider writes a 404 response and returns false when the provider in the path is not configured */
+func (server *Server) getOIDCProvider(ctx *gin.Context, pointOfFailure string) (*oidc.Provider, bool) {
+	var req OIDCProviderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.BadRequestError(ctx)
+		return nil, false
+	}
+
+	provider, ok := server.OIDCProviders[req.Provider]
+	if !ok {
+		logger.LogError(fmt.Sprintf("oidc provider %q is not configured", req.Provider), pointOfFailure)
+		server.NotFoundError(ctx)
+		return nil, false
+	}
+	return provider, true
+}
+
+/* linkOIDCIdentity attaches the identity to the user that started the link request */
+func (server *Server) linkOIDCIdentity(ctx *gin.Context, providerName string, username string, claims *oidc.IDTokenClaims) {
+	identity, err := server.DataStore.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+		Username: username,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLink")
+		if util.ErrorCode(err) == util.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errOIDCAlreadyLinked))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetUserIdentityResponse(identity))
+}
+
+/*
+createOIDCUser creates an account for a new identity, it writes the error response and returns false on failure.
+Accounts are never linked by email address alone, the owner of an existing account has to sign in and link the provider.
+*/
+func (server *Server) createOIDCUser(ctx *gin.Context, providerName string, claims *oidc.IDTokenClaims, inviteCode string) (db.User, bool) {
+	if !server.checkRegistrationAllowed(ctx, inviteCode, "FinishOIDCLogin") {
+		return db.User{}, false
+	}
+
+	if claims.Email == "" {
+		logger.LogError(errOIDCEmailRequired.Error(), "FinishOIDCLogin")
+		ctx.JSON(http.StatusBadRequest, errorResponse(errOIDCEmailRequired))
+		return db.User{}, false
+	}
+
+	_, err := server.DataStore.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		logger.LogError(errOIDCAccountExists.Error(), "FinishOIDCLogin")
+		ctx.JSON(http.StatusConflict, errorResponse(errOIDCAccountExists))
+		return db.User{}, false
+	}
+	if !errors.Is(err, util.ErrRecordNotFound) {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.InternalServerError(ctx)
+		return db.User{}, false
+	}
+
+	username, err := server.generateOIDCUsername(ctx, claims)
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.InternalServerError(ctx)
+		return db.User{}, false
+	}
+
+	// the account has no usable password until the user sets one with a password reset
+	randomPassword, err := util.RandomToken(oidcPasswordSize)
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.InternalServerError(ctx)
+		return db.User{}, false
+	}
+	hashedPassword, err := server.PasswordHasher.Hash(randomPassword)
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		server.InternalServerError(ctx)
+		return db.User{}, false
+	}
+
+	firstName, lastName := oidcUserNames(claims)
+	var user db.User
+	var verificationToken string
+	_, err = server.DataStore.CreateUserTx(ctx, db.CreateUserTxParams{
+		CreateNewUserParams: db.CreateNewUserParams{
+			Username:  username,
+			Password:  hashedPassword,
+			Email:     claims.Email,
+			FirstName: firstName,
+			LastName:  lastName,
+		},
+		AfterCreate: func(querier db.Querier, createdUser db.User) error {
+			user = createdUser
+			if err := server.consumeInvite(ctx, querier, inviteCode); err != nil {
+				return err
+			}
+
+			_, err := querier.CreateUserIdentity(ctx, db.CreateUserIdentityParams{
+				Username: createdUser.Username,
+				Provider: providerName,
+				Subject:  claims.Subject,
+				Email:    claims.Email,
+			})
+			if err != nil {
+				return err
+			}
+
+			// an address the provider has verified does not need to be verified again
+			if claims.EmailVerified {
+				user, err = querier.MarkUserEmailAsVerified(ctx, createdUser.Username)
+				return err
+			}
+			verificationToken, err = server.createVerificationToken(ctx, querier, createdUser)
+			return err
+		},
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "FinishOIDCLogin")
+		if errors.Is(err, errInvalidInvite) {
+			ctx.JSON(http.StatusForbidden, errorResponse(errInvalidInvite))
+			return db.User{}, false
+		}
+		if util.ErrorCode(err) == util.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errOIDCAccountExists))
+			return db.User{}, false
+		}
+		server.InternalServerError(ctx)
+		return db.User{}, false
+	}
+
+	// the email is sent once the account is committed, the user can ask for another one when it fails
+	if verificationToken != "" {
+		if err := server.mailVerificationToken(ctx, user, verificationToken); err != nil {
+			logger.LogError(err.Error(), "FinishOIDCLogin")
+		}
+	}
+	return user, true
+}
+
+/* generateOIDCUsername derives a free alphanumeric username from the claims, adding a random suffix when it is taken */
+func (server *Server) generateOIDCUsername(ctx *gin.Context, claims *oidc.IDTokenClaims) (string, error) {
+	base := ""
+	for _, candidate := range []string{claims.PreferredUsername, strings.Split(claims.Email, "@")[0], claims.GivenName + claims.FamilyName} {
+		base = alphanumeric(candidate)
+		if base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > maxOIDCUsernameLength {
+		base = base[:maxOIDCUsernameLength]
+	}
+
+	username := base
+	for attempt := 0; attempt < maxOIDCUsernameAttempts; attempt++ {
+		_, err := server.DataStore.GetUserByUsername(ctx, username)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		suffix, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		username = fmt.Sprintf("%s%04d", base, suffix.Int64())
+	}
+	return "", fmt.Errorf("no free username found for %q", base)
+}
+
+func alphanumeric(value string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+/* oidcUserNames returns the first and last name of the claims, splitting the full name when the parts are missing */
+func oidcUserNames(claims *oidc.IDTokenClaims) (string, string) {
+	if claims.GivenName != "" || claims.FamilyName != "" {
+		return claims.GivenName, claims.FamilyName
+	}
+	firstName, lastName, _ := strings.Cut(strings.TrimSpace(claims.Name), " ")
+	return firstName, strings.TrimSpace(lastName)
+}
@@ -0,0 +1,717 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/oidc"
+	"github.com/Oabraham1/open-blogger/server/oidc/oidctest"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testOIDCProvider     = "test"
+	testOIDCClientID     = "test-client"
+	testOIDCClientSecret = "test-secret"
+	testOIDCRedirectURL  = "http://localhost:3000/oidc/callback"
+)
+
+/* newOIDCTestServer creates a test server with a provider registered at a fake in-process issuer */
+func newOIDCTestServer(t *testing.T, store db.Store) (*Server, *oidctest.Issuer) {
+	issuer, err := oidctest.NewIssuer(testOIDCClientID, testOIDCClientSecret)
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+
+	provider, err := oidc.NewProvider(oidc.Config{
+		Name:         testOIDCProvider,
+		IssuerURL:    issuer.URL(),
+		ClientID:     testOIDCClientID,
+		ClientSecret: testOIDCClientSecret,
+		RedirectURL:  testOIDCRedirectURL,
+	}, nil)
+	require.NoError(t, err)
+
+	server := newTestServer(t, store)
+	server.OIDCProviders[testOIDCProvider] = provider
+	return server, issuer
+}
+
+/* authorizeTestOIDCLogin signs the user in at the issuer and returns the code, state and stored login state of the flow */
+func authorizeTestOIDCLogin(t *testing.T, server *Server, issuer *oidctest.Issuer, user oidctest.User) (string, string, db.OidcLoginState) {
+	issuer.SetUser(user)
+
+	state, err := util.RandomToken(oidcStateSize)
+	require.NoError(t, err)
+	nonce, err := util.RandomToken(oidcNonceSize)
+	require.NoError(t, err)
+	codeVerifier, err := oidc.GenerateCodeVerifier()
+	require.NoError(t, err)
+
+	authorizationURL, err := server.OIDCProviders[testOIDCProvider].AuthCodeURL(context.Background(), state, nonce, oidc.CodeChallengeS256(codeVerifier))
+	require.NoError(t, err)
+	code, returnedState, err := issuer.Authorize(authorizationURL)
+	require.NoError(t, err)
+	require.Equal(t, state, returnedState)
+
+	loginState := db.OidcLoginState{
+		StateHash:    util.HashToken(state),
+		Provider:     testOIDCProvider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(time.Minute),
+		CreatedAt:    time.Now(),
+	}
+	return code, state, loginState
+}
+
+func TestStartOIDCLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server, _ := newOIDCTestServer(t, store)
+
+	var stored db.CreateOIDCLoginStateParams
+	store.EXPECT().
+		CreateOIDCLoginState(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateOIDCLoginStateParams) (db.OidcLoginState, error) {
+			stored = arg
+			return db.OidcLoginState{StateHash: arg.StateHash, Provider: arg.Provider, ExpiresAt: arg.ExpiresAt}, nil
+		})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/user/oidc/test/login", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp OIDCAuthorizationResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(defaultOIDCLoginStateDuration), rsp.ExpiresAt, time.Second)
+
+	/* Only hashes of the state are stored and the challenge belongs to the stored code verifier */
+	authorizationURL, err := url.Parse(rsp.AuthorizationURL)
+	require.NoError(t, err)
+	query := authorizationURL.Query()
+	require.Equal(t, testOIDCProvider, stored.Provider)
+	require.Equal(t, util.HashToken(query.Get("state")), stored.StateHash)
+	require.Equal(t, stored.Nonce, query.Get("nonce"))
+	require.Equal(t, oidc.CodeChallengeS256(stored.CodeVerifier), query.Get("code_challenge"))
+	require.False(t, stored.LinkUsername.Valid)
+}
+
+func TestStartOIDCLoginWithUnknownProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateOIDCLoginState(gomock.Any(), gomock.Any()).
+		Times(0)
+	server, _ := newOIDCTestServer(t, store)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/user/oidc/unknown/login", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestStartOIDCLink(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateOIDCLoginState(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateOIDCLoginStateParams) (db.OidcLoginState, error) {
+			require.Equal(t, pgtype.Text{String: user.Username, Valid: true}, arg.LinkUsername)
+			return db.OidcLoginState{StateHash: arg.StateHash, Provider: arg.Provider, ExpiresAt: arg.ExpiresAt}, nil
+		})
+	server, _ := newOIDCTestServer(t, store)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/user/oidc/test/link", nil)
+	require.NoError(t, err)
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	/* Linking requires a signed in user */
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/api/user/oidc/test/link", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestFinishOIDCLogin(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	issuerUser := oidctest.User{
+		Subject:           "subject-1",
+		Email:             "oidc@email.com",
+		EmailVerified:     true,
+		GivenName:         "oidc",
+		FamilyName:        "user",
+		PreferredUsername: "oidc.user",
+	}
+	identity := db.UserIdentity{
+		Username: user.Username,
+		Provider: testOIDCProvider,
+		Subject:  issuerUser.Subject,
+		Email:    issuerUser.Email,
+	}
+	newUser := db.User{
+		Username:  "oidcuser",
+		Email:     issuerUser.Email,
+		FirstName: issuerUser.GivenName,
+		LastName:  issuerUser.FamilyName,
+		Role:      db.UserRoleAuthor,
+	}
+
+	testCases := []struct {
+		name string
+		// updateLoginState changes the stored login state before the callback
+		updateLoginState func(loginState *db.OidcLoginState)
+		// updateRequest changes the callback body after the user signed in
+		updateRequest func(body gin.H)
+		buildStubs    func(store *mockdb.MockStore, loginState db.OidcLoginState)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "LinkedIdentity",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Eq(db.GetUserIdentityParams{Provider: testOIDCProvider, Subject: issuerUser.Subject})).
+					Times(1).
+					Return(identity, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "NewUser",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserIdentity{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(issuerUser.Email)).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(newUser.Username)).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						require.Equal(t, newUser.Username, arg.Username)
+						require.Equal(t, newUser.Email, arg.Email)
+						require.Equal(t, newUser.FirstName, arg.FirstName)
+						require.Equal(t, newUser.LastName, arg.LastName)
+						require.NotEmpty(t, arg.Password)
+						err := arg.AfterCreate(store, newUser)
+						return db.CreateUserTxResult{User: newUser}, err
+					})
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Eq(db.CreateUserIdentityParams{
+						Username: newUser.Username,
+						Provider: testOIDCProvider,
+						Subject:  issuerUser.Subject,
+						Email:    issuerUser.Email,
+					})).
+					Times(1).
+					Return(db.UserIdentity{Username: newUser.Username}, nil)
+				store.EXPECT().
+					MarkUserEmailAsVerified(gomock.Any(), gomock.Eq(newUser.Username)).
+					Times(1).
+					Return(newUser, nil)
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(newUser.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body, newUser)
+			},
+		},
+		{
+			name: "EmailBelongsToAnotherAccount",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserIdentity{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(issuerUser.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errOIDCAccountExists.Error())
+			},
+		},
+		{
+			name: "MFAEnabled",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(identity, nil)
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username, ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+						return db.MfaChallenge{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp MFAChallengeResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.MFARequired)
+				require.NotEmpty(t, rsp.ChallengeToken)
+			},
+		},
+		{
+			/* A link started by someone else cannot be finished at the public callback */
+			name: "LinkState",
+			updateLoginState: func(loginState *db.OidcLoginState) {
+				loginState.LinkUsername = pgtype.Text{String: user.Username, Valid: true}
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownState",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(db.OidcLoginState{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredState",
+			updateLoginState: func(loginState *db.OidcLoginState) {
+				loginState.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "StateOfAnotherProvider",
+			updateLoginState: func(loginState *db.OidcLoginState) {
+				loginState.Provider = "other"
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCodeVerifier",
+			updateLoginState: func(loginState *db.OidcLoginState) {
+				codeVerifier, err := oidc.GenerateCodeVerifier()
+				require.NoError(t, err)
+				loginState.CodeVerifier = codeVerifier
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCode",
+			updateRequest: func(body gin.H) {
+				body["code"] = "forged-code"
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					GetUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingCode",
+			updateRequest: func(body gin.H) {
+				delete(body, "code")
+			},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.OidcLoginState{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server, issuer := newOIDCTestServer(t, store)
+
+			code, state, loginState := authorizeTestOIDCLogin(t, server, issuer, issuerUser)
+			if tc.updateLoginState != nil {
+				tc.updateLoginState(&loginState)
+			}
+			tc.buildStubs(store, loginState)
+
+			body := gin.H{
+				"code":  code,
+				"state": state,
+			}
+			if tc.updateRequest != nil {
+				tc.updateRequest(body)
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/api/user/oidc/test/callback", bytes.NewReader(data))
+			require.NoError(t, err)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestFinishOIDCLink(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	issuerUser := oidctest.User{
+		Subject:       "subject-1",
+		Email:         "oidc@email.com",
+		EmailVerified: true,
+	}
+	identity := db.UserIdentity{
+		Username: user.Username,
+		Provider: testOIDCProvider,
+		Subject:  issuerUser.Subject,
+		Email:    issuerUser.Email,
+	}
+
+	testCases := []struct {
+		name string
+		// linkUsername is the user that started the link, the request is signed in as the dummy user
+		linkUsername  pgtype.Text
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mockdb.MockStore, loginState db.OidcLoginState)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			linkUsername: pgtype.Text{String: user.Username, Valid: true},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Eq(db.CreateUserIdentityParams{
+						Username: user.Username,
+						Provider: testOIDCProvider,
+						Subject:  issuerUser.Subject,
+						Email:    issuerUser.Email,
+					})).
+					Times(1).
+					Return(identity, nil)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp UserIdentityResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, testOIDCProvider, rsp.Provider)
+				require.Equal(t, issuerUser.Email, rsp.Email)
+			},
+		},
+		{
+			name:         "AlreadyLinked",
+			linkUsername: pgtype.Text{String: user.Username, Valid: true},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserIdentity{}, util.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errOIDCAlreadyLinked.Error())
+			},
+		},
+		{
+			/* The link URL of another account, finished by the signed in user, is refused */
+			name:         "StartedByAnotherUser",
+			linkUsername: pgtype.Text{String: "attacker", Valid: true},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "LoginState",
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Eq(loginState.StateHash)).
+					Times(1).
+					Return(loginState, nil)
+				store.EXPECT().
+					CreateUserIdentity(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "NoAuthorization",
+			linkUsername: pgtype.Text{String: user.Username, Valid: true},
+			setupAuth:    func(t *testing.T, request *http.Request, server *Server) {},
+			buildStubs: func(store *mockdb.MockStore, loginState db.OidcLoginState) {
+				store.EXPECT().
+					ConsumeOIDCLoginState(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server, issuer := newOIDCTestServer(t, store)
+
+			code, state, loginState := authorizeTestOIDCLogin(t, server, issuer, issuerUser)
+			loginState.LinkUsername = tc.linkUsername
+			tc.buildStubs(store, loginState)
+
+			data, err := json.Marshal(gin.H{
+				"code":  code,
+				"state": state,
+			})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/api/user/oidc/test/link/callback", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.setupAuth != nil {
+				tc.setupAuth(t, request, server)
+			} else {
+				addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			}
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGenerateOIDCUsername(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	/* Taken usernames get a numeric suffix */
+	gomock.InOrder(
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Eq("janedoe")).
+			Times(1).
+			Return(db.User{Username: "janedoe"}, nil),
+		store.EXPECT().
+			GetUserByUsername(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.User{}, util.ErrRecordNotFound),
+	)
+	username, err := server.generateOIDCUsername(ctx, &oidc.IDTokenClaims{Email: "Jane.Doe@email.com"})
+	require.NoError(t, err)
+	require.Regexp(t, `^janedoe\d{4}$`, username)
+
+	/* Claims without usable characters fall back to a generic name */
+	store.EXPECT().
+		GetUserByUsername(gomock.Any(), gomock.Eq("user")).
+		Times(1).
+		Return(db.User{}, util.ErrRecordNotFound)
+	username, err = server.generateOIDCUsername(ctx, &oidc.IDTokenClaims{PreferredUsername: "ü.-", Email: "@email.com"})
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+}
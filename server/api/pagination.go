@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const defaultPageLimit = 20
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+/* PageRequest holds the query parameters of list endpoints, the cursor is the next_cursor of the previous page */
+type PageRequest struct {
+	Limit  int32  `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor"`
+}
+
+/* pageCursor is the sort key and ID of the last item of a page, clients only see it as an opaque string */
+type pageCursor struct {
+	Key string    `json:"k"`
+	ID  uuid.UUID `json:"i"`
+}
+
+/* page is a decoded PageRequest, the cursor key is null on the first page */
+type page struct {
+	Limit     int32
+	CursorKey pgtype.Text
+	CursorID  uuid.UUID
+}
+
+type ListPostsResponse struct {
+	Posts      []PostResponse `json:"posts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type ListCommentsResponse struct {
+	Comments   []CommentResponse `json:"comments"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func encodePageCursor(key string, id uuid.UUID) string {
+	data, _ := json.Marshal(pageCursor{Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(cursor string) (pageCursor, error) {
+	var decoded pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decoded, errInvalidCursor
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.ID == uuid.Nil {
+		return decoded, errInvalidCursor
+	}
+	return decoded, nil
+}
+
+/* bindPage reads the limit and cursor of the query string, it writes a 400 response and returns false when they are invalid */
+func (server *Server) bindPage(ctx *gin.Context, pointOfFailure string) (page, bool) {
+	var req PageRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.BadRequestError(ctx)
+		return page{}, false
+	}
+
+	result := page{Limit: req.Limit}
+	if result.Limit == 0 {
+		result.Limit = defaultPageLimit
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodePageCursor(req.Cursor)
+		if err != nil {
+			logger.LogError(err.Error(), pointOfFailure)
+			server.BadRequestError(ctx)
+			return page{}, false
+		}
+		result.CursorKey = pgtype.Text{String: cursor.Key, Valid: true}
+		result.CursorID = cursor.ID
+	}
+	return result, true
+}
+
+/* bindOrderedPage is bindPage for the lists sorted by ordered_at, whose cursor key is a timestamp */
+func (server *Server) bindOrderedPage(ctx *gin.Context, pointOfFailure string) (page, pgtype.Timestamptz, bool) {
+	result, ok := server.bindPage(ctx, pointOfFailure)
+	if !ok || !result.CursorKey.Valid {
+		return result, pgtype.Timestamptz{}, ok
+	}
+
+	orderedAt, err := time.Parse(time.RFC3339Nano, result.CursorKey.String)
+	if err != nil {
+		logger.LogError(errInvalidCursor.Error(), pointOfFailure)
+		server.BadRequestError(ctx)
+		return page{}, pgtype.Timestamptz{}, false
+	}
+	return result, pgtype.Timestamptz{Time: orderedAt, Valid: true}, true
+}
+
+/* encodeOrderedAt is the cursor key of the lists sorted by ordered_at */
+func encodeOrderedAt(orderedAt time.Time) string {
+	return orderedAt.UTC().Format(time.RFC3339Nano)
+}
+
+/* queryLimit is the number of rows to fetch, one more than the page holds tells whether there is a next page */
+func (p page) queryLimit() int32 {
+	return p.Limit + 1
+}
+
+/* GetListPostsResponse returns the posts of the page, with a cursor to the next page when more posts were fetched */
+func GetListPostsResponse(posts []db.Post, limit int32) ListPostsResponse {
+	return getListPostsResponse(posts, limit, func(post db.Post) string { return post.PublishedAt })
+}
+
+/* GetListDraftPostsResponse returns a page of drafts, which have no publication time and are paged by ordered_at */
+func GetListDraftPostsResponse(posts []db.Post, limit int32) ListPostsResponse {
+	return getListPostsResponse(posts, limit, func(post db.Post) string { return encodeOrderedAt(post.OrderedAt) })
+}
+
+func getListPostsResponse(posts []db.Post, limit int32, cursorKey func(post db.Post) string) ListPostsResponse {
+	rsp := ListPostsResponse{Posts: []PostResponse{}}
+	for i, post := range posts {
+		if i == int(limit) {
+			last := posts[i-1]
+			rsp.NextCursor = encodePageCursor(cursorKey(last), last.ID)
+			break
+		}
+		rsp.Posts = append(rsp.Posts, GetPostResponse(post))
+	}
+	return rsp
+}
+
+/* GetListCommentsResponse returns the comments of the page, with a cursor to the next page when more comments were fetched */
+func GetListCommentsResponse(comments []db.Comment, limit int32) ListCommentsResponse {
+	rsp := ListCommentsResponse{Comments: []CommentResponse{}}
+	for i, comment := range comments {
+		if i == int(limit) {
+			last := comments[i-1]
+			rsp.NextCursor = encodePageCursor(encodeOrderedAt(last.OrderedAt), last.ID)
+			break
+		}
+		rsp.Comments = append(rsp.Comments, GetCommentResponse(comment))
+	}
+	return rsp
+}
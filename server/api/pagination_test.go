@@ -0,0 +1,346 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func generateDummyPublishedPosts(t *testing.T, n int) []db.Post {
+	user, _ := generateDummyUser(t)
+	posts := []db.Post{}
+	for i := 0; i < n; i++ {
+		post := generateDummyPost(t, user)
+		post.ID = uuid.New()
+		post.Status = db.StatusPublished
+		post.PublishedAt = fmt.Sprintf("2024-01-%02d 10:00:00", n-i)
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+func TestPageCursor(t *testing.T) {
+	id := uuid.New()
+	cursor := encodePageCursor("2024-01-02 10:00:00", id)
+
+	decoded, err := decodePageCursor(cursor)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-02 10:00:00", decoded.Key)
+	require.Equal(t, id, decoded.ID)
+
+	/* Cursors are opaque and URL safe */
+	require.Equal(t, cursor, url.QueryEscape(cursor))
+
+	_, err = decodePageCursor("not a cursor")
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	_, err = decodePageCursor(encodePageCursor("2024-01-02 10:00:00", uuid.Nil))
+	require.ErrorIs(t, err, errInvalidCursor)
+}
+
+func TestGetPublishedPosts(t *testing.T) {
+	posts := generateDummyPublishedPosts(t, 3)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstPage",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Eq(db.ListPublishedPostsParams{PageLimit: defaultPageLimit + 1})).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 3)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "PageWithNextCursor",
+			query: "?limit=2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Eq(db.ListPublishedPostsParams{PageLimit: 3})).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 2)
+				require.Equal(t, posts[1].ID.String(), rsp.Posts[1].ID)
+
+				/* The cursor points at the last post of the page */
+				cursor, err := decodePageCursor(rsp.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, posts[1].PublishedAt, cursor.Key)
+				require.Equal(t, posts[1].ID, cursor.ID)
+			},
+		},
+		{
+			name:  "NextPage",
+			query: "?limit=2&cursor=" + encodePageCursor(posts[1].PublishedAt, posts[1].ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Eq(db.ListPublishedPostsParams{
+						CursorPublishedAt: pgtype.Text{String: posts[1].PublishedAt, Valid: true},
+						CursorID:          posts[1].ID,
+						PageLimit:         3,
+					})).
+					Times(1).
+					Return(posts[2:], nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 1)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: "?cursor=invalid",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "LimitTooLarge",
+			query: "?limit=101",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "ZeroLimitUsesDefault",
+			query: "?limit=0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Eq(db.ListPublishedPostsParams{PageLimit: defaultPageLimit + 1})).
+					Times(1).
+					Return([]db.Post{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/post/getPublished"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetCommentsByPostIDNextPage(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+
+	comments := []db.Comment{}
+	for i := 0; i < 2; i++ {
+		comment := generateDummyComment(t, user, post)
+		comment.ID = uuid.New()
+		comment.OrderedAt = time.Date(2024, 1, i+1, 10, 0, 0, 0, time.UTC)
+		comments = append(comments, comment)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+		Times(1).
+		Return(post, nil)
+	store.EXPECT().
+		ListCommentsByPostID(gomock.Any(), gomock.Eq(db.ListCommentsByPostIDParams{PostID: post.ID, PageLimit: 2})).
+		Times(1).
+		Return(comments, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/comment/getByPostID/%s?limit=1", post.ID), nil)
+	require.NoError(t, err)
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp ListCommentsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Comments, 1)
+
+	/* The cursor of comments is their ordered_at timestamp */
+	cursor, err := decodePageCursor(rsp.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-01T10:00:00Z", cursor.Key)
+	require.Equal(t, comments[0].ID, cursor.ID)
+}
+
+func TestGetListDraftPostsResponse(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	drafts := []db.Post{}
+	for i := 0; i < 2; i++ {
+		draft := generateDummyPost(t, user)
+		draft.ID = uuid.New()
+		draft.OrderedAt = time.Date(2024, 1, 2-i, 10, 0, 0, 0, time.UTC)
+		drafts = append(drafts, draft)
+	}
+
+	/* The cursor of drafts is their ordered_at timestamp, they have no publication time */
+	rsp := GetListDraftPostsResponse(drafts, 1)
+	require.Len(t, rsp.Posts, 1)
+
+	cursor, err := decodePageCursor(rsp.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, "2024-01-02T10:00:00Z", cursor.Key)
+	require.Equal(t, drafts[0].ID, cursor.ID)
+}
+
+func TestGetCommentsByPostIDCursor(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	cursorID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		cursor        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			cursor: encodePageCursor("2024-01-01T10:00:00.123456Z", cursorID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListCommentsByPostID(gomock.Any(), gomock.Eq(db.ListCommentsByPostIDParams{
+						PostID:          post.ID,
+						CursorOrderedAt: pgtype.Timestamptz{Time: time.Date(2024, 1, 1, 10, 0, 0, 123456000, time.UTC), Valid: true},
+						CursorID:        cursorID,
+						PageLimit:       defaultPageLimit + 1,
+					})).
+					Times(1).
+					Return([]db.Comment{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			/* The text stamps are no longer cursor keys */
+			name:   "TextStampCursor",
+			cursor: encodePageCursor("2024/01/01 10:00:00", cursorID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListCommentsByPostID(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/comment/getByPostID/%s?cursor=%s", post.ID, tc.cursor)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required,max=1024"`
+	NewPassword     string `json:"new_password" binding:"required,max=1024"`
+}
+
+func (server *Server) ChangePassword(ctx *gin.Context) {
+	var req ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "ChangePassword")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	// a stolen access token must not allow guessing the current password without limits
+	if !server.checkLoginAllowed(ctx, authenticationPayload.Username, "ChangePassword") {
+		return
+	}
+
+	user, err := server.DataStore.GetUserByUsername(ctx, authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	_, err = server.PasswordHasher.Verify(user.Password, req.CurrentPassword)
+	if err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		if errors.Is(err, util.ErrPasswordMismatch) {
+			server.recordLoginFailure(ctx, user.Username, "ChangePassword")
+			server.ForbiddenError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if err := server.PasswordPolicy.Validate(req.NewPassword, user.Username); err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.PasswordHasher.Hash(req.NewPassword)
+	if err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	sessionParams, rsp, ok := server.issueLoginTokens(ctx, user, "ChangePassword")
+	if !ok {
+		return
+	}
+
+	_, err = server.DataStore.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		HashedPassword:             hashedPassword,
+		CreateNewUserSessionParams: sessionParams,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.SessionCache.InvalidateUser(user.Username)
+	server.resetLoginFailures(ctx, user.Username, "ChangePassword")
+
+	csrfToken, err := server.setAuthCookies(ctx, rsp.AccessToken, rsp.ExpiresAt, rsp.RefreshToken, rsp.RefreshTokenExpiresAt)
+	if err != nil {
+		logger.LogError(err.Error(), "ChangePassword")
+		server.InternalServerError(ctx)
+		return
+	}
+	server.ReturnOK(ctx, rsp.withCookieAuth(csrfToken))
+}
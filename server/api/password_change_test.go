@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestChangePassword(t *testing.T) {
+	user, password := generateDummyUser(t)
+	newPassword := "correct horse battery staple"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"current_password": password,
+				"new_password":     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ChangePasswordTxParams) (db.ChangePasswordTxResult, error) {
+						require.NoError(t, util.VerifyPassword(arg.HashedPassword, newPassword))
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, arg.ID, arg.FamilyID)
+						return db.ChangePasswordTxResult{Session: db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "WrongCurrentPassword",
+			body: gin.H{
+				"current_password": "wrongPassword",
+				"new_password":     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "TooShort",
+			body: gin.H{
+				"current_password": password,
+				"new_password":     "short",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordTooShort.Error())
+			},
+		},
+		{
+			name: "CommonPassword",
+			body: gin.H{
+				"current_password": password,
+				"new_password":     "iloveyou",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordTooCommon.Error())
+			},
+		},
+		{
+			name: "ContainsUsername",
+			body: gin.H{
+				"current_password": password,
+				"new_password":     "my-" + user.Username + "-password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordContainsUsername.Error())
+			},
+		},
+		{
+			name: "MissingCurrentPassword",
+			body: gin.H{
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"current_password": password,
+				"new_password":     newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangePasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/password"
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireBodyMatchLoginResponse(t *testing.T, body *bytes.Buffer, user db.User) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp LoginUserAccountResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.SessionID)
+	require.NotEmpty(t, rsp.AccessToken)
+	require.NotEmpty(t, rsp.RefreshToken)
+	require.Equal(t, user.Username, rsp.UserAccount.Username)
+}
+
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, message string) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp gin.H
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.Contains(t, rsp["error"], message)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/lockout"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPasswordResetTokenDuration    = time.Hour
+	defaultPasswordResetRequestInterval  = time.Minute
+	defaultPasswordResetMaxRequestsPerIP = 10
+	// requests of a client IP are counted over this window
+	passwordResetRequestWindow = time.Hour
+	passwordResetTokenSize     = 32
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6,max=1024"`
+}
+
+func passwordResetIPKey(clientIP string) string {
+	return "password-reset-ip:" + clientIP
+}
+
+/*
+ForgotPassword emails a password reset link. The account lookup and the email are sent in the background,
+so the response is the same, and takes the same time, whether or not the email belongs to an account.
+*/
+func (server *Server) ForgotPassword(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "ForgotPassword")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	if !server.checkPasswordResetAllowed(ctx) {
+		return
+	}
+
+	server.runInBackground("ForgotPassword", func(taskCtx context.Context) error {
+		return server.sendPasswordResetEmail(taskCtx, req.Email)
+	})
+
+	server.ReturnOK(ctx, gin.H{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+/*
+checkPasswordResetAllowed counts the password reset request of the client IP. It writes a 429 response and
+returns false once the client IP has sent too many requests within the window.
+*/
+func (server *Server) checkPasswordResetAllowed(ctx *gin.Context) bool {
+	key := passwordResetIPKey(ctx.ClientIP())
+	retryAfter, err := server.LoginLimiter.RetryAfter(ctx, key)
+	if err != nil {
+		logger.LogError(err.Error(), "ForgotPassword")
+		server.InternalServerError(ctx)
+		return false
+	}
+	if retryAfter > 0 {
+		logger.LogError("password reset requested while blocked", "ForgotPassword")
+		server.TooManyRequestsError(ctx, retryAfter)
+		return false
+	}
+
+	maxRequests := server.Configurations.PasswordResetMaxRequestsPerIP
+	if maxRequests <= 0 {
+		maxRequests = defaultPasswordResetMaxRequestsPerIP
+	}
+	// every request counts, there is no backoff until the client IP is blocked for the rest of the window
+	_, err = server.LoginLimiter.RecordFailure(ctx, key, lockout.Policy{
+		MaxFailures:     maxRequests,
+		LockoutDuration: passwordResetRequestWindow,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "ForgotPassword")
+		server.InternalServerError(ctx)
+		return false
+	}
+	return true
+}
+
+/*
+sendPasswordResetEmail emails a reset link to the account of the email address. Nothing is sent for an unknown
+email address, or when the last link of the account was sent less than the request interval ago.
+*/
+func (server *Server) sendPasswordResetEmail(ctx context.Context, email string) error {
+	user, err := server.DataStore.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, util.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	interval := server.Configurations.PasswordResetRequestInterval
+	if interval <= 0 {
+		interval = defaultPasswordResetRequestInterval
+	}
+
+	latestToken, err := server.DataStore.GetLatestPasswordResetToken(ctx, user.Username)
+	if err != nil && !errors.Is(err, util.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && time.Since(latestToken.CreatedAt) < interval {
+		logger.LogError("password reset email requested too soon", "ForgotPassword")
+		return nil
+	}
+
+	token, err := util.RandomToken(passwordResetTokenSize)
+	if err != nil {
+		return err
+	}
+
+	duration := server.Configurations.PasswordResetTokenDuration
+	if duration <= 0 {
+		duration = defaultPasswordResetTokenDuration
+	}
+
+	_, err = server.DataStore.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(duration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return server.Mailer.SendEmail(ctx, mail.Email{
+		To:      []string{user.Email},
+		Subject: "Reset your Open Blogger password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. The link expires in %s and can only be used once.\n\n%s/reset-password?token=%s\n\nIf you did not ask for a password reset you can ignore this email.\n",
+			user.FirstName, duration, server.Configurations.AppBaseURL, token,
+		),
+	})
+}
+
+func (server *Server) ResetPassword(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "ResetPassword")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	resetToken, err := server.DataStore.GetPasswordResetTokenByHash(ctx, util.HashToken(req.Token))
+	if err != nil {
+		logger.LogError(err.Error(), "ResetPassword")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if resetToken.IsUsed {
+		logger.LogError("password reset token already used", "ResetPassword")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	if resetToken.ExpiresAt.Before(time.Now()) {
+		logger.LogError("password reset token expired", "ResetPassword")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	if err := server.PasswordPolicy.Validate(req.Password, resetToken.Username); err != nil {
+		logger.LogError(err.Error(), "ResetPassword")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := server.PasswordHasher.Hash(req.Password)
+	if err != nil {
+		logger.LogError(err.Error(), "ResetPassword")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	err = server.DataStore.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenID:        resetToken.ID,
+		Username:       resetToken.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "ResetPassword")
+		if errors.Is(err, db.ErrPasswordResetTokenUsed) {
+			server.UnauthorizedError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.SessionCache.InvalidateUser(resetToken.Username)
+	server.ReturnOK(ctx, gin.H{"message": "Password reset successfully"})
+}
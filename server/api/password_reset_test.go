@@ -0,0 +1,455 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var resetLinkPattern = regexp.MustCompile(`/reset-password\?token=([A-Za-z0-9_-]+)`)
+
+func TestForgotPassword(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	var storedTokenHash string
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestPasswordResetToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.PasswordResetToken{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(defaultPasswordResetTokenDuration), arg.ExpiresAt, time.Second)
+						storedTokenHash = arg.TokenHash
+						return db.PasswordResetToken{ID: uuid.New(), Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				emails := mailer.Emails()
+				require.Len(t, emails, 1)
+				require.Equal(t, []string{user.Email}, emails[0].To)
+
+				/* Only the hash of the emailed token is stored */
+				match := resetLinkPattern.FindStringSubmatch(emails[0].Body)
+				require.Len(t, match, 2)
+				require.NotEqual(t, match[1], storedTokenHash)
+				require.Equal(t, util.HashToken(match[1]), storedTokenHash)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{
+				"email": "unknown@email.com",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq("unknown@email.com")).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Emails())
+			},
+		},
+		{
+			name: "RequestedTooSoon",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestPasswordResetToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.PasswordResetToken{CreatedAt: time.Now().Add(-defaultPasswordResetRequestInterval / 2)}, nil)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				/* The response does not tell that the account exists */
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Emails())
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"email": "invalidEmail",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetLatestPasswordResetToken(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.PasswordResetToken{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PasswordResetToken{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, mailer *mail.MemoryMailer) {
+				/* The email is sent in the background, failures are only logged */
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, mailer.Emails())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/password/forgot"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			server.backgroundTasks.Wait()
+			tc.checkResponse(recorder, server.Mailer.(*mail.MemoryMailer))
+		})
+	}
+}
+
+func TestForgotPasswordRateLimitPerIP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUserByEmail(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.User{}, util.ErrRecordNotFound)
+
+	server := newTestServer(t, store)
+	server.Configurations.PasswordResetMaxRequestsPerIP = 2
+
+	data, err := json.Marshal(gin.H{"email": "unknown@email.com"})
+	require.NoError(t, err)
+
+	/* Test the client IP is blocked once it used up its requests */
+	for _, expectedCode := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/api/user/password/forgot", bytes.NewReader(data))
+		require.NoError(t, err)
+
+		server.Router.ServeHTTP(recorder, request)
+		require.Equal(t, expectedCode, recorder.Code)
+	}
+	server.backgroundTasks.Wait()
+
+	/* Test another client IP is not blocked */
+	store.EXPECT().
+		GetUserByEmail(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.User{}, util.ErrRecordNotFound)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/user/password/forgot", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.RemoteAddr = "192.0.2.10:1234"
+
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	server.backgroundTasks.Wait()
+}
+
+func TestResetPassword(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	token, err := util.RandomToken(passwordResetTokenSize)
+	require.NoError(t, err)
+
+	resetToken := db.PasswordResetToken{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	newPassword := "newPassword123"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"token":    token,
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(resetToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) error {
+						require.Equal(t, resetToken.ID, arg.TokenID)
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.VerifyPassword(arg.HashedPassword, newPassword))
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{
+				"token":    "unknownToken",
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(util.HashToken("unknownToken"))).
+					Times(1).
+					Return(db.PasswordResetToken{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "UsedToken",
+			body: gin.H{
+				"token":    token,
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				usedToken := resetToken
+				usedToken.IsUsed = true
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(usedToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{
+				"token":    token,
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredToken := resetToken
+				expiredToken.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(expiredToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentlyUsedToken",
+			body: gin.H{
+				"token":    token,
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(resetToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ErrPasswordResetTokenUsed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CommonPassword",
+			body: gin.H{
+				"token":    token,
+				"password": "password123",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(resetToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordTooCommon.Error())
+			},
+		},
+		{
+			name: "PasswordContainsUsername",
+			body: gin.H{
+				"token":    token,
+				"password": "my " + user.Username + " password",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(resetToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordContainsUsername.Error())
+			},
+		},
+		{
+			name: "PasswordTooShort",
+			body: gin.H{
+				"token":    token,
+				"password": "short",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"token":    token,
+				"password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPasswordResetTokenByHash(gomock.Any(), gomock.Eq(resetToken.TokenHash)).
+					Times(1).
+					Return(resetToken, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// Marshal body data to JSON
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/password/reset"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const personalAccessTokenSize = 32
+
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RevokePersonalAccessTokenRequest struct {
+	ID string `uri:"id" binding:"required,min=1"`
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePersonalAccessTokenResponse struct {
+	// Token is only returned once, the server keeps a hash of it
+	Token               string                      `json:"token"`
+	PersonalAccessToken PersonalAccessTokenResponse `json:"personal_access_token"`
+}
+
+func GetPersonalAccessTokenResponse(token db.PersonalAccessToken) PersonalAccessTokenResponse {
+	return PersonalAccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		ExpiresAt:  timestamptzPointer(token.ExpiresAt),
+		LastUsedAt: timestamptzPointer(token.LastUsedAt),
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+func timestamptzPointer(timestamp pgtype.Timestamptz) *time.Time {
+	if !timestamp.Valid {
+		return nil
+	}
+	return &timestamp.Time
+}
+
+func (server *Server) CreatePersonalAccessToken(ctx *gin.Context) {
+	var req CreatePersonalAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "CreatePersonalAccessToken")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	scopes := []string{}
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !auth.IsValidScope(scope) {
+			logger.LogError("invalid personal access token scope "+scope, "CreatePersonalAccessToken")
+			server.BadRequestError(ctx)
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	expiresAt := pgtype.Timestamptz{}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			logger.LogError("personal access token expiry is in the past", "CreatePersonalAccessToken")
+			server.BadRequestError(ctx)
+			return
+		}
+		expiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "CreatePersonalAccessToken")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	randomToken, err := util.RandomToken(personalAccessTokenSize)
+	if err != nil {
+		logger.LogError(err.Error(), "CreatePersonalAccessToken")
+		server.InternalServerError(ctx)
+		return
+	}
+	token := auth.PersonalAccessTokenPrefix + randomToken
+
+	personalAccessToken, err := server.DataStore.CreatePersonalAccessToken(ctx, db.CreatePersonalAccessTokenParams{
+		Username:  authenticationPayload.Username,
+		Name:      req.Name,
+		TokenHash: util.HashToken(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "CreatePersonalAccessToken")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, CreatePersonalAccessTokenResponse{
+		Token:               token,
+		PersonalAccessToken: GetPersonalAccessTokenResponse(personalAccessToken),
+	})
+}
+
+func (server *Server) GetPersonalAccessTokens(ctx *gin.Context) {
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "GetPersonalAccessTokens")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	personalAccessTokens, err := server.DataStore.GetPersonalAccessTokensByUsername(ctx, authenticationPayload.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "GetPersonalAccessTokens")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp := []PersonalAccessTokenResponse{}
+	for _, personalAccessToken := range personalAccessTokens {
+		rsp = append(rsp, GetPersonalAccessTokenResponse(personalAccessToken))
+	}
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) RevokePersonalAccessToken(ctx *gin.Context) {
+	var req RevokePersonalAccessTokenRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "RevokePersonalAccessToken")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	tokenID, err := uuid.Parse(req.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "RevokePersonalAccessToken")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "RevokePersonalAccessToken")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	// tokens of other users are reported as not found
+	rows, err := server.DataStore.DeletePersonalAccessToken(ctx, db.DeletePersonalAccessTokenParams{
+		ID:       tokenID,
+		Username: authenticationPayload.Username,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "RevokePersonalAccessToken")
+		server.InternalServerError(ctx)
+		return
+	}
+	if rows == 0 {
+		logger.LogError("personal access token not found", "RevokePersonalAccessToken")
+		server.NotFoundError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, gin.H{"message": "Personal access token revoked successfully"})
+}
+
+/*
+verifyPersonalAccessToken looks up the hash of a personal access token and returns a payload carrying
+the current role of its owner and the scopes of the token
+*/
+func verifyPersonalAccessToken(ctx context.Context, store db.Querier, token string) (*auth.AuthPayload, error) {
+	personalAccessToken, err := store.GetPersonalAccessTokenByHash(ctx, util.HashToken(token))
+	if err != nil {
+		if errors.Is(err, util.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if personalAccessToken.ExpiresAt.Valid && personalAccessToken.ExpiresAt.Time.Before(time.Now()) {
+		return nil, auth.ErrExpiredToken
+	}
+
+	// failing to record the last use must not reject a valid token
+	err = store.UpdatePersonalAccessTokenLastUsedAt(ctx, personalAccessToken.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "verifyPersonalAccessToken")
+	}
+
+	scopes := []auth.Scope{}
+	for _, scope := range personalAccessToken.Scopes {
+		scopes = append(scopes, auth.Scope(scope))
+	}
+	return &auth.AuthPayload{
+		ID:        personalAccessToken.ID,
+		Username:  personalAccessToken.Username,
+		Role:      string(personalAccessToken.Role),
+		IssuedAt:  personalAccessToken.CreatedAt,
+		ExpiredAt: personalAccessToken.ExpiresAt.Time,
+		Scopes:    scopes,
+	}, nil
+}
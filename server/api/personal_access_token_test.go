@@ -0,0 +1,500 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func generateDummyPersonalAccessToken(t *testing.T, username string, scopes ...string) (db.GetPersonalAccessTokenByHashRow, string) {
+	randomToken, err := util.RandomToken(personalAccessTokenSize)
+	require.NoError(t, err)
+	token := auth.PersonalAccessTokenPrefix + randomToken
+	return db.GetPersonalAccessTokenByHashRow{
+		ID:        uuid.New(),
+		Username:  username,
+		Name:      "ci",
+		TokenHash: util.HashToken(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		Role:      db.UserRoleAuthor,
+	}, token
+}
+
+func addPersonalAccessToken(request *http.Request, token string) {
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+}
+
+func TestCreatePersonalAccessToken(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	var storedTokenHash string
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"name":       "ci",
+				"scopes":     []string{"posts:write", "read", "posts:write"},
+				"expires_at": expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePersonalAccessTokenParams) (db.PersonalAccessToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, "ci", arg.Name)
+						require.Equal(t, []string{"posts:write", "read"}, arg.Scopes)
+						require.True(t, arg.ExpiresAt.Valid)
+						require.WithinDuration(t, expiresAt, arg.ExpiresAt.Time, time.Second)
+						storedTokenHash = arg.TokenHash
+						return db.PersonalAccessToken{
+							ID:        uuid.New(),
+							Username:  arg.Username,
+							Name:      arg.Name,
+							TokenHash: arg.TokenHash,
+							Scopes:    arg.Scopes,
+							ExpiresAt: arg.ExpiresAt,
+							CreatedAt: time.Now(),
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				var rsp CreatePersonalAccessTokenResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+
+				/* Only the hash of the returned token is stored */
+				require.True(t, strings.HasPrefix(rsp.Token, auth.PersonalAccessTokenPrefix))
+				require.Equal(t, util.HashToken(rsp.Token), storedTokenHash)
+				require.Equal(t, "ci", rsp.PersonalAccessToken.Name)
+				require.NotNil(t, rsp.PersonalAccessToken.ExpiresAt)
+				require.Nil(t, rsp.PersonalAccessToken.LastUsedAt)
+			},
+		},
+		{
+			name: "NoExpiry",
+			body: gin.H{
+				"name":   "ci",
+				"scopes": []string{"read"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreatePersonalAccessTokenParams) (db.PersonalAccessToken, error) {
+						require.False(t, arg.ExpiresAt.Valid)
+						return db.PersonalAccessToken{ID: uuid.New(), Name: arg.Name, Scopes: arg.Scopes}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidScope",
+			body: gin.H{
+				"name":   "ci",
+				"scopes": []string{"users:manage_roles"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoScopes",
+			body: gin.H{
+				"name":   "ci",
+				"scopes": []string{},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiryInThePast",
+			body: gin.H{
+				"name":       "ci",
+				"scopes":     []string{"read"},
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"name":   "ci",
+				"scopes": []string{"read"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PersonalAccessToken{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/api/user/tokens"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetPersonalAccessTokens(t *testing.T) {
+	user, _ := generateDummyUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetPersonalAccessTokensByUsername(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.PersonalAccessToken{
+			{ID: uuid.New(), Name: "ci", TokenHash: "secretHash", Scopes: []string{"read"}},
+			{ID: uuid.New(), Name: "backup", TokenHash: "otherSecretHash", Scopes: []string{"read"}, LastUsedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+		}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/api/user/tokens", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	/* Token hashes are never returned */
+	require.NotContains(t, recorder.Body.String(), "secretHash")
+
+	var rsp []PersonalAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp, 2)
+	require.Nil(t, rsp[0].LastUsedAt)
+	require.NotNil(t, rsp[1].LastUsedAt)
+}
+
+func TestRevokePersonalAccessToken(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	tokenID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		id            string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   tokenID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeletePersonalAccessToken(gomock.Any(), gomock.Eq(db.DeletePersonalAccessTokenParams{ID: tokenID, Username: user.Username})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   tokenID.String(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeletePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   "invalid",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeletePersonalAccessToken(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/user/tokens/%s", tc.id)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPersonalAccessTokenAuthentication(t *testing.T) {
+	testCases := []struct {
+		name          string
+		scopes        []string
+		expiresAt     pgtype.Timestamptz
+		middleware    gin.HandlerFunc
+		buildStubs    func(store *mockdb.MockStore, row db.GetPersonalAccessTokenByHashRow)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "PermissionWithScope",
+			scopes:     []string{"posts:write"},
+			middleware: RequirePermission(auth.PermissionWritePosts),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "PermissionWithoutScope",
+			scopes:     []string{"read"},
+			middleware: RequirePermission(auth.PermissionWritePosts),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "PermissionWithoutAnyScope",
+			scopes:     []string{"read", "posts:write", "comments:write"},
+			middleware: RequirePermission(auth.PermissionDeleteAnyPost),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "Scope",
+			scopes:     []string{"read"},
+			middleware: RequireScope(auth.ScopeRead),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "MissingScope",
+			scopes:     []string{"posts:write"},
+			middleware: RequireScope(auth.ScopeRead),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "SessionRequired",
+			scopes:     []string{"read", "posts:write", "comments:write"},
+			middleware: RequireSession(),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "Expired",
+			scopes:     []string{"read"},
+			expiresAt:  pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+			middleware: RequireScope(auth.ScopeRead),
+			buildStubs: func(store *mockdb.MockStore, row db.GetPersonalAccessTokenByHashRow) {
+				store.EXPECT().
+					GetPersonalAccessTokenByHash(gomock.Any(), gomock.Eq(row.TokenHash)).
+					Times(1).
+					Return(row, nil)
+				store.EXPECT().
+					UpdatePersonalAccessTokenLastUsedAt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "Revoked",
+			scopes:     []string{"read"},
+			middleware: RequireScope(auth.ScopeRead),
+			buildStubs: func(store *mockdb.MockStore, row db.GetPersonalAccessTokenByHashRow) {
+				store.EXPECT().
+					GetPersonalAccessTokenByHash(gomock.Any(), gomock.Eq(row.TokenHash)).
+					Times(1).
+					Return(db.GetPersonalAccessTokenByHashRow{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "InternalError",
+			scopes:     []string{"read"},
+			middleware: RequireScope(auth.ScopeRead),
+			buildStubs: func(store *mockdb.MockStore, row db.GetPersonalAccessTokenByHashRow) {
+				store.EXPECT().
+					GetPersonalAccessTokenByHash(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetPersonalAccessTokenByHashRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			row, token := generateDummyPersonalAccessToken(t, "testUser", tc.scopes...)
+			row.ExpiresAt = tc.expiresAt
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store, row)
+			} else {
+				store.EXPECT().
+					GetPersonalAccessTokenByHash(gomock.Any(), gomock.Eq(row.TokenHash)).
+					Times(1).
+					Return(row, nil)
+				store.EXPECT().
+					UpdatePersonalAccessTokenLastUsedAt(gomock.Any(), gomock.Eq(row.ID)).
+					Times(1).
+					Return(nil)
+			}
+
+			server := newTestServer(t, store)
+			authPath := "/auth"
+			server.Router.GET(
+				authPath,
+				AuthenticationMiddleware(server.Authenticator, server.SessionCache, server.DataStore),
+				tc.middleware,
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addPersonalAccessToken(request, token)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestPersonalAccessTokenCannotManageTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	row, token := generateDummyPersonalAccessToken(t, "testUser", "read", "posts:write", "comments:write")
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetPersonalAccessTokenByHash(gomock.Any(), gomock.Eq(row.TokenHash)).
+		Times(1).
+		Return(row, nil)
+	store.EXPECT().
+		UpdatePersonalAccessTokenLastUsedAt(gomock.Any(), gomock.Eq(row.ID)).
+		Times(1).
+		Return(nil)
+	store.EXPECT().
+		CreatePersonalAccessToken(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body := bytes.NewReader([]byte(`{"name":"escalated","scopes":["read"]}`))
+	request, err := http.NewRequest(http.MethodPost, "/api/user/tokens", body)
+	require.NoError(t, err)
+
+	addPersonalAccessToken(request, token)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
@@ -13,11 +14,12 @@ import (
 )
 
 type CreatePostRequest struct {
-	Title    string `json:"title" binding:"required"`
-	Body     string `json:"body" binding:"required"`
-	Username string `json:"username" binding:"required,alphanum"`
-	Status   string `json:"status" binding:"required,oneof=draft published"`
-	Category string `json:"category" binding:"required"`
+	Title    string   `json:"title" binding:"required"`
+	Body     string   `json:"body" binding:"required"`
+	Username string   `json:"username" binding:"required,alphanum"`
+	Status   string   `json:"status" binding:"required,oneof=draft published"`
+	Category string   `json:"category" binding:"required"`
+	Tags     []string `json:"tags" binding:"max=10"`
 }
 
 type CreateNewCommentRequest struct {
@@ -31,22 +33,37 @@ type GetPostByIDRequest struct {
 }
 
 type GetPostsByCategoryRequest struct {
-	Category string `uri:"category" binding:"required,alphanum,min=1"`
+	Category string `uri:"category" binding:"required,max=100"`
+}
+
+/* GetPostsByCategoryQuery adds the posts of every subcategory when include_descendants is true */
+type GetPostsByCategoryQuery struct {
+	IncludeDescendants bool `form:"include_descendants"`
 }
 
 type GetPostsByUsernameRequest struct {
 	Username string `uri:"username" binding:"required,alphanum,min=1"`
 }
 
+/*
+UpdatePostBodyRequest replaces the body of a post, a new title also changes the slug and the old permalink redirects.
+The tags of the post are replaced when tags is set, leaving it out keeps them. The version is the one the edit is
+based on, it is only read when the request has no If-Match header.
+*/
 type UpdatePostBodyRequest struct {
-	ID       string `json:"post_id" binding:"required"`
-	Body     string `json:"body" binding:"required"`
-	Username string `json:"username" binding:"required,alphanum"`
+	ID       string   `json:"post_id" binding:"required"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body" binding:"required"`
+	Username string   `json:"username" binding:"required,alphanum"`
+	Tags     []string `json:"tags" binding:"max=10"`
+	Version  int32    `json:"version" binding:"omitempty,min=1"`
 }
 
+/* UpdatePostStatusRequest publishes a post, the version is only read when the request has no If-Match header */
 type UpdatePostStatusRequest struct {
 	ID       string `json:"post_id" binding:"required"`
 	Username string `json:"username" binding:"required,alphanum"`
+	Version  int32  `json:"version" binding:"omitempty,min=1"`
 }
 
 type GetCommentsByPostIDRequest struct {
@@ -61,15 +78,23 @@ type DeletePostRequest struct {
 	ID string `uri:"id" binding:"required,min=1"`
 }
 
+type GetPostByPermalinkRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+	Slug     string `uri:"slug" binding:"required,max=100"`
+}
+
 type PostResponse struct {
-	ID           string `json:"id"`
-	Title        string `json:"title"`
-	Body         string `json:"body"`
-	Username     string `json:"username"`
-	Status       string `json:"status"`
-	Category     string `json:"category"`
-	LastModified string `json:"last_modified"`
-	PublishedAt  string `json:"published_at"`
+	ID           string   `json:"id"`
+	Title        string   `json:"title"`
+	Body         string   `json:"body"`
+	Username     string   `json:"username"`
+	Slug         string   `json:"slug"`
+	Status       string   `json:"status"`
+	Category     string   `json:"category"`
+	Tags         []string `json:"tags"`
+	LastModified string   `json:"last_modified"`
+	PublishedAt  string   `json:"published_at"`
+	Version      int32    `json:"version"`
 }
 
 type CommentResponse struct {
@@ -86,10 +111,13 @@ func GetPostResponse(post db.Post) PostResponse {
 		Title:        post.Title,
 		Body:         post.Body,
 		Username:     post.Username,
+		Slug:         post.Slug,
 		Status:       string(post.Status),
 		Category:     post.Category,
+		Tags:         []string{},
 		LastModified: post.LastModified,
 		PublishedAt:  post.PublishedAt,
+		Version:      post.Version,
 	}
 }
 
@@ -119,7 +147,7 @@ func (server *Server) CreateNewPost(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "CreateNewPost")
 		server.UnauthorizedError(ctx)
 		return
@@ -133,26 +161,54 @@ func (server *Server) CreateNewPost(ctx *gin.Context) {
 	}
 	dbStatus := db.Status(status)
 
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		logger.LogError(err.Error(), "CreateNewPost")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	category, err := server.DataStore.GetCategoryBySlug(ctx, util.Slugify(req.Category))
+	if err != nil {
+		logger.LogError(err.Error(), "CreateNewPost")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errUnknownCategory))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if dbStatus == db.StatusPublished && !server.requireVerifiedEmail(ctx, req.Username, "CreateNewPost") {
+		return
+	}
+
+	// store the username as it was registered, the request may spell it in another case
 	arg := db.CreateNewPostParams{
 		Title:    req.Title,
 		Body:     req.Body,
-		Username: req.Username,
+		Username: authenticationPayload.Username,
 		Status:   dbStatus,
-		Category: req.Category,
+		Category: category.Slug,
 	}
 
 	if dbStatus == db.StatusPublished {
 		arg.PublishedAt = time.Now().Format("2006-01-02 15:04:05")
 	}
 
-	post, err := server.DataStore.CreateNewPost(ctx, arg)
+	result, err := server.DataStore.CreatePostTx(ctx, db.CreatePostTxParams{
+		CreateNewPostParams: arg,
+		Tags:                tags,
+	})
 	if err != nil {
 		logger.LogError(err.Error(), "CreateNewPost")
 		server.InternalServerError(ctx)
 		return
 	}
 
-	server.ReturnOK(ctx, GetPostResponse(post))
+	rsp := GetPostResponse(result.Post)
+	rsp.Tags = result.Tags
+	server.ReturnOK(ctx, rsp)
 }
 
 func (server *Server) CreateNewComment(ctx *gin.Context) {
@@ -171,12 +227,16 @@ func (server *Server) CreateNewComment(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "CreateNewComment")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
+	if !server.requireVerifiedEmail(ctx, req.Username, "CreateNewComment") {
+		return
+	}
+
 	// convert postId string int32
 	postId, err := uuid.Parse(req.PostID)
 	if err != nil {
@@ -200,7 +260,7 @@ func (server *Server) CreateNewComment(ctx *gin.Context) {
 	arg := db.CreateNewCommentParams{
 		PostID:   postId,
 		Body:     req.Body,
-		Username: req.Username,
+		Username: authenticationPayload.Username,
 	}
 
 	comment, err := server.DataStore.CreateNewComment(ctx, arg)
@@ -213,6 +273,27 @@ func (server *Server) CreateNewComment(ctx *gin.Context) {
 	server.ReturnOK(ctx, GetCommentResponse(comment))
 }
 
+/* GetPublishedPosts returns the published posts of all authors, newest first */
+func (server *Server) GetPublishedPosts(ctx *gin.Context) {
+	page, ok := server.bindPage(ctx, "GetPublishedPosts")
+	if !ok {
+		return
+	}
+
+	posts, err := server.DataStore.ListPublishedPosts(ctx, db.ListPublishedPostsParams{
+		CursorPublishedAt: page.CursorKey,
+		CursorID:          page.CursorID,
+		PageLimit:         page.queryLimit(),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "GetPublishedPosts")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.returnListPosts(ctx, posts, page.Limit, "GetPublishedPosts")
+}
+
 func (server *Server) GetPostsByCategory(ctx *gin.Context) {
 	var req GetPostsByCategoryRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
@@ -221,21 +302,48 @@ func (server *Server) GetPostsByCategory(ctx *gin.Context) {
 		return
 	}
 
-	posts, err := server.DataStore.GetPostsByCategory(ctx, req.Category)
+	var query GetPostsByCategoryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logger.LogError(err.Error(), "GetPostsByCategory")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	slug, err := categorySlug(req.Category)
 	if err != nil {
 		logger.LogError(err.Error(), "GetPostsByCategory")
-		server.InternalServerError(ctx)
+		server.BadRequestError(ctx)
 		return
 	}
 
-	var rsp []PostResponse
-	for _, post := range posts {
-		if post.Status == db.StatusPublished {
-			rsp = append(rsp, GetPostResponse(post))
-		}
+	page, ok := server.bindPage(ctx, "GetPostsByCategory")
+	if !ok {
+		return
 	}
 
-	server.ReturnOK(ctx, rsp)
+	var posts []db.Post
+	if query.IncludeDescendants {
+		posts, err = server.DataStore.ListPublishedPostsInCategoryTree(ctx, db.ListPublishedPostsInCategoryTreeParams{
+			Category:          slug,
+			CursorPublishedAt: page.CursorKey,
+			CursorID:          page.CursorID,
+			PageLimit:         page.queryLimit(),
+		})
+	} else {
+		posts, err = server.DataStore.ListPublishedPostsByCategory(ctx, db.ListPublishedPostsByCategoryParams{
+			Category:          slug,
+			CursorPublishedAt: page.CursorKey,
+			CursorID:          page.CursorID,
+			PageLimit:         page.queryLimit(),
+		})
+	}
+	if err != nil {
+		logger.LogError(err.Error(), "GetPostsByCategory")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.returnListPosts(ctx, posts, page.Limit, "GetPostsByCategory")
 }
 
 func (server *Server) GetPostById(ctx *gin.Context) {
@@ -264,7 +372,65 @@ func (server *Server) GetPostById(ctx *gin.Context) {
 		return
 	}
 
-	server.ReturnOK(ctx, GetPostResponse(post))
+	rsp := []PostResponse{GetPostResponse(post)}
+	if err := server.addPostTags(ctx, rsp); err != nil {
+		logger.LogError(err.Error(), "GetPostById")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	setPostETag(ctx, post)
+	server.ReturnOK(ctx, rsp[0])
+}
+
+/* postPermalink is the path of the permalink of a post */
+func postPermalink(post db.Post) string {
+	return "/api/@" + post.Username + "/" + post.Slug
+}
+
+/* GetPostByPermalink returns a published post by its author and slug, drafts are only reachable by their ID */
+func (server *Server) GetPostByPermalink(ctx *gin.Context) {
+	var req GetPostByPermalinkRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "GetPostByPermalink")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	post, err := server.DataStore.GetPublishedPostByUsernameAndSlug(ctx, db.GetPublishedPostByUsernameAndSlugParams{
+		Username: req.Username,
+		Slug:     req.Slug,
+	})
+	if errors.Is(err, util.ErrRecordNotFound) {
+		// the previous slugs of a renamed post redirect to its current permalink
+		post, err = server.DataStore.GetPublishedPostByPreviousSlug(ctx, db.GetPublishedPostByPreviousSlugParams{
+			Username: req.Username,
+			Slug:     req.Slug,
+		})
+		if err == nil {
+			ctx.Redirect(http.StatusMovedPermanently, postPermalink(post))
+			return
+		}
+	}
+	if err != nil {
+		logger.LogError(err.Error(), "GetPostByPermalink")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp := []PostResponse{GetPostResponse(post)}
+	if err := server.addPostTags(ctx, rsp); err != nil {
+		logger.LogError(err.Error(), "GetPostByPermalink")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	setPostETag(ctx, post)
+	server.ReturnOK(ctx, rsp[0])
 }
 
 func (server *Server) GetPublishedPostsByUsername(ctx *gin.Context) {
@@ -275,6 +441,11 @@ func (server *Server) GetPublishedPostsByUsername(ctx *gin.Context) {
 		return
 	}
 
+	page, ok := server.bindPage(ctx, "GetPublishedPostsByUsername")
+	if !ok {
+		return
+	}
+
 	// find user by username
 	_, err := server.DataStore.GetUserByUsername(ctx, req.Username)
 	if err != nil {
@@ -287,21 +458,20 @@ func (server *Server) GetPublishedPostsByUsername(ctx *gin.Context) {
 		return
 	}
 
-	posts, err := server.DataStore.GetPostsByUserName(ctx, req.Username)
+	posts, err := server.DataStore.ListPostsByUsername(ctx, db.ListPostsByUsernameParams{
+		Username:          req.Username,
+		Status:            db.StatusPublished,
+		CursorPublishedAt: page.CursorKey,
+		CursorID:          page.CursorID,
+		PageLimit:         page.queryLimit(),
+	})
 	if err != nil {
 		logger.LogError(err.Error(), "GetPublishedPostsByUsername")
 		server.InternalServerError(ctx)
 		return
 	}
 
-	var rsp []PostResponse
-	for _, post := range posts {
-		if post.Status == db.StatusPublished {
-			rsp = append(rsp, GetPostResponse(post))
-		}
-	}
-
-	server.ReturnOK(ctx, rsp)
+	server.returnListPosts(ctx, posts, page.Limit, "GetPublishedPostsByUsername")
 }
 
 func (server *Server) GetDraftPostsByUsername(ctx *gin.Context) {
@@ -312,6 +482,11 @@ func (server *Server) GetDraftPostsByUsername(ctx *gin.Context) {
 		return
 	}
 
+	page, cursorOrderedAt, ok := server.bindOrderedPage(ctx, "GetDraftPostsByUsername")
+	if !ok {
+		return
+	}
+
 	// get auth payload
 	authenticationPayload := server.GetAuthPayload(ctx)
 	if authenticationPayload == nil {
@@ -320,30 +495,25 @@ func (server *Server) GetDraftPostsByUsername(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "GetDraftPostsByUsername")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
-	posts, err := server.DataStore.GetPostsByUserName(ctx, req.Username)
+	posts, err := server.DataStore.ListDraftPostsByUsername(ctx, db.ListDraftPostsByUsernameParams{
+		Username:        req.Username,
+		CursorOrderedAt: cursorOrderedAt,
+		CursorID:        page.CursorID,
+		PageLimit:       page.queryLimit(),
+	})
 	if err != nil {
 		logger.LogError(err.Error(), "GetDraftPostsByUsername")
-		if errors.Is(err, util.ErrRecordNotFound) {
-			server.NotFoundError(ctx)
-			return
-		}
 		server.InternalServerError(ctx)
 		return
 	}
 
-	var rsp []PostResponse
-	for _, post := range posts {
-		if post.Status == db.StatusDraft {
-			rsp = append(rsp, GetPostResponse(post))
-		}
-	}
-	server.ReturnOK(ctx, rsp)
+	server.returnListPostsResponse(ctx, GetListDraftPostsResponse(posts, page.Limit), "GetDraftPostsByUsername")
 }
 
 func (server *Server) UpdatePostBody(ctx *gin.Context) {
@@ -362,7 +532,7 @@ func (server *Server) UpdatePostBody(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "UpdatePostBody")
 		server.UnauthorizedError(ctx)
 		return
@@ -376,34 +546,61 @@ func (server *Server) UpdatePostBody(ctx *gin.Context) {
 		return
 	}
 
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		logger.LogError(err.Error(), "UpdatePostBody")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	post, err := server.DataStore.GetPostById(ctx, postId)
 	if err != nil {
 		logger.LogError(err.Error(), "UpdatePostBody")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
 		server.InternalServerError(ctx)
 		return
 	}
 
-	if authenticationPayload.Username != post.Username {
+	if !isSameUsername(authenticationPayload.Username, post.Username) {
 		logger.LogError("authentication payload username does not match post username", "UpdatePostBody")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
-	arg := db.UpdatePostBodyParams{
-		ID:           postId,
-		Body:         req.Body,
-		Username:     req.Username,
-		LastModified: time.Now().Format("2006-01-02 15:04:05"),
+	if !server.checkPostVersion(ctx, post, req.Version, "UpdatePostBody") {
+		return
+	}
+
+	arg := db.UpdatePostTxParams{
+		UpdatePostBodyParams: db.UpdatePostBodyParams{
+			ID:           postId,
+			Body:         req.Body,
+			Username:     req.Username,
+			LastModified: time.Now().Format("2006-01-02 15:04:05"),
+			Version:      post.Version,
+		},
+		Title: req.Title,
+		Tags:  tags,
 	}
 
-	post, err = server.DataStore.UpdatePostBody(ctx, arg)
+	result, err := server.DataStore.UpdatePostTx(ctx, arg)
 	if err != nil {
 		logger.LogError(err.Error(), "UpdatePostBody")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.lostUpdateError(ctx, postId, post.Version, "UpdatePostBody")
+			return
+		}
 		server.InternalServerError(ctx)
 		return
 	}
 
-	server.ReturnOK(ctx, GetPostResponse(post))
+	rsp := GetPostResponse(result.Post)
+	rsp.Tags = result.Tags
+	setPostETag(ctx, result.Post)
+	server.ReturnOK(ctx, rsp)
 }
 
 func (server *Server) UpdatePostStatus(ctx *gin.Context) {
@@ -422,12 +619,16 @@ func (server *Server) UpdatePostStatus(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "UpdatePostStatus")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
+	if !server.requireVerifiedEmail(ctx, req.Username, "UpdatePostStatus") {
+		return
+	}
+
 	// convert postId string to uuid
 	postId, err := uuid.Parse(req.ID)
 	if err != nil {
@@ -447,27 +648,37 @@ func (server *Server) UpdatePostStatus(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != post.Username {
+	if !isSameUsername(authenticationPayload.Username, post.Username) {
 		logger.LogError("authentication payload username does not match post username", "UpdatePostStatus")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
+	if !server.checkPostVersion(ctx, post, req.Version, "UpdatePostStatus") {
+		return
+	}
+
 	arg := db.UpdatePostStatusParams{
 		ID:          postId,
 		Status:      db.StatusPublished,
 		Username:    req.Username,
 		PublishedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Version:     post.Version,
 	}
 
-	post, err = server.DataStore.UpdatePostStatus(ctx, arg)
+	updated, err := server.DataStore.UpdatePostStatus(ctx, arg)
 	if err != nil {
 		logger.LogError(err.Error(), "UpdatePostStatus")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.lostUpdateError(ctx, postId, post.Version, "UpdatePostStatus")
+			return
+		}
 		server.InternalServerError(ctx)
 		return
 	}
 
-	server.ReturnOK(ctx, GetPostResponse(post))
+	setPostETag(ctx, updated)
+	server.ReturnOK(ctx, GetPostResponse(updated))
 }
 
 func (server *Server) GetCommentsByPostID(ctx *gin.Context) {
@@ -486,6 +697,11 @@ func (server *Server) GetCommentsByPostID(ctx *gin.Context) {
 		return
 	}
 
+	page, cursorOrderedAt, ok := server.bindOrderedPage(ctx, "GetCommentsByPostID")
+	if !ok {
+		return
+	}
+
 	// find post
 	_, err = server.DataStore.GetPostById(ctx, postId)
 	if err != nil {
@@ -498,19 +714,19 @@ func (server *Server) GetCommentsByPostID(ctx *gin.Context) {
 		return
 	}
 
-	comments, err := server.DataStore.GetCommentsByPostID(ctx, postId)
+	comments, err := server.DataStore.ListCommentsByPostID(ctx, db.ListCommentsByPostIDParams{
+		PostID:          postId,
+		CursorOrderedAt: cursorOrderedAt,
+		CursorID:        page.CursorID,
+		PageLimit:       page.queryLimit(),
+	})
 	if err != nil {
 		logger.LogError(err.Error(), "GetCommentsByPostID")
 		server.InternalServerError(ctx)
 		return
 	}
 
-	var rsp []CommentResponse
-	for _, comment := range comments {
-		rsp = append(rsp, GetCommentResponse(comment))
-	}
-
-	server.ReturnOK(ctx, rsp)
+	server.ReturnOK(ctx, GetListCommentsResponse(comments, page.Limit))
 }
 
 func (server *Server) DeleteComment(ctx *gin.Context) {
@@ -549,7 +765,7 @@ func (server *Server) DeleteComment(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != comment.Username {
+	if !isSameUsername(authenticationPayload.Username, comment.Username) {
 		logger.LogError("authentication payload username does not match comment username", "DeleteComment")
 		server.UnauthorizedError(ctx)
 		return
@@ -601,38 +817,33 @@ func (server *Server) DeletePost(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != post.Username {
+	if !isSameUsername(authenticationPayload.Username, post.Username) {
 		logger.LogError("authentication payload username does not match post username", "DeletePost")
 		server.UnauthorizedError(ctx)
 		return
 	}
 
-	// Delete all comments by postID
-	comments, err := server.DataStore.GetCommentsByPostID(ctx, postId)
-	if err != nil {
-		logger.LogError(err.Error(), "DeletePost")
-		if errors.Is(err, util.ErrRecordNotFound) {
-			server.NotFoundError(ctx)
-			return
-		}
-		server.InternalServerError(ctx)
+	if !server.deletePostAndComments(ctx, postId, "DeletePost") {
 		return
 	}
-	for _, comment := range comments {
-		err = server.DataStore.DeleteCommentByID(ctx, comment.ID)
-		if err != nil {
-			logger.LogError(err.Error(), "DeletePost")
-			server.InternalServerError(ctx)
-			return
-		}
+
+	server.ReturnOK(ctx, gin.H{"message": "Post deleted successfully"})
+}
+
+/* deletePostAndComments deletes a post with all of its comments, it writes the error response and returns false on failure */
+func (server *Server) deletePostAndComments(ctx *gin.Context, postId uuid.UUID, pointOfFailure string) bool {
+	err := server.DataStore.DeleteCommentsByPostID(ctx, postId)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return false
 	}
 
 	err = server.DataStore.DeletePostByID(ctx, postId)
 	if err != nil {
-		logger.LogError(err.Error(), "DeletePost")
+		logger.LogError(err.Error(), pointOfFailure)
 		server.InternalServerError(ctx)
-		return
+		return false
 	}
-
-	server.ReturnOK(ctx, gin.H{"message": "Post deleted successfully"})
+	return true
 }
@@ -2,11 +2,13 @@ package api
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -25,7 +27,7 @@ func generateDummyPost(t *testing.T, user db.User) db.Post {
 		Title:    "Test Post",
 		Body:     "This is a test post",
 		Username: user.Username,
-		Category: "testCategory",
+		Category: "test-category",
 	}
 }
 
@@ -70,34 +72,64 @@ func TestCreatePost(t *testing.T) {
 					Status:   post.Status,
 				}
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), arg).
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(post.Category)).
 					Times(1).
-					Return(post, nil)
+					Return(db.Category{Slug: post.Category}, nil)
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Eq(db.CreatePostTxParams{CreateNewPostParams: arg})).
+					Times(1).
+					Return(db.CreatePostTxResult{Post: post, Tags: []string{}}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
 		{
-			name: "Unauthorized",
+			name: "UsernameInAnotherCase",
 			body: gin.H{
 				"title":    post.Title,
 				"body":     post.Body,
 				"category": post.Category,
-				"username": post.Username,
+				"username": strings.ToUpper(post.Username),
 				"status":   post.Status,
 			},
-			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuth(t, request, authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.CreateNewPostParams{
 					Title:    post.Title,
 					Body:     post.Body,
-					Username: post.Username,
+					Username: user.Username,
 					Category: post.Category,
 					Status:   post.Status,
 				}
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), arg).
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(post.Category)).
+					Times(1).
+					Return(db.Category{Slug: post.Category}, nil)
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Eq(db.CreatePostTxParams{CreateNewPostParams: arg})).
+					Times(1).
+					Return(db.CreatePostTxResult{Post: post, Tags: []string{}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Unauthorized",
+			body: gin.H{
+				"title":    post.Title,
+				"body":     post.Body,
+				"category": post.Category,
+				"username": post.Username,
+				"status":   post.Status,
+			},
+			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -118,7 +150,7 @@ func TestCreatePost(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), gomock.Any()).
+					CreatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -139,7 +171,7 @@ func TestCreatePost(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), gomock.Any()).
+					CreatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -160,7 +192,7 @@ func TestCreatePost(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), gomock.Any()).
+					CreatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -181,7 +213,7 @@ func TestCreatePost(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewPost(gomock.Any(), gomock.Any()).
+					CreatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -376,20 +408,26 @@ func TestGetPostByCategory(t *testing.T) {
 			category: post.Category,
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByCategory(gomock.Any(), gomock.Eq(post.Category)).
+					ListPublishedPostsByCategory(gomock.Any(), gomock.Eq(db.ListPublishedPostsByCategoryParams{Category: post.Category, PageLimit: defaultPageLimit + 1})).
 					Times(1).
 					Return([]db.Post{post}, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []PostResponse
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, post.Body, posts[0].Body)
+				require.Len(t, rsp.Posts, 1)
+				require.Equal(t, post.Body, rsp.Posts[0].Body)
+				require.Empty(t, rsp.NextCursor)
 			},
 		},
 		{
@@ -397,20 +435,21 @@ func TestGetPostByCategory(t *testing.T) {
 			category: post.Category,
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByCategory(gomock.Any(), gomock.Any()).
+					ListPublishedPostsByCategory(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Post{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []db.Post
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, len([]db.Post{}), len(posts))
+				require.NotNil(t, rsp.Posts)
+				require.Empty(t, rsp.Posts)
 			},
 		},
 		{
@@ -418,7 +457,7 @@ func TestGetPostByCategory(t *testing.T) {
 			category: "$",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByCategory(gomock.Any(), gomock.Any()).
+					ListPublishedPostsByCategory(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -454,6 +493,7 @@ func TestGetPostById(t *testing.T) {
 	post := generateDummyPost(t, user)
 	post.Status = "published"
 	post.LastModified = time.Now().Format("2006-01-02 15:04:05")
+	post.Version = 2
 
 	testCases := []struct {
 		name          string
@@ -469,6 +509,10 @@ func TestGetPostById(t *testing.T) {
 					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Eq([]uuid.UUID{post.ID})).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{{PostID: post.ID, Slug: "golang"}}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -480,6 +524,9 @@ func TestGetPostById(t *testing.T) {
 				err = json.Unmarshal(data, &postResponse)
 				require.NoError(t, err)
 				require.Equal(t, post.Body, postResponse.Body)
+				require.Equal(t, []string{"golang"}, postResponse.Tags)
+				require.Equal(t, post.Version, postResponse.Version)
+				require.Equal(t, `"2"`, recorder.Header().Get("ETag"))
 			},
 		},
 		{
@@ -531,6 +578,203 @@ func TestGetPostById(t *testing.T) {
 	}
 }
 
+func TestGetPostByPermalink(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	post.Status = db.StatusPublished
+	post.Slug = "test-post"
+
+	testCases := []struct {
+		name          string
+		username      string
+		slug          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: post.Username,
+			slug:     post.Slug,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Eq(db.GetPublishedPostByUsernameAndSlugParams{Username: post.Username, Slug: post.Slug})).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Eq([]uuid.UUID{post.ID})).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp PostResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, post.ID.String(), rsp.ID)
+				require.Equal(t, post.Slug, rsp.Slug)
+			},
+		},
+		{
+			name:     "PreviousSlug",
+			username: post.Username,
+			slug:     "old-test-post",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetPublishedPostByPreviousSlug(gomock.Any(), gomock.Eq(db.GetPublishedPostByPreviousSlugParams{Username: post.Username, Slug: "old-test-post"})).
+					Times(1).
+					Return(post, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMovedPermanently, recorder.Code)
+				require.Equal(t, "/api/@"+post.Username+"/"+post.Slug, recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "NotFound",
+			username: post.Username,
+			slug:     "missing-post",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetPublishedPostByPreviousSlug(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			/* Drafts have no permalink, the store only finds published posts */
+			name:     "Draft",
+			username: post.Username,
+			slug:     "draft-post",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Eq(db.GetPublishedPostByUsernameAndSlugParams{Username: post.Username, Slug: "draft-post"})).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					GetPublishedPostByPreviousSlug(gomock.Any(), gomock.Eq(db.GetPublishedPostByPreviousSlugParams{Username: post.Username, Slug: "draft-post"})).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Location"))
+			},
+		},
+		{
+			name:     "InvalidUsername",
+			username: "not-a-user",
+			slug:     post.Slug,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: post.Username,
+			slug:     post.Slug,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPublishedPostByUsernameAndSlug(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, sql.ErrConnDone)
+				store.EXPECT().
+					GetPublishedPostByPreviousSlug(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/@%s/%s", tc.username, tc.slug)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRenamePost(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	post.Version = 1
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+		Times(1).
+		Return(post, nil)
+	store.EXPECT().
+		UpdatePostTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+			require.Equal(t, "Renamed Post", arg.Title)
+			renamed := post
+			renamed.Title = arg.Title
+			renamed.Slug = "renamed-post"
+			return db.UpdatePostTxResult{Post: renamed, Tags: []string{}}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"post_id":  post.ID,
+		"title":    "Renamed Post",
+		"body":     post.Body,
+		"username": post.Username,
+		"version":  post.Version,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPut, "/api/post/updateBody", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp PostResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, "renamed-post", rsp.Slug)
+}
+
 func TestGetPublishedPostsByUserName(t *testing.T) {
 	user, _ := generateDummyUser(t)
 	post := generateDummyPost(t, user)
@@ -552,20 +796,26 @@ func TestGetPublishedPostsByUserName(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Eq(post.Username)).
+					ListPostsByUsername(gomock.Any(), gomock.Eq(db.ListPostsByUsernameParams{Username: post.Username, Status: db.StatusPublished, PageLimit: defaultPageLimit + 1})).
 					Times(1).
 					Return([]db.Post{post}, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []PostResponse
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, post.Body, posts[0].Body)
+				require.Len(t, rsp.Posts, 1)
+				require.Equal(t, post.Body, rsp.Posts[0].Body)
+				require.Empty(t, rsp.NextCursor)
 			},
 		},
 		{
@@ -577,20 +827,21 @@ func TestGetPublishedPostsByUserName(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Post{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []db.Post
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, len([]db.Post{}), len(posts))
+				require.NotNil(t, rsp.Posts)
+				require.Empty(t, rsp.Posts)
 			},
 		},
 		{
@@ -598,7 +849,7 @@ func TestGetPublishedPostsByUserName(t *testing.T) {
 			username: "$",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -614,7 +865,7 @@ func TestGetPublishedPostsByUserName(t *testing.T) {
 					Times(1).
 					Return(db.User{}, util.ErrRecordNotFound)
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -666,20 +917,26 @@ func TestGetDraftPostsByUserName(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Eq(post.Username)).
+					ListDraftPostsByUsername(gomock.Any(), gomock.Eq(db.ListDraftPostsByUsernameParams{Username: post.Username, PageLimit: defaultPageLimit + 1})).
 					Times(1).
 					Return([]db.Post{post}, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []PostResponse
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, post.Body, posts[0].Body)
+				require.Len(t, rsp.Posts, 1)
+				require.Equal(t, post.Body, rsp.Posts[0].Body)
+				require.Empty(t, rsp.NextCursor)
 			},
 		},
 		{
@@ -688,7 +945,7 @@ func TestGetDraftPostsByUserName(t *testing.T) {
 			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListDraftPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -703,7 +960,7 @@ func TestGetDraftPostsByUserName(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListDraftPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -718,7 +975,7 @@ func TestGetDraftPostsByUserName(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Any()).
+					ListDraftPostsByUsername(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -733,20 +990,21 @@ func TestGetDraftPostsByUserName(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Eq(post.Username)).
+					ListDraftPostsByUsername(gomock.Any(), gomock.Eq(db.ListDraftPostsByUsernameParams{Username: post.Username, PageLimit: defaultPageLimit + 1})).
 					Times(1).
 					Return([]db.Post{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var posts []db.Post
+				var rsp ListPostsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &posts)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, len([]db.Post{}), len(posts))
+				require.NotNil(t, rsp.Posts)
+				require.Empty(t, rsp.Posts)
 			},
 		},
 	}
@@ -779,6 +1037,7 @@ func TestUpdatePostBody(t *testing.T) {
 	post := generateDummyPost(t, user)
 	post.Status = "published"
 	post.LastModified = time.Now().Format("2006-01-02 15:04:05")
+	post.Version = 1
 
 	testCases := []struct {
 		name               string
@@ -793,6 +1052,7 @@ func TestUpdatePostBody(t *testing.T) {
 				"body":     post.Body,
 				"username": post.Username,
 				"post_id":  post.ID,
+				"version":  post.Version,
 			},
 			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, user.Username, time.Minute)
@@ -803,15 +1063,16 @@ func TestUpdatePostBody(t *testing.T) {
 					Username:     post.Username,
 					ID:           post.ID,
 					LastModified: post.LastModified,
+					Version:      post.Version,
 				}
 				store.EXPECT().
 					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
 					Return(post, nil)
 				store.EXPECT().
-					UpdatePostBody(gomock.Any(), arg).
+					UpdatePostTx(gomock.Any(), gomock.Eq(db.UpdatePostTxParams{UpdatePostBodyParams: arg})).
 					Times(1).
-					Return(post, nil)
+					Return(db.UpdatePostTxResult{Post: post, Tags: []string{}}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -834,13 +1095,8 @@ func TestUpdatePostBody(t *testing.T) {
 			},
 			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdatePostBodyParams{
-					Body:     post.Body,
-					Username: post.Username,
-					ID:       post.ID,
-				}
 				store.EXPECT().
-					UpdatePostBody(gomock.Any(), arg).
+					UpdatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -858,13 +1114,8 @@ func TestUpdatePostBody(t *testing.T) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, "anotherUserName", time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdatePostBodyParams{
-					Body:     post.Body,
-					Username: post.Username,
-					ID:       post.ID,
-				}
 				store.EXPECT().
-					UpdatePostBody(gomock.Any(), arg).
+					UpdatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -882,13 +1133,8 @@ func TestUpdatePostBody(t *testing.T) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, post.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.UpdatePostBodyParams{
-					Body:     post.Body,
-					Username: post.Username,
-					ID:       post.ID,
-				}
 				store.EXPECT().
-					UpdatePostBody(gomock.Any(), arg).
+					UpdatePostTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -929,6 +1175,7 @@ func TestUpdatePostStatus(t *testing.T) {
 	post := generateDummyPost(t, user)
 	post.Status = "draft"
 	post.PublishedAt = time.Now().Format("2006-01-02 15:04:05")
+	post.Version = 1
 	invalidPostId := uuid.New()
 
 	testCases := []struct {
@@ -943,6 +1190,7 @@ func TestUpdatePostStatus(t *testing.T) {
 			body: gin.H{
 				"username": post.Username,
 				"post_id":  post.ID,
+				"version":  post.Version,
 			},
 			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, post.Username, time.Minute)
@@ -953,6 +1201,7 @@ func TestUpdatePostStatus(t *testing.T) {
 					ID:          post.ID,
 					PublishedAt: post.PublishedAt,
 					Status:      db.StatusPublished,
+					Version:     post.Version,
 				}
 				store.EXPECT().
 					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
@@ -969,6 +1218,7 @@ func TestUpdatePostStatus(t *testing.T) {
 						PublishedAt: post.PublishedAt,
 						Category:    post.Category,
 						CreatedAt:   post.CreatedAt,
+						Version:     post.Version + 1,
 					}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -1134,20 +1384,22 @@ func TestGetCommentsByPostID(t *testing.T) {
 					Times(1).
 					Return(post, nil)
 				store.EXPECT().
-					GetCommentsByPostID(gomock.Any(), gomock.Eq(post.ID)).
+					ListCommentsByPostID(gomock.Any(), gomock.Eq(db.ListCommentsByPostIDParams{PostID: post.ID, PageLimit: defaultPageLimit + 1})).
 					Times(1).
 					Return([]db.Comment{comment}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var comments []CommentResponse
+				var rsp ListCommentsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &comments)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, comment.Body, comments[0].Body)
+				require.Len(t, rsp.Comments, 1)
+				require.Equal(t, comment.Body, rsp.Comments[0].Body)
+				require.Empty(t, rsp.NextCursor)
 			},
 		},
 		{
@@ -1159,20 +1411,21 @@ func TestGetCommentsByPostID(t *testing.T) {
 					Times(1).
 					Return(post, nil)
 				store.EXPECT().
-					GetCommentsByPostID(gomock.Any(), gomock.Any()).
+					ListCommentsByPostID(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return([]db.Comment{}, nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				var comments []db.Comment
+				var rsp ListCommentsResponse
 
 				data, err := io.ReadAll(recorder.Body)
 				require.NoError(t, err)
 
-				err = json.Unmarshal(data, &comments)
+				err = json.Unmarshal(data, &rsp)
 				require.NoError(t, err)
-				require.Equal(t, len([]db.Comment{}), len(comments))
+				require.NotNil(t, rsp.Comments)
+				require.Empty(t, rsp.Comments)
 			},
 		},
 		{
@@ -1180,7 +1433,7 @@ func TestGetCommentsByPostID(t *testing.T) {
 			postID: "  ",
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetCommentsByPostID(gomock.Any(), gomock.Any()).
+					ListCommentsByPostID(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -1196,7 +1449,7 @@ func TestGetCommentsByPostID(t *testing.T) {
 					Times(1).
 					Return(db.Post{}, util.ErrRecordNotFound)
 				store.EXPECT().
-					GetCommentsByPostID(gomock.Any(), randomPostId).
+					ListCommentsByPostID(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -1377,9 +1630,9 @@ func TestDeletePost(t *testing.T) {
 					Times(1).
 					Return(post, nil)
 				store.EXPECT().
-					GetCommentsByPostID(gomock.Any(), gomock.Eq(post.ID)).
+					DeleteCommentsByPostID(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
-					Return([]db.Comment{}, nil)
+					Return(nil)
 				store.EXPECT().
 					DeletePostByID(gomock.Any(), gomock.Eq(post.ID)).
 					Times(1).
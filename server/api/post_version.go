@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const ifMatchHeaderKey = "If-Match"
+
+/*
+postETag is the entity tag of a version of a post. The version changes with every update, so a weak tag
+W/"n" that a proxy made from it names the same version and If-Match compares tags weakly.
+*/
+func postETag(version int32) string {
+	return `"` + strconv.Itoa(int(version)) + `"`
+}
+
+/* setPostETag sends the version of the post in the response as its ETag */
+func setPostETag(ctx *gin.Context, post db.Post) {
+	ctx.Header("ETag", postETag(post.Version))
+}
+
+/*
+checkPostVersion makes sure an update is based on the current version of post, named by the If-Match header,
+strong or weak, or else by the version field of the request. It writes a 428 response when the request names no version and
+a 412 response with the current version when it names another one.
+*/
+func (server *Server) checkPostVersion(ctx *gin.Context, post db.Post, version int32, pointOfFailure string) bool {
+	ifMatch := ctx.GetHeader(ifMatchHeaderKey)
+	if ifMatch == "" && version == 0 {
+		logger.LogError("request has no If-Match header or version", pointOfFailure)
+		server.PreconditionRequiredError(ctx)
+		return false
+	}
+
+	if ifMatch != "" {
+		for _, tag := range strings.Split(ifMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == postETag(post.Version) {
+				return true
+			}
+		}
+	} else if version == post.Version {
+		return true
+	}
+
+	logger.LogError("request version does not match post version", pointOfFailure)
+	server.PreconditionFailedError(ctx, post.Version)
+	return false
+}
+
+/*
+lostUpdateError answers an update of version of a post that matched no row. When the post has another
+version by now a concurrent update won and the response is 412, otherwise something else was missing.
+*/
+func (server *Server) lostUpdateError(ctx *gin.Context, postID uuid.UUID, version int32, pointOfFailure string) {
+	post, err := server.DataStore.GetPostById(ctx, postID)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if post.Version != version {
+		server.PreconditionFailedError(ctx, post.Version)
+		return
+	}
+	server.NotFoundError(ctx)
+}
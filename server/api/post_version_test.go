@@ -0,0 +1,319 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func requireBodyMatchVersion(t *testing.T, recorder *httptest.ResponseRecorder, version int32) {
+	var rsp struct {
+		Error   string `json:"error"`
+		Version int32  `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, version, rsp.Version)
+	require.Equal(t, postETag(version), recorder.Header().Get("ETag"))
+}
+
+func TestUpdatePostBodyVersion(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.Version = 3
+
+	updated := post
+	updated.Body = "An updated body"
+	updated.Version = post.Version + 1
+
+	testCases := []struct {
+		name          string
+		ifMatch       string
+		version       int32
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "IfMatch",
+			ifMatch: `"1", "3"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+						require.Equal(t, post.Version, arg.Version)
+						return db.UpdatePostTxResult{Post: updated, Tags: []string{}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"4"`, recorder.Header().Get("ETag"))
+
+				var rsp PostResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, updated.Version, rsp.Version)
+			},
+		},
+		{
+			/* A proxy may send the ETag back as a weak tag */
+			name:    "WeakIfMatch",
+			ifMatch: `W/"3"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdatePostTxResult{Post: updated, Tags: []string{}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "NotFound",
+			ifMatch: `"3"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "MissingVersion",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+			},
+		},
+		{
+			name:    "StaleIfMatch",
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchVersion(t, recorder, post.Version)
+			},
+		},
+		{
+			name:    "StaleVersion",
+			version: 2,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchVersion(t, recorder, post.Version)
+			},
+		},
+		{
+			/* Another update wins between reading the post and writing it */
+			name:    "LostUpdate",
+			version: post.Version,
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().
+						GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+						Times(1).
+						Return(post, nil),
+					store.EXPECT().
+						UpdatePostTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.UpdatePostTxResult{}, util.ErrRecordNotFound),
+					store.EXPECT().
+						GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+						Times(1).
+						Return(updated, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchVersion(t, recorder, updated.Version)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"post_id":  post.ID,
+				"body":     updated.Body,
+				"username": post.Username,
+			}
+			if tc.version != 0 {
+				body["version"] = tc.version
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/api/post/updateBody", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				request.Header.Set(ifMatchHeaderKey, tc.ifMatch)
+			}
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdatePostStatusVersion(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.Status = db.StatusDraft
+	post.Version = 2
+
+	testCases := []struct {
+		name          string
+		ifMatch       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			/* Any version of the post matches the wildcard */
+			name:    "IfMatchAny",
+			ifMatch: "*",
+			buildStubs: func(store *mockdb.MockStore) {
+				published := post
+				published.Status = db.StatusPublished
+				published.Version = post.Version + 1
+
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdatePostStatusParams) (db.Post, error) {
+						require.Equal(t, post.Version, arg.Version)
+						return published, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+			},
+		},
+		{
+			name:    "StaleIfMatch",
+			ifMatch: `"1"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchVersion(t, recorder, post.Version)
+			},
+		},
+		{
+			name: "MissingVersion",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					UpdatePostStatus(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"post_id":  post.ID,
+				"username": post.Username,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/api/post/publish", bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				request.Header.Set(ifMatchHeaderKey, tc.ifMatch)
+			}
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	diffModeUnified = "unified"
+	diffModeWords   = "words"
+)
+
+type PostRevisionsRequest struct {
+	ID string `uri:"id" binding:"required,min=1"`
+}
+
+type GetPostRevisionRequest struct {
+	ID     string `uri:"id" binding:"required,min=1"`
+	Number int32  `uri:"number" binding:"required,min=1"`
+}
+
+/* DiffPostRevisionsRequest compares revision From with revision To, in unified format unless the mode is words */
+type DiffPostRevisionsRequest struct {
+	From int32  `form:"from" binding:"required,min=1"`
+	To   int32  `form:"to" binding:"required,min=1"`
+	Mode string `form:"mode" binding:"omitempty,oneof=unified words"`
+}
+
+/* RestorePostRevisionRequest restores a revision of a post, the version is only read when the request has no If-Match header */
+type RestorePostRevisionRequest struct {
+	ID       string `json:"post_id" binding:"required"`
+	Number   int32  `json:"number" binding:"required,min=1"`
+	Username string `json:"username" binding:"required,alphanum"`
+	Version  int32  `json:"version" binding:"omitempty,min=1"`
+}
+
+/* PostRevisionResponse is a revision of a post, lists of revisions leave out the body */
+type PostRevisionResponse struct {
+	Number       int32     `json:"number"`
+	Title        string    `json:"title"`
+	Body         string    `json:"body,omitempty"`
+	RestoredFrom *int32    `json:"restored_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ListPostRevisionsResponse struct {
+	Revisions  []PostRevisionResponse `json:"revisions"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+type DiffChangeResponse struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+/* DiffPostRevisionsResponse holds the unified diff or the word changes between two revisions, depending on the mode */
+type DiffPostRevisionsResponse struct {
+	From    int32                `json:"from"`
+	To      int32                `json:"to"`
+	Mode    string               `json:"mode"`
+	Unified string               `json:"unified,omitempty"`
+	Changes []DiffChangeResponse `json:"changes,omitempty"`
+}
+
+type RestorePostRevisionResponse struct {
+	Post     PostResponse         `json:"post"`
+	Revision PostRevisionResponse `json:"revision"`
+}
+
+func GetPostRevisionResponse(revision db.PostRevision) PostRevisionResponse {
+	rsp := PostRevisionResponse{
+		Number:    revision.Number,
+		Title:     revision.Title,
+		Body:      revision.Body,
+		CreatedAt: revision.CreatedAt,
+	}
+	if revision.RestoredFrom.Valid {
+		rsp.RestoredFrom = &revision.RestoredFrom.Int32
+	}
+	return rsp
+}
+
+/* GetListPostRevisionsResponse returns the revisions of the page, the cursor of a revision list is the number of the last revision */
+func GetListPostRevisionsResponse(revisions []db.ListPostRevisionsRow, limit int32) ListPostRevisionsResponse {
+	rsp := ListPostRevisionsResponse{Revisions: []PostRevisionResponse{}}
+	for i, revision := range revisions {
+		if i == int(limit) {
+			last := revisions[i-1]
+			rsp.NextCursor = encodePageCursor(strconv.Itoa(int(last.Number)), last.ID)
+			break
+		}
+		rsp.Revisions = append(rsp.Revisions, GetPostRevisionResponse(db.PostRevision{
+			Number:       revision.Number,
+			Title:        revision.Title,
+			RestoredFrom: revision.RestoredFrom,
+			CreatedAt:    revision.CreatedAt,
+		}))
+	}
+	return rsp
+}
+
+/* revisionDiffText is the text compared by a diff, the title then a blank line then the body */
+func revisionDiffText(revision db.PostRevision) string {
+	return revision.Title + "\n\n" + revision.Body
+}
+
+/*
+authorizedPost loads the post with the given ID for its author. It writes the response and returns
+false when the ID is invalid, the post does not exist or the authenticated user did not write it.
+*/
+func (server *Server) authorizedPost(ctx *gin.Context, id string, pointOfFailure string) (db.Post, bool) {
+	// convert postId string to uuid
+	postId, err := uuid.Parse(id)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.BadRequestError(ctx)
+		return db.Post{}, false
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", pointOfFailure)
+		server.UnauthorizedError(ctx)
+		return db.Post{}, false
+	}
+
+	post, err := server.DataStore.GetPostById(ctx, postId)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return db.Post{}, false
+		}
+		server.InternalServerError(ctx)
+		return db.Post{}, false
+	}
+
+	if !isSameUsername(authenticationPayload.Username, post.Username) {
+		logger.LogError("authentication payload username does not match post username", pointOfFailure)
+		server.UnauthorizedError(ctx)
+		return db.Post{}, false
+	}
+	return post, true
+}
+
+/* getPostRevision reads a revision of a post, it writes the response and returns false when the revision cannot be read */
+func (server *Server) getPostRevision(ctx *gin.Context, postID uuid.UUID, number int32, pointOfFailure string) (db.PostRevision, bool) {
+	revision, err := server.DataStore.GetPostRevision(ctx, db.GetPostRevisionParams{PostID: postID, Number: number})
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return db.PostRevision{}, false
+		}
+		server.InternalServerError(ctx)
+		return db.PostRevision{}, false
+	}
+	return revision, true
+}
+
+func (server *Server) GetPostRevisions(ctx *gin.Context) {
+	var req PostRevisionsRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "GetPostRevisions")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	page, ok := server.bindPage(ctx, "GetPostRevisions")
+	if !ok {
+		return
+	}
+
+	post, ok := server.authorizedPost(ctx, req.ID, "GetPostRevisions")
+	if !ok {
+		return
+	}
+
+	arg := db.ListPostRevisionsParams{
+		PostID:    post.ID,
+		PageLimit: page.queryLimit(),
+	}
+	if page.CursorKey.Valid {
+		number, err := strconv.ParseInt(page.CursorKey.String, 10, 32)
+		if err != nil {
+			logger.LogError(err.Error(), "GetPostRevisions")
+			server.BadRequestError(ctx)
+			return
+		}
+		arg.CursorNumber = pgtype.Int4{Int32: int32(number), Valid: true}
+	}
+
+	revisions, err := server.DataStore.ListPostRevisions(ctx, arg)
+	if err != nil {
+		logger.LogError(err.Error(), "GetPostRevisions")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetListPostRevisionsResponse(revisions, page.Limit))
+}
+
+func (server *Server) GetPostRevision(ctx *gin.Context) {
+	var req GetPostRevisionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "GetPostRevision")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	post, ok := server.authorizedPost(ctx, req.ID, "GetPostRevision")
+	if !ok {
+		return
+	}
+
+	revision, ok := server.getPostRevision(ctx, post.ID, req.Number, "GetPostRevision")
+	if !ok {
+		return
+	}
+
+	server.ReturnOK(ctx, GetPostRevisionResponse(revision))
+}
+
+func (server *Server) DiffPostRevisions(ctx *gin.Context) {
+	var uri PostRevisionsRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		logger.LogError(err.Error(), "DiffPostRevisions")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	var req DiffPostRevisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.LogError(err.Error(), "DiffPostRevisions")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	post, ok := server.authorizedPost(ctx, uri.ID, "DiffPostRevisions")
+	if !ok {
+		return
+	}
+
+	from, ok := server.getPostRevision(ctx, post.ID, req.From, "DiffPostRevisions")
+	if !ok {
+		return
+	}
+	to, ok := server.getPostRevision(ctx, post.ID, req.To, "DiffPostRevisions")
+	if !ok {
+		return
+	}
+
+	rsp := DiffPostRevisionsResponse{From: from.Number, To: to.Number, Mode: req.Mode}
+	var err error
+	if rsp.Mode == diffModeWords {
+		var changes []util.DiffChange
+		changes, err = util.WordDiff(revisionDiffText(from), revisionDiffText(to))
+		for _, change := range changes {
+			rsp.Changes = append(rsp.Changes, DiffChangeResponse{Op: string(change.Op), Text: change.Text})
+		}
+	} else {
+		rsp.Mode = diffModeUnified
+		rsp.Unified, err = util.UnifiedDiff(
+			fmt.Sprintf("revision %d", from.Number),
+			fmt.Sprintf("revision %d", to.Number),
+			revisionDiffText(from),
+			revisionDiffText(to),
+		)
+	}
+	if err != nil {
+		logger.LogError(err.Error(), "DiffPostRevisions")
+		if errors.Is(err, util.ErrDiffTooLarge) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) RestorePostRevision(ctx *gin.Context) {
+	var req RestorePostRevisionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		logger.LogError(err.Error(), "RestorePostRevision")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	post, ok := server.authorizedPost(ctx, req.ID, "RestorePostRevision")
+	if !ok {
+		return
+	}
+
+	if !isSameUsername(post.Username, req.Username) {
+		logger.LogError("post username does not match request username", "RestorePostRevision")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	if !server.checkPostVersion(ctx, post, req.Version, "RestorePostRevision") {
+		return
+	}
+
+	result, err := server.DataStore.RestorePostRevisionTx(ctx, db.RestorePostRevisionTxParams{
+		PostID:       post.ID,
+		Number:       req.Number,
+		Username:     post.Username,
+		LastModified: time.Now().Format("2006-01-02 15:04:05"),
+		Version:      post.Version,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "RestorePostRevision")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			// either the revision does not exist or the post was updated since it was read
+			server.lostUpdateError(ctx, post.ID, post.Version, "RestorePostRevision")
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	posts := []PostResponse{GetPostResponse(result.Post)}
+	if err := server.addPostTags(ctx, posts); err != nil {
+		logger.LogError(err.Error(), "RestorePostRevision")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	setPostETag(ctx, result.Post)
+	server.ReturnOK(ctx, RestorePostRevisionResponse{
+		Post:     posts[0],
+		Revision: GetPostRevisionResponse(result.Revision),
+	})
+}
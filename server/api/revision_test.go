@@ -0,0 +1,630 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func generateDummyRevision(post db.Post, number int32, body string) db.PostRevision {
+	return db.PostRevision{
+		ID:        uuid.New(),
+		PostID:    post.ID,
+		Number:    number,
+		Title:     post.Title,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestGetPostRevisions(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+
+	revisions := []db.ListPostRevisionsRow{}
+	for number := int32(3); number > 0; number-- {
+		revisions = append(revisions, db.ListPostRevisionsRow{ID: uuid.New(), PostID: post.ID, Number: number, Title: post.Title})
+	}
+	revisions[0].RestoredFrom = pgtype.Int4{Int32: 1, Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			query:    url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Eq(db.ListPostRevisionsParams{PostID: post.ID, PageLimit: defaultPageLimit + 1})).
+					Times(1).
+					Return(revisions, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostRevisionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Revisions, 3)
+				require.Equal(t, int32(3), rsp.Revisions[0].Number)
+				require.Equal(t, int32(1), *rsp.Revisions[0].RestoredFrom)
+				require.Nil(t, rsp.Revisions[1].RestoredFrom)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:     "NextPage",
+			username: user.Username,
+			query:    url.Values{"limit": {"1"}, "cursor": {encodePageCursor("3", revisions[0].ID)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Eq(db.ListPostRevisionsParams{
+						PostID:       post.ID,
+						CursorNumber: pgtype.Int4{Int32: 3, Valid: true},
+						PageLimit:    2,
+					})).
+					Times(1).
+					Return(revisions[1:], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostRevisionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Revisions, 1)
+
+				cursor, err := decodePageCursor(rsp.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, "2", cursor.Key)
+			},
+		},
+		{
+			name:     "NotAuthor",
+			username: "otherUser",
+			query:    url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "PostNotFound",
+			username: user.Username,
+			query:    url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Post{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidCursorNumber",
+			username: user.Username,
+			query:    url.Values{"cursor": {encodePageCursor("three", revisions[0].ID)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			query:    url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					ListPostRevisions(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/post/getRevisions/%s?%s", post.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, tc.username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetPostRevision(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	revision := generateDummyRevision(post, 2, "An older body")
+
+	testCases := []struct {
+		name          string
+		number        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			number: "2",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 2})).
+					Times(1).
+					Return(revision, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp PostRevisionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, revision.Number, rsp.Number)
+				require.Equal(t, revision.Body, rsp.Body)
+			},
+		},
+		{
+			name:   "NotFound",
+			number: "9",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PostRevision{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InvalidNumber",
+			number: "0",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/post/getRevision/%s/%s", post.ID, tc.number)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDiffPostRevisions(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	from := generateDummyRevision(post, 1, "the quick brown fox")
+	to := generateDummyRevision(post, 2, "the slow brown fox")
+
+	stubRevisions := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+			Times(1).
+			Return(post, nil)
+		store.EXPECT().
+			GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 1})).
+			Times(1).
+			Return(from, nil)
+		store.EXPECT().
+			GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 2})).
+			Times(1).
+			Return(to, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "Unified",
+			query:      url.Values{"from": {"1"}, "to": {"2"}},
+			buildStubs: stubRevisions,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp DiffPostRevisionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, diffModeUnified, rsp.Mode)
+				require.Equal(t, "--- revision 1\n+++ revision 2\n@@ -1,3 +1,3 @@\n "+post.Title+"\n \n-the quick brown fox\n+the slow brown fox\n", rsp.Unified)
+				require.Empty(t, rsp.Changes)
+			},
+		},
+		{
+			name:       "Words",
+			query:      url.Values{"from": {"1"}, "to": {"2"}, "mode": {"words"}},
+			buildStubs: stubRevisions,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp DiffPostRevisionsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, diffModeWords, rsp.Mode)
+				require.Equal(t, []DiffChangeResponse{
+					{Op: "equal", Text: post.Title + "\n\nthe "},
+					{Op: "delete", Text: "quick"},
+					{Op: "insert", Text: "slow"},
+					{Op: "equal", Text: " brown fox"},
+				}, rsp.Changes)
+			},
+		},
+		{
+			name:  "TooLarge",
+			query: url.Values{"from": {"1"}, "to": {"3"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				rewritten := generateDummyRevision(post, 3, strings.Repeat("a rewritten line\n", 2000))
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 1})).
+					Times(1).
+					Return(from, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 3})).
+					Times(1).
+					Return(rewritten, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrDiffTooLarge.Error())
+			},
+		},
+		{
+			name:  "InvalidMode",
+			query: url.Values{"from": {"1"}, "to": {"2"}, "mode": {"lines"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingRevision",
+			query: url.Values{"from": {"1"}, "to": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 1})).
+					Times(1).
+					Return(from, nil)
+				store.EXPECT().
+					GetPostRevision(gomock.Any(), gomock.Eq(db.GetPostRevisionParams{PostID: post.ID, Number: 5})).
+					Times(1).
+					Return(db.PostRevision{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/api/post/diffRevisions/%s?%s", post.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRestorePostRevision(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	post.Version = 3
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": user.Username, "version": post.Version},
+			buildStubs: func(store *mockdb.MockStore) {
+				restored := post
+				restored.Body = "The first body"
+				revision := generateDummyRevision(restored, 4, restored.Body)
+				revision.RestoredFrom = pgtype.Int4{Int32: 1, Valid: true}
+
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.RestorePostRevisionTxParams) (db.RestorePostRevisionTxResult, error) {
+						require.Equal(t, post.ID, arg.PostID)
+						require.Equal(t, int32(1), arg.Number)
+						require.Equal(t, post.Username, arg.Username)
+						require.Equal(t, post.Version, arg.Version)
+						return db.RestorePostRevisionTxResult{Post: restored, Revision: revision}, nil
+					})
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Eq([]uuid.UUID{post.ID})).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp RestorePostRevisionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, "The first body", rsp.Post.Body)
+				require.Equal(t, int32(4), rsp.Revision.Number)
+				require.Equal(t, int32(1), *rsp.Revision.RestoredFrom)
+			},
+		},
+		{
+			name: "RevisionNotFound",
+			body: gin.H{"post_id": post.ID, "number": 9, "username": user.Username, "version": post.Version},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(2).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RestorePostRevisionTxResult{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "PostChanged",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": user.Username, "version": post.Version},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := post
+				changed.Version = post.Version + 1
+
+				gomock.InOrder(
+					store.EXPECT().
+						GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+						Times(1).
+						Return(post, nil),
+					store.EXPECT().
+						RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+						Times(1).
+						Return(db.RestorePostRevisionTxResult{}, util.ErrRecordNotFound),
+					store.EXPECT().
+						GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+						Times(1).
+						Return(changed, nil),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "UsernameMismatch",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": "otherUser", "version": post.Version},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "StaleVersion",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": user.Username, "version": post.Version + 1},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+				requireBodyMatchVersion(t, recorder, post.Version)
+			},
+		},
+		{
+			name: "MissingVersion",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": user.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+			},
+		},
+		{
+			name: "MissingNumber",
+			body: gin.H{"post_id": post.ID, "username": user.Username},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"post_id": post.ID, "number": 1, "username": user.Username, "version": post.Version},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+					Times(1).
+					Return(post, nil)
+				store.EXPECT().
+					RestorePostRevisionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RestorePostRevisionTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/api/post/restoreRevision", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
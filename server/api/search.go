@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const searchDateLayout = "2006-01-02"
+
+var errEmptySearchQuery = errors.New("search query has no words")
+
+/*
+SearchPostsRequest holds the query string of a search. Words in double quotes match as a phrase and a
+word ending in * matches as a prefix. The date range is inclusive and filters on the publication date.
+*/
+type SearchPostsRequest struct {
+	Query    string `form:"q" binding:"required,max=256"`
+	Category string `form:"category" binding:"max=100"`
+	Author   string `form:"author" binding:"omitempty,alphanum"`
+	From     string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To       string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+/* SearchPostResponse is a matching post, the snippet is HTML with the matches wrapped in <mark> and the rest of the body escaped */
+type SearchPostResponse struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Username    string  `json:"username"`
+	Slug        string  `json:"slug"`
+	Category    string  `json:"category"`
+	PublishedAt string  `json:"published_at"`
+	Rank        float32 `json:"rank"`
+	Snippet     string  `json:"snippet"`
+}
+
+type SearchPostsResponse struct {
+	Posts      []SearchPostResponse `json:"posts"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+/*
+buildSearchQuery turns the text of a search into a to_tsquery expression. Every word must match,
+quoted words must match next to each other and a trailing * matches any word with that prefix.
+Punctuation separates words so user input never reaches the tsquery syntax.
+*/
+func buildSearchQuery(text string) (string, error) {
+	terms := []string{}
+	for i, part := range strings.Split(text, `"`) {
+		words := searchWords(part)
+		if len(words) == 0 {
+			continue
+		}
+		// odd parts are between quotes
+		if i%2 == 1 {
+			terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			continue
+		}
+		terms = append(terms, words...)
+	}
+	if len(terms) == 0 {
+		return "", errEmptySearchQuery
+	}
+	return strings.Join(terms, " & "), nil
+}
+
+/* searchWords splits text into lowercase lexemes, the last lexeme of a field ending in * becomes a prefix match */
+func searchWords(text string) []string {
+	words := []string{}
+	for _, field := range strings.Fields(text) {
+		parts := strings.FieldsFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for i, part := range parts {
+			word := strings.ToLower(part)
+			if i == len(parts)-1 && strings.HasSuffix(field, "*") {
+				word += ":*"
+			}
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+/* searchTextParam returns a null parameter for an empty filter */
+func searchTextParam(value string) pgtype.Text {
+	return pgtype.Text{String: value, Valid: value != ""}
+}
+
+/* GetSearchPostsResponse returns the posts of the page, the cursor of a search is the rank of the last post */
+func GetSearchPostsResponse(posts []db.SearchPublishedPostsRow, limit int32) SearchPostsResponse {
+	rsp := SearchPostsResponse{Posts: []SearchPostResponse{}}
+	for i, post := range posts {
+		if i == int(limit) {
+			last := posts[i-1]
+			rsp.NextCursor = encodePageCursor(strconv.FormatFloat(float64(last.Rank), 'g', -1, 32), last.ID)
+			break
+		}
+		rsp.Posts = append(rsp.Posts, SearchPostResponse{
+			ID:          post.ID.String(),
+			Title:       post.Title,
+			Username:    post.Username,
+			Slug:        post.Slug,
+			Category:    post.Category,
+			PublishedAt: post.PublishedAt,
+			Rank:        post.Rank,
+			Snippet:     post.Snippet,
+		})
+	}
+	return rsp
+}
+
+func (server *Server) SearchPosts(ctx *gin.Context) {
+	var req SearchPostsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		logger.LogError(err.Error(), "SearchPosts")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	query, err := buildSearchQuery(req.Query)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	page, ok := server.bindPage(ctx, "SearchPosts")
+	if !ok {
+		return
+	}
+
+	arg := db.SearchPublishedPostsParams{
+		Query:         query,
+		Category:      searchTextParam(util.Slugify(req.Category)),
+		Username:      searchTextParam(req.Author),
+		PublishedFrom: searchTextParam(req.From),
+		CursorID:      page.CursorID,
+		PageLimit:     page.queryLimit(),
+	}
+
+	// published_at sorts as text, so the day after the range is an exclusive upper bound
+	if req.To != "" {
+		to, err := time.Parse(searchDateLayout, req.To)
+		if err != nil {
+			logger.LogError(err.Error(), "SearchPosts")
+			server.BadRequestError(ctx)
+			return
+		}
+		arg.PublishedTo = searchTextParam(to.AddDate(0, 0, 1).Format(searchDateLayout))
+	}
+
+	if page.CursorKey.Valid {
+		rank, err := strconv.ParseFloat(page.CursorKey.String, 32)
+		if err != nil {
+			logger.LogError(err.Error(), "SearchPosts")
+			server.BadRequestError(ctx)
+			return
+		}
+		arg.CursorRank = pgtype.Float4{Float32: float32(rank), Valid: true}
+	}
+
+	posts, err := server.DataStore.SearchPublishedPosts(ctx, arg)
+	if err != nil {
+		logger.LogError(err.Error(), "SearchPosts")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, GetSearchPostsResponse(posts, page.Limit))
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func generateDummySearchResults(n int) []db.SearchPublishedPostsRow {
+	posts := []db.SearchPublishedPostsRow{}
+	for i := 0; i < n; i++ {
+		posts = append(posts, db.SearchPublishedPostsRow{
+			ID:          uuid.New(),
+			Title:       "Search Post",
+			Username:    "searchUser",
+			Category:    "test-category",
+			PublishedAt: "2024-01-01 10:00:00",
+			Rank:        float32(n-i) / 10,
+			Snippet:     "a <mark>search</mark> result",
+		})
+	}
+	return posts
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	testCases := []struct {
+		text  string
+		query string
+	}{
+		{text: "golang", query: "golang"},
+		{text: "Go  Generics", query: "go & generics"},
+		{text: `"full text" search`, query: "(full <-> text) & search"},
+		{text: "post*", query: "post:*"},
+		{text: `"open blog*"`, query: "(open <-> blog:*)"},
+		{text: "it's a test!", query: "it & s & a & test"},
+		{text: "a & b | !c", query: "a & b & c"},
+		{text: `unterminated "quote here`, query: "unterminated & (quote <-> here)"},
+	}
+
+	for _, tc := range testCases {
+		query, err := buildSearchQuery(tc.text)
+		require.NoError(t, err)
+		require.Equal(t, tc.query, query)
+	}
+
+	/* Text without words cannot be searched */
+	_, err := buildSearchQuery(`"" & | *`)
+	require.ErrorIs(t, err, errEmptySearchQuery)
+}
+
+func TestSearchPosts(t *testing.T) {
+	posts := generateDummySearchResults(3)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"q": {"search"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Eq(db.SearchPublishedPostsParams{
+						Query:     "search",
+						PageLimit: defaultPageLimit + 1,
+					})).
+					Times(1).
+					Return(posts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp SearchPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 3)
+				require.Equal(t, posts[0].Snippet, rsp.Posts[0].Snippet)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name: "Filters",
+			query: url.Values{
+				"q":        {`"full text" post*`},
+				"category": {"Test Category"},
+				"author":   {"searchUser"},
+				"from":     {"2024-01-01"},
+				"to":       {"2024-01-31"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Eq(db.SearchPublishedPostsParams{
+						Query:         "(full <-> text) & post:*",
+						Category:      pgtype.Text{String: "test-category", Valid: true},
+						Username:      pgtype.Text{String: "searchUser", Valid: true},
+						PublishedFrom: pgtype.Text{String: "2024-01-01", Valid: true},
+						PublishedTo:   pgtype.Text{String: "2024-02-01", Valid: true},
+						PageLimit:     defaultPageLimit + 1,
+					})).
+					Times(1).
+					Return([]db.SearchPublishedPostsRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "PageWithNextCursor",
+			query: url.Values{"q": {"search"}, "limit": {"2"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(posts, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp SearchPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 2)
+
+				/* The cursor of a search is the rank of the last post of the page */
+				cursor, err := decodePageCursor(rsp.NextCursor)
+				require.NoError(t, err)
+				require.Equal(t, "0.2", cursor.Key)
+				require.Equal(t, posts[1].ID, cursor.ID)
+			},
+		},
+		{
+			name:  "NextPage",
+			query: url.Values{"q": {"search"}, "limit": {"2"}, "cursor": {encodePageCursor("0.2", posts[1].ID)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Eq(db.SearchPublishedPostsParams{
+						Query:      "search",
+						CursorRank: pgtype.Float4{Float32: posts[1].Rank, Valid: true},
+						CursorID:   posts[1].ID,
+						PageLimit:  3,
+					})).
+					Times(1).
+					Return(posts[2:], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp SearchPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 1)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "MissingQuery",
+			query: url.Values{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "QueryWithoutWords",
+			query: url.Values{"q": {"&|!"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errEmptySearchQuery.Error())
+			},
+		},
+		{
+			name:  "InvalidDate",
+			query: url.Values{"q": {"search"}, "from": {"01/01/2024"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCursorRank",
+			query: url.Values{"q": {"search"}, "cursor": {encodePageCursor("2024-01-01 10:00:00", posts[0].ID)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"q": {"search"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchPublishedPosts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/post/search?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
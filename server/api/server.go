@@ -1,12 +1,22 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Oabraham1/open-blogger/server/auth"
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/lockout"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/oidc"
 	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +25,7 @@ const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	authorizationSessionKey = "authorization_session"
 )
 
 func (server *Server) UnauthorizedError(ctx *gin.Context) {
@@ -47,6 +58,28 @@ func (server *Server) ConflictError(ctx *gin.Context) {
 	ctx.JSON(http.StatusConflict, errorResponse(err))
 }
 
+func (server *Server) TooManyRequestsError(ctx *gin.Context, retryAfter time.Duration) {
+	err := errors.New("too many requests")
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+}
+
+func (server *Server) ServiceUnavailableError(ctx *gin.Context) {
+	err := errors.New("service unavailable")
+	ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+}
+
+func (server *Server) PreconditionRequiredError(ctx *gin.Context) {
+	err := errors.New("precondition required")
+	ctx.JSON(http.StatusPreconditionRequired, errorResponse(err))
+}
+
+/* PreconditionFailedError answers an update based on an old version with the current version and its ETag */
+func (server *Server) PreconditionFailedError(ctx *gin.Context, version int32) {
+	ctx.Header("ETag", postETag(version))
+	ctx.JSON(http.StatusPreconditionFailed, gin.H{"error": "precondition failed", "version": version})
+}
+
 func (server *Server) ReturnOK(ctx *gin.Context, data interface{}) {
 	ctx.JSON(http.StatusOK, data)
 }
@@ -57,62 +90,255 @@ type Server struct {
 	DataStore      db.Store
 	Configurations util.Config
 	Authenticator  auth.Authenticator
+	SessionCache   *SessionCache
+	Mailer         mail.Mailer
+	SecretCipher   *util.SecretCipher
+	LoginLimiter   *lockout.Limiter
+	PasswordHasher *util.PasswordHasher
+	PasswordPolicy *util.PasswordPolicy
+	OIDCProviders  map[string]*oidc.Provider
+
+	httpServer      *http.Server
+	backgroundTasks sync.WaitGroup
 }
 
 /* NewServer creates a new server */
 func NewServer(store db.Store, config util.Config) (*Server, error) {
-	authenticator, err := auth.NewPasetoAuthenticator(config.TokenSymmetricKey)
+	authenticator, err := newAuthenticator(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create authenticator: %w", err)
 	}
+	mailer, err := newMailer(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create mailer: %w", err)
+	}
+	loginAttemptStore, err := newLoginAttemptStore(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create login attempt store: %w", err)
+	}
+	if err := checkRegistrationMode(config.RegistrationMode); err != nil {
+		return nil, err
+	}
+	oidcProviders, err := newOIDCProviders(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create oidc providers: %w", err)
+	}
+	secretCipher, err := newSecretCipher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create secret cipher: %w", err)
+	}
 	server := &Server{
 		DataStore:      store,
 		Configurations: config,
 		Authenticator:  authenticator,
+		SessionCache:   NewSessionCache(store, config.SessionCacheTTL),
+		Mailer:         mailer,
+		SecretCipher:   secretCipher,
+		LoginLimiter:   lockout.NewLimiter(loginAttemptStore),
+		PasswordHasher: util.NewPasswordHasherFromConfig(config),
+		PasswordPolicy: util.NewPasswordPolicyFromConfig(config),
+		OIDCProviders:  oidcProviders,
 	}
 	server.setupRouter()
+	server.httpServer = &http.Server{Handler: server.Router}
 	return server, nil
 }
 
+/* newAuthenticator creates the authenticator for the configured token format */
+func newAuthenticator(config util.Config) (auth.Authenticator, error) {
+	switch config.TokenFormat {
+	case "", auth.TokenFormatPasetoLocal:
+		return auth.NewPasetoAuthenticator(config.TokenSymmetricKey)
+	case auth.TokenFormatPasetoPublic:
+		return auth.NewPasetoPublicAuthenticator(config.TokenSigningKeyID, config.TokenSigningKey, config.TokenVerificationKeys)
+	case auth.TokenFormatJWTHS256:
+		return auth.NewHS256JWTAuthenticator(config.TokenSymmetricKey, config.TokenIssuer, config.TokenAudience)
+	case auth.TokenFormatJWTEdDSA:
+		return auth.NewEdDSAJWTAuthenticator(config.TokenSigningKeyID, config.TokenSigningKey, config.TokenVerificationKeys, config.TokenIssuer, config.TokenAudience)
+	default:
+		return nil, fmt.Errorf("unsupported token format %q", config.TokenFormat)
+	}
+}
+
+/* newMailer creates the mailer for the configured mailer type, without one emails are only logged */
+func newMailer(config util.Config) (mail.Mailer, error) {
+	switch config.Mailer {
+	case "", mail.MailerLog:
+		return mail.NewLogMailer(log.Default()), nil
+	case mail.MailerMemory:
+		return mail.NewMemoryMailer(), nil
+	case mail.MailerFile:
+		return mail.NewFileMailer(config.MailDirectory, config.MailSender)
+	case mail.MailerSMTP:
+		return mail.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailSender)
+	default:
+		return nil, fmt.Errorf("unsupported mailer %q", config.Mailer)
+	}
+}
+
+/* newSecretCipher creates the cipher of TOTP secrets, without an encryption key there is none and MFA is unavailable */
+func newSecretCipher(config util.Config) (*util.SecretCipher, error) {
+	if config.TOTPEncryptionKey == "" {
+		return nil, nil
+	}
+	return util.NewSecretCipher(config.TOTPEncryptionKey)
+}
+
+/* newLoginAttemptStore creates the store of failed login counters for the configured store type */
+func newLoginAttemptStore(config util.Config, store db.Store) (lockout.Store, error) {
+	switch config.LoginAttemptStore {
+	case "", lockout.StoreMemory:
+		return lockout.NewMemoryStore(), nil
+	case lockout.StorePostgres:
+		return lockout.NewPostgresStore(store), nil
+	default:
+		return nil, fmt.Errorf("unsupported login attempt store %q", config.LoginAttemptStore)
+	}
+}
+
+/* newOIDCProviders creates the configured OpenID Connect provider, no provider is configured without an issuer url */
+func newOIDCProviders(config util.Config) (map[string]*oidc.Provider, error) {
+	providers := make(map[string]*oidc.Provider)
+	if config.OIDCIssuerURL == "" {
+		return providers, nil
+	}
+
+	name := config.OIDCProviderName
+	if name == "" {
+		name = defaultOIDCProviderName
+	}
+	provider, err := oidc.NewProvider(oidc.Config{
+		Name:         name,
+		IssuerURL:    config.OIDCIssuerURL,
+		ClientID:     config.OIDCClientID,
+		ClientSecret: config.OIDCClientSecret,
+		RedirectURL:  config.OIDCRedirectURL,
+		Scopes:       strings.Fields(config.OIDCScopes),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	providers[name] = provider
+	return providers, nil
+}
+
 /* SetupRouter sets up the router */
 func (server *Server) setupRouter() {
 	router := gin.Default()
 
-	authenticatedRoutes := router.Group("/").Use(AuthenticationMiddleware(server.Authenticator))
+	authenticationMiddleware := AuthenticationMiddleware(server.Authenticator, server.SessionCache, server.DataStore)
+	// routes that personal access tokens may use, each one requires a scope or a permission
+	authenticatedRoutes := router.Group("/").Use(authenticationMiddleware)
+	// routes that manage the account, only session access tokens may use them
+	sessionRoutes := router.Group("/").Use(authenticationMiddleware, RequireSession())
 
 	router.POST("/api/user/create", server.CreateUserAccount)
 	router.POST("/api/user/login", server.LoginUser)
-	authenticatedRoutes.GET("/api/user/getByUsername/:username", server.GetUserByUsername)
-	authenticatedRoutes.PUT("/api/user/updateInterests", server.UpdateUserInterests)
-	authenticatedRoutes.DELETE("/api/user/delete/:username", server.DeleteUserAccount)
+	router.POST("/api/user/login/mfa", server.LoginUserWithMFA)
+	router.POST("/api/user/password/forgot", server.ForgotPassword)
+	router.POST("/api/user/password/reset", server.ResetPassword)
+	router.POST("/api/user/email/verify", server.VerifyEmail)
+	router.GET("/api/user/oidc/:provider/login", server.StartOIDCLogin)
+	router.POST("/api/user/oidc/:provider/callback", server.FinishOIDCLogin)
+	sessionRoutes.POST("/api/user/oidc/:provider/link", server.StartOIDCLink)
+	sessionRoutes.POST("/api/user/oidc/:provider/link/callback", server.FinishOIDCLink)
+	sessionRoutes.POST("/api/user/email/resend", server.ResendVerificationEmail)
+	sessionRoutes.PUT("/api/user/password", server.ChangePassword)
+	sessionRoutes.POST("/api/user/mfa/totp/enroll", server.EnrollTOTP)
+	sessionRoutes.POST("/api/user/mfa/totp/confirm", server.ConfirmTOTP)
+	authenticatedRoutes.GET("/api/user/getByUsername/:username", RequireScope(auth.ScopeRead), server.GetUserByUsername)
+	sessionRoutes.PUT("/api/user/updateInterests", server.UpdateUserInterests)
+	sessionRoutes.DELETE("/api/user/delete/:username", server.DeleteUserAccount)
+	sessionRoutes.POST("/api/user/logout", server.LogoutUser)
+	sessionRoutes.GET("/api/user/sessions", server.GetUserSessions)
+	sessionRoutes.DELETE("/api/user/sessions/:id", server.RevokeSession)
+	sessionRoutes.POST("/api/user/sessions/revokeOthers", server.RevokeOtherSessions)
+	sessionRoutes.POST("/api/user/tokens", server.CreatePersonalAccessToken)
+	sessionRoutes.GET("/api/user/tokens", server.GetPersonalAccessTokens)
+	sessionRoutes.DELETE("/api/user/tokens/:id", server.RevokePersonalAccessToken)
+	sessionRoutes.POST("/api/user/invites", RequirePermission(auth.PermissionCreateInvites), server.CreateInvite)
+	sessionRoutes.GET("/api/user/invites", server.GetInvites)
+	sessionRoutes.DELETE("/api/user/invites/:id", server.DeleteInvite)
 
-	authenticatedRoutes.POST("/api/post/create", server.CreateNewPost)
+	authenticatedRoutes.POST("/api/post/create", RequirePermission(auth.PermissionWritePosts), server.CreateNewPost)
+	router.GET("/api/post/getPublished", server.GetPublishedPosts)
+	router.GET("/api/post/search", server.SearchPosts)
 	router.GET("/api/post/getByID/:id", server.GetPostById)
+	router.GET("/api/@:username/:slug", server.GetPostByPermalink)
 	router.GET("/api/post/getByCategory/:category", server.GetPostsByCategory)
+	router.GET("/api/post/getByTag/:tag", server.GetPostsByTag)
 	router.GET("/api/post/getPublishedByUsername/:username", server.GetPublishedPostsByUsername)
-	authenticatedRoutes.GET("/api/post/getDraftsByUsername/:username", server.GetDraftPostsByUsername)
-	authenticatedRoutes.PUT("/api/post/updateBody", server.UpdatePostBody)
-	authenticatedRoutes.PUT("/api/post/publish", server.UpdatePostStatus)
-	authenticatedRoutes.DELETE("/api/post/delete/:id", server.DeletePost)
+	authenticatedRoutes.GET("/api/post/getDraftsByUsername/:username", RequireScope(auth.ScopeRead), server.GetDraftPostsByUsername)
+	authenticatedRoutes.PUT("/api/post/updateBody", RequirePermission(auth.PermissionWritePosts), server.UpdatePostBody)
+	authenticatedRoutes.PUT("/api/post/publish", RequirePermission(auth.PermissionWritePosts), server.UpdatePostStatus)
+	authenticatedRoutes.DELETE("/api/post/delete/:id", RequireScope(auth.ScopePostsWrite), server.DeletePost)
+	authenticatedRoutes.GET("/api/post/getRevisions/:id", RequireScope(auth.ScopeRead), server.GetPostRevisions)
+	authenticatedRoutes.GET("/api/post/getRevision/:id/:number", RequireScope(auth.ScopeRead), server.GetPostRevision)
+	authenticatedRoutes.GET("/api/post/diffRevisions/:id", RequireScope(auth.ScopeRead), server.DiffPostRevisions)
+	authenticatedRoutes.PUT("/api/post/restoreRevision", RequirePermission(auth.PermissionWritePosts), server.RestorePostRevision)
 
-	authenticatedRoutes.POST("/api/comment/create", server.CreateNewComment)
+	authenticatedRoutes.POST("/api/comment/create", RequirePermission(auth.PermissionWriteComments), server.CreateNewComment)
 	router.GET("/api/comment/getByPostID/:id", server.GetCommentsByPostID)
-	authenticatedRoutes.DELETE("/api/comment/delete/:id", server.DeleteComment)
+	authenticatedRoutes.DELETE("/api/comment/delete/:id", RequireScope(auth.ScopeCommentsWrite), server.DeleteComment)
+
+	router.GET("/api/tag/getAll", server.GetTags)
+	router.GET("/api/category/getAll", server.GetCategories)
+
+	authenticatedRoutes.DELETE("/api/moderation/comment/delete/:id", RequirePermission(auth.PermissionDeleteAnyComment), server.ModerateDeleteComment)
+	authenticatedRoutes.DELETE("/api/admin/post/delete/:id", RequirePermission(auth.PermissionDeleteAnyPost), server.AdminDeletePost)
+	authenticatedRoutes.DELETE("/api/admin/user/delete/:username", RequirePermission(auth.PermissionDeleteAnyUser), server.AdminDeleteUserAccount)
+	authenticatedRoutes.PUT("/api/admin/user/updateRole", RequirePermission(auth.PermissionManageRoles), server.UpdateUserRole)
+	authenticatedRoutes.POST("/api/admin/category/create", RequirePermission(auth.PermissionManageCategories), server.CreateCategory)
+	authenticatedRoutes.PUT("/api/admin/category/update/:slug", RequirePermission(auth.PermissionManageCategories), server.UpdateCategory)
+	authenticatedRoutes.DELETE("/api/admin/category/delete/:slug", RequirePermission(auth.PermissionManageCategories), server.DeleteCategory)
 
 	router.POST("/api/token/renew", server.RenewTokenRequest)
 
 	server.Router = router
 }
 
-/* StartServer starts the server */
+/* StartServer starts the server, it returns nil once the server is shut down */
 func (server *Server) StartServer(address string) error {
-	return server.Router.Run(address)
+	server.httpServer.Addr = address
+	err := server.httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+/*
+Shutdown stops accepting requests and waits for the requests in flight, then for the background tasks
+such as the queued emails. It gives up and returns the error of ctx once ctx is done.
+*/
+func (server *Server) Shutdown(ctx context.Context) error {
+	if err := server.httpServer.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.backgroundTasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func errorResponse(err error) gin.H {
 	return gin.H{"error": err.Error()}
 }
 
+/* isSameUsername compares usernames the way the database does, usernames are unique regardless of case */
+func isSameUsername(username string, otherUsername string) bool {
+	return strings.EqualFold(username, otherUsername)
+}
+
 func (server *Server) GetAuthPayload(ctx *gin.Context) *auth.AuthPayload {
 	authorizationPayload, exists := ctx.Get(authorizationPayloadKey)
 	if !exists {
@@ -124,3 +350,15 @@ func (server *Server) GetAuthPayload(ctx *gin.Context) *auth.AuthPayload {
 	}
 	return payload
 }
+
+func (server *Server) GetAuthSession(ctx *gin.Context) *db.Session {
+	authorizationSession, exists := ctx.Get(authorizationSessionKey)
+	if !exists {
+		return nil
+	}
+	session, ok := authorizationSession.(db.Session)
+	if !ok {
+		return nil
+	}
+	return &session
+}
@@ -7,43 +7,178 @@ import (
 	"strings"
 
 	"github.com/Oabraham1/open-blogger/server/auth"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
 	"github.com/Oabraham1/open-blogger/server/log"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthenticationMiddleware(authenticator auth.Authenticator) gin.HandlerFunc {
+/*
+AuthenticationMiddleware accepts session access tokens and personal access tokens.
+Personal access tokens have no session and carry scopes, which RequireScope and RequirePermission enforce.
+Without an authorization header the access token is read from the cookie set in cookie auth mode.
+*/
+func AuthenticationMiddleware(authenticator auth.Authenticator, sessionCache *SessionCache, store db.Querier) gin.HandlerFunc {
 	genericError := gin.H{"error": "unauthorized"}
+	internalError := gin.H{"error": "internal server error"}
 	return func(c *gin.Context) {
-		authorizationHeader := c.GetHeader(authorizationHeaderKey)
-		if authorizationHeader == "" {
-			logger.LogError(errors.New("authorization header is not provided"), "AuthenticationMiddleware")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+		token, ok := requestAccessToken(c)
+		if !ok {
 			return
 		}
 
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			logger.LogError(errors.New("invalid authorization header format"), "AuthenticationMiddleware")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+		if strings.HasPrefix(token, auth.PersonalAccessTokenPrefix) {
+			payload, err := verifyPersonalAccessToken(c, store, token)
+			if err != nil {
+				logger.LogError(err.Error(), "AuthenticationMiddleware")
+				if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrExpiredToken) {
+					c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusInternalServerError, internalError)
+				return
+			}
+
+			c.Set(authorizationPayloadKey, payload)
+			c.Next()
 			return
 		}
 
-		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != authorizationTypeBearer {
-			logger.LogError(fmt.Errorf("unsupported authorization type %s", authorizationType), "AuthenticationMiddleware")
+		payload, err := authenticator.VerifyToken(token)
+		if err != nil {
+			logger.LogError(err.Error(), "AuthenticationMiddleware")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+			return
+		}
+		// a refresh token shares its session and claims with the access token, it only renews them
+		if payload.TokenType != auth.TokenTypeAccess {
+			logger.LogError(errors.New("token is not an access token"), "AuthenticationMiddleware")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
 			return
 		}
 
-		token := fields[1]
-		payload, err := authenticator.VerifyToken(token)
+		// reject tokens whose session has been blocked, deleted or has expired
+		session, err := sessionCache.GetActiveSession(c, payload.SessionID)
 		if err != nil {
 			logger.LogError(err.Error(), "AuthenticationMiddleware")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+			if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrSessionBlocked) || errors.Is(err, ErrSessionExpired) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, internalError)
 			return
 		}
 
 		c.Set(authorizationPayloadKey, payload)
+		c.Set(authorizationSessionKey, session)
+		c.Next()
+	}
+}
+
+/*
+requestAccessToken returns the bearer token of the authorization header. Without the header the token is read
+from the access token cookie, which also needs a CSRF token on unsafe methods. It aborts the request and returns false on failure.
+*/
+func requestAccessToken(c *gin.Context) (string, bool) {
+	genericError := gin.H{"error": "unauthorized"}
+
+	authorizationHeader := c.GetHeader(authorizationHeaderKey)
+	if authorizationHeader == "" {
+		token, err := c.Cookie(accessTokenCookieName)
+		if err != nil || token == "" {
+			logger.LogError(errors.New("authorization header is not provided"), "AuthenticationMiddleware")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+			return "", false
+		}
+		if err := verifyCSRFToken(c); err != nil {
+			logger.LogError(err.Error(), "AuthenticationMiddleware")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return "", false
+		}
+		return token, true
+	}
+
+	fields := strings.Fields(authorizationHeader)
+	if len(fields) < 2 {
+		logger.LogError(errors.New("invalid authorization header format"), "AuthenticationMiddleware")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+		return "", false
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		logger.LogError(fmt.Errorf("unsupported authorization type %s", authorizationType), "AuthenticationMiddleware")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, genericError)
+		return "", false
+	}
+	return fields[1], true
+}
+
+/* RequireRole only lets requests through when the authenticated user holds one of the roles */
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(authorizationPayloadKey)
+		payload, ok := value.(*auth.AuthPayload)
+		if ok {
+			for _, role := range roles {
+				if payload.Role == role {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		logger.LogError(fmt.Sprintf("role is not one of %v", roles), "RequireRole")
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+/* RequirePermission only lets requests through when the role of the authenticated user is granted the permission */
+func RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(authorizationPayloadKey)
+		payload, ok := value.(*auth.AuthPayload)
+		if !ok || !auth.HasPermission(payload.Role, permission) {
+			logger.LogError(fmt.Sprintf("role does not have permission %s", permission), "RequirePermission")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		// personal access tokens also need the scope of the permission, permissions without a scope are denied to them
+		scope, hasScope := auth.ScopeForPermission(permission)
+		if payload.IsPersonalAccessToken() && (!hasScope || !payload.HasScope(scope)) {
+			logger.LogError(fmt.Sprintf("token does not have the scope for permission %s", permission), "RequirePermission")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+/* RequireScope only lets personal access tokens through when they hold the scope, session access tokens always pass */
+func RequireScope(scope auth.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(authorizationPayloadKey)
+		payload, ok := value.(*auth.AuthPayload)
+		if !ok || !payload.HasScope(scope) {
+			logger.LogError(fmt.Sprintf("token does not have scope %s", scope), "RequireScope")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+/* RequireSession rejects personal access tokens on routes that manage the account itself */
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get(authorizationSessionKey); !exists {
+			logger.LogError("route requires a session access token", "RequireSession")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -1,20 +1,35 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/Oabraham1/open-blogger/server/auth"
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 )
 
 func TestAuthenticationMiddleware(t *testing.T) {
+	sessionID := uuid.New()
+	session := db.Session{
+		ID:        sessionID,
+		FamilyID:  sessionID,
+		Username:  "testUser",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
 	testCases := []struct {
 		name          string
 		setupAuth     func(t *testing.T, request *http.Request, authenticator auth.Authenticator)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
@@ -22,6 +37,7 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, "testUser", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
@@ -30,6 +46,7 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			name: "NoAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 			},
+			buildStubs: func(store *mockdb.MockStore) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -39,6 +56,7 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, "unsupported", "testUser", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -48,6 +66,7 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, "", "testUser", time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
@@ -57,21 +76,108 @@ func TestAuthenticationMiddleware(t *testing.T) {
 			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
 				addAuth(t, request, authenticator, authorizationTypeBearer, "testUser", -time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				token, _, err := authenticator.CreateToken("testUser", auth.RoleAuthor, sessionID, auth.TokenTypeRefresh, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+token)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithSession(t, request, authenticator, authorizationTypeBearer, "testUser", sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				blockedSession := session
+				blockedSession.IsBlocked = true
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(sessionID)).
+					Times(1).
+					Return(blockedSession, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "DeletedSession",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithSession(t, request, authenticator, authorizationTypeBearer, "testUser", sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(sessionID)).
+					Times(1).
+					Return(db.Session{}, util.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredSession",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithSession(t, request, authenticator, authorizationTypeBearer, "testUser", sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredSession := session
+				expiredSession.ExpiresAt = time.Now().Add(-time.Minute)
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(sessionID)).
+					Times(1).
+					Return(expiredSession, nil)
+			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "SessionLookupError",
+			setupAuth: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {
+				addAuthWithSession(t, request, authenticator, authorizationTypeBearer, "testUser", sessionID, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSessionById(gomock.Any(), gomock.Eq(sessionID)).
+					Times(1).
+					Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
 			authPath := "/auth"
 			server.Router.GET(
 				authPath,
-				AuthenticationMiddleware(server.Authenticator),
+				AuthenticationMiddleware(server.Authenticator, server.SessionCache, server.DataStore),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -87,3 +193,131 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRequirePermissionMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          string
+		permission    auth.Permission
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "AuthorCanWritePosts",
+			role:       auth.RoleAuthor,
+			permission: auth.PermissionWritePosts,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "ReaderCannotWritePosts",
+			role:       auth.RoleReader,
+			permission: auth.PermissionWritePosts,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "ModeratorCanDeleteAnyComment",
+			role:       auth.RoleModerator,
+			permission: auth.PermissionDeleteAnyComment,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "UnknownRole",
+			role:       "unknown",
+			permission: auth.PermissionWriteComments,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			authPath := "/auth"
+			server.Router.GET(
+				authPath,
+				AuthenticationMiddleware(server.Authenticator, server.SessionCache, server.DataStore),
+				RequirePermission(tc.permission),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthWithRole(t, request, server.Authenticator, authorizationTypeBearer, "testUser", tc.role, uuid.New(), time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequireRoleMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		role          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Admin",
+			role: auth.RoleAdmin,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Moderator",
+			role: auth.RoleModerator,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Author",
+			role: auth.RoleAuthor,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+			authPath := "/auth"
+			server.Router.GET(
+				authPath,
+				AuthenticationMiddleware(server.Authenticator, server.SessionCache, server.DataStore),
+				RequireRole(auth.RoleModerator, auth.RoleAdmin),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthWithRole(t, request, server.Authenticator, authorizationTypeBearer, "testUser", tc.role, uuid.New(), time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
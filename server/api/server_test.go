@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/auth"
+	"github.com/Oabraham1/open-blogger/server/lockout"
+	"github.com/Oabraham1/open-blogger/server/mail"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	authenticator, err := newAuthenticator(util.Config{
+		TokenSymmetricKey: "01234567890123456789012345678901",
+	})
+	require.NoError(t, err)
+	require.IsType(t, &auth.PasetoAuthenticator{}, authenticator)
+
+	authenticator, err = newAuthenticator(util.Config{
+		TokenFormat:       auth.TokenFormatPasetoPublic,
+		TokenSigningKeyID: "key-1",
+		TokenSigningKey:   hex.EncodeToString(privateKey),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &auth.PasetoPublicAuthenticator{}, authenticator)
+
+	authenticator, err = newAuthenticator(util.Config{
+		TokenFormat:       auth.TokenFormatJWTHS256,
+		TokenSymmetricKey: "01234567890123456789012345678901",
+		TokenIssuer:       "open-blogger",
+	})
+	require.NoError(t, err)
+	require.IsType(t, &auth.JWTAuthenticator{}, authenticator)
+
+	authenticator, err = newAuthenticator(util.Config{
+		TokenFormat:       auth.TokenFormatJWTEdDSA,
+		TokenSigningKeyID: "key-1",
+		TokenSigningKey:   hex.EncodeToString(privateKey),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &auth.JWTAuthenticator{}, authenticator)
+
+	_, err = newAuthenticator(util.Config{TokenFormat: "unknown"})
+	require.Error(t, err)
+}
+
+func TestNewLoginAttemptStore(t *testing.T) {
+	store, err := newLoginAttemptStore(util.Config{}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &lockout.MemoryStore{}, store)
+
+	store, err = newLoginAttemptStore(util.Config{LoginAttemptStore: lockout.StorePostgres}, nil)
+	require.NoError(t, err)
+	require.IsType(t, &lockout.PostgresStore{}, store)
+
+	_, err = newLoginAttemptStore(util.Config{LoginAttemptStore: "unknown"}, nil)
+	require.Error(t, err)
+}
+
+func TestNewMailer(t *testing.T) {
+	/* An unset mailer only logs, the memory mailer has to be asked for */
+	mailer, err := newMailer(util.Config{})
+	require.NoError(t, err)
+	require.IsType(t, &mail.LogMailer{}, mailer)
+
+	mailer, err = newMailer(util.Config{Mailer: mail.MailerMemory})
+	require.NoError(t, err)
+	require.IsType(t, &mail.MemoryMailer{}, mailer)
+
+	_, err = newMailer(util.Config{Mailer: "unknown"})
+	require.Error(t, err)
+}
+
+func TestNewOIDCProviders(t *testing.T) {
+	providers, err := newOIDCProviders(util.Config{})
+	require.NoError(t, err)
+	require.Empty(t, providers)
+
+	providers, err = newOIDCProviders(util.Config{
+		OIDCIssuerURL:   "https://accounts.example.com",
+		OIDCClientID:    "client",
+		OIDCRedirectURL: testOIDCRedirectURL,
+	})
+	require.NoError(t, err)
+	require.Contains(t, providers, defaultOIDCProviderName)
+
+	_, err = newOIDCProviders(util.Config{OIDCIssuerURL: "https://accounts.example.com"})
+	require.Error(t, err)
+}
+
+func TestIsSameUsername(t *testing.T) {
+	require.True(t, isSameUsername("testuser", "testuser"))
+	require.True(t, isSameUsername("testuser", "TestUser"))
+	require.False(t, isSameUsername("testuser", "otheruser"))
+}
+
+func TestShutdownWaitsForBackgroundTasks(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	finished := make(chan struct{})
+	server.runInBackground("TestShutdownWaitsForBackgroundTasks", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		close(finished)
+		return nil
+	})
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Shutdown returned before the background task finished")
+	}
+
+	/* Shutdown gives up once its context is done */
+	server.runInBackground("TestShutdownWaitsForBackgroundTasks", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, server.Shutdown(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RevokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,min=1"`
+}
+
+type SessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Current   bool      `json:"current"`
+}
+
+func GetSessionResponse(session db.Session, current bool) SessionResponse {
+	return SessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		Current:   current,
+	}
+}
+
+/* isActiveSession reports whether the session still holds the latest refresh token of a login */
+func isActiveSession(session db.Session) bool {
+	return !session.IsBlocked && !session.IsUsed && session.ExpiresAt.After(time.Now())
+}
+
+func (server *Server) LogoutUser(ctx *gin.Context) {
+	// get auth session
+	authenticationSession := server.GetAuthSession(ctx)
+	if authenticationSession == nil {
+		logger.LogError("authentication session is nil", "LogoutUser")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	err := server.DataStore.BlockSessionFamily(ctx, authenticationSession.FamilyID)
+	if err != nil {
+		logger.LogError(err.Error(), "LogoutUser")
+		server.InternalServerError(ctx)
+		return
+	}
+	server.SessionCache.InvalidateUser(authenticationSession.Username)
+	server.clearAuthCookies(ctx)
+
+	server.ReturnOK(ctx, gin.H{"message": "Logged out successfully"})
+}
+
+func (server *Server) GetUserSessions(ctx *gin.Context) {
+	// get auth session
+	authenticationSession := server.GetAuthSession(ctx)
+	if authenticationSession == nil {
+		logger.LogError("authentication session is nil", "GetUserSessions")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	userSessions, err := server.DataStore.GetUserSessionsByUsername(ctx, authenticationSession.Username)
+	if err != nil {
+		logger.LogError(err.Error(), "GetUserSessions")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp := []SessionResponse{}
+	for _, userSession := range userSessions {
+		if isActiveSession(userSession) {
+			rsp = append(rsp, GetSessionResponse(userSession, userSession.FamilyID == authenticationSession.FamilyID))
+		}
+	}
+
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) RevokeSession(ctx *gin.Context) {
+	var req RevokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "RevokeSession")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// convert sessionId string to uuid
+	sessionId, err := uuid.Parse(req.ID)
+	if err != nil {
+		logger.LogError(err.Error(), "RevokeSession")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	// get auth payload
+	authenticationPayload := server.GetAuthPayload(ctx)
+	if authenticationPayload == nil {
+		logger.LogError("authentication payload is nil", "RevokeSession")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	userSession, err := server.DataStore.GetSessionById(ctx, sessionId)
+	if err != nil {
+		logger.LogError(err.Error(), "RevokeSession")
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return
+		}
+		server.InternalServerError(ctx)
+		return
+	}
+
+	if authenticationPayload.Username != userSession.Username {
+		logger.LogError("authentication payload username does not match session username", "RevokeSession")
+		server.NotFoundError(ctx)
+		return
+	}
+
+	err = server.DataStore.BlockSessionFamily(ctx, userSession.FamilyID)
+	if err != nil {
+		logger.LogError(err.Error(), "RevokeSession")
+		server.InternalServerError(ctx)
+		return
+	}
+	server.SessionCache.InvalidateUser(userSession.Username)
+
+	server.ReturnOK(ctx, gin.H{"message": "Session revoked successfully"})
+}
+
+func (server *Server) RevokeOtherSessions(ctx *gin.Context) {
+	// get auth session
+	authenticationSession := server.GetAuthSession(ctx)
+	if authenticationSession == nil {
+		logger.LogError("authentication session is nil", "RevokeOtherSessions")
+		server.UnauthorizedError(ctx)
+		return
+	}
+
+	err := server.DataStore.BlockUserSessionsExceptFamily(ctx, db.BlockUserSessionsExceptFamilyParams{
+		Username: authenticationSession.Username,
+		FamilyID: authenticationSession.FamilyID,
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "RevokeOtherSessions")
+		server.InternalServerError(ctx)
+		return
+	}
+	server.SessionCache.InvalidateUser(authenticationSession.Username)
+
+	server.ReturnOK(ctx, gin.H{"message": "Other sessions revoked successfully"})
+}
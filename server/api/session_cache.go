@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSessionCacheTTL = 30 * time.Second
+	maxSessionCacheEntries = 10000
+)
+
+/* Errors returned by SessionCache.GetActiveSession */
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionBlocked  = errors.New("session is blocked")
+	ErrSessionExpired  = errors.New("session has expired")
+)
+
+type sessionCacheEntry struct {
+	session  db.Session
+	found    bool
+	cachedAt time.Time
+}
+
+/*
+SessionCache keeps recently looked up sessions in memory for a short TTL,
+so checking that an access token's session is still active does not need a database round-trip on every request.
+*/
+type SessionCache struct {
+	store   db.Store
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[uuid.UUID]sessionCacheEntry
+}
+
+/* NewSessionCache creates a new session cache */
+func NewSessionCache(store db.Store, ttl time.Duration) *SessionCache {
+	if ttl <= 0 {
+		ttl = defaultSessionCacheTTL
+	}
+	return &SessionCache{
+		store:   store,
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]sessionCacheEntry),
+	}
+}
+
+/* GetActiveSession returns the session if it exists, is not blocked and has not expired */
+func (cache *SessionCache) GetActiveSession(ctx context.Context, sessionID uuid.UUID) (db.Session, error) {
+	entry, ok := cache.get(sessionID)
+	if !ok {
+		session, err := cache.store.GetSessionById(ctx, sessionID)
+		if err != nil && !errors.Is(err, util.ErrRecordNotFound) {
+			return db.Session{}, err
+		}
+		entry = sessionCacheEntry{session: session, found: err == nil, cachedAt: time.Now()}
+		cache.set(sessionID, entry)
+	}
+
+	if !entry.found {
+		return db.Session{}, ErrSessionNotFound
+	}
+	if entry.session.IsBlocked {
+		return db.Session{}, ErrSessionBlocked
+	}
+	if entry.session.ExpiresAt.Before(time.Now()) {
+		return db.Session{}, ErrSessionExpired
+	}
+	return entry.session, nil
+}
+
+/* InvalidateUser drops every cached session of the user, it must be called whenever sessions are blocked or deleted */
+func (cache *SessionCache) InvalidateUser(username string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for id, entry := range cache.entries {
+		if entry.session.Username == username {
+			delete(cache.entries, id)
+		}
+	}
+}
+
+func (cache *SessionCache) get(sessionID uuid.UUID) (sessionCacheEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	entry, ok := cache.entries[sessionID]
+	if !ok || time.Since(entry.cachedAt) > cache.ttl {
+		return sessionCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (cache *SessionCache) set(sessionID uuid.UUID, entry sessionCacheEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if len(cache.entries) >= maxSessionCacheEntries {
+		for id, cached := range cache.entries {
+			if time.Since(cached.cachedAt) > cache.ttl {
+				delete(cache.entries, id)
+			}
+		}
+	}
+	if len(cache.entries) >= maxSessionCacheEntries {
+		cache.entries = make(map[uuid.UUID]sessionCacheEntry)
+	}
+	cache.entries[sessionID] = entry
+}
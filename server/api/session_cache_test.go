@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSessionCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	cache := NewSessionCache(store, time.Minute)
+
+	session := db.Session{
+		ID:        uuid.New(),
+		Username:  "testUser",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	blockedSession := session
+	blockedSession.IsBlocked = true
+	missingSessionID := uuid.New()
+
+	gomock.InOrder(
+		store.EXPECT().
+			GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+			Times(1).
+			Return(session, nil),
+		store.EXPECT().
+			GetSessionById(gomock.Any(), gomock.Eq(session.ID)).
+			Times(1).
+			Return(blockedSession, nil),
+	)
+	store.EXPECT().
+		GetSessionById(gomock.Any(), gomock.Eq(missingSessionID)).
+		Times(1).
+		Return(db.Session{}, util.ErrRecordNotFound)
+
+	/* Lookups within the TTL are served from memory */
+	for i := 0; i < 3; i++ {
+		got, err := cache.GetActiveSession(context.Background(), session.ID)
+		require.NoError(t, err)
+		require.Equal(t, session.ID, got.ID)
+	}
+
+	/* Missing sessions are cached as well */
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetActiveSession(context.Background(), missingSessionID)
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	}
+
+	/* Invalidating the user reloads the session */
+	cache.InvalidateUser(session.Username)
+	_, err := cache.GetActiveSession(context.Background(), session.ID)
+	require.ErrorIs(t, err, ErrSessionBlocked)
+}
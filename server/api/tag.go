@@ -0,0 +1,143 @@
+package api
+
+import (
+	"errors"
+	"sort"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	logger "github.com/Oabraham1/open-blogger/server/log"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPostTags      = 10
+	maxTagSlugLength = 50
+)
+
+var errInvalidTag = errors.New("tags must have letters or digits and at most 50 characters")
+
+type GetPostsByTagRequest struct {
+	Tag string `uri:"tag" binding:"required,max=100"`
+}
+
+type TagResponse struct {
+	Slug      string `json:"slug"`
+	PostCount int64  `json:"post_count"`
+}
+
+type ListTagsResponse struct {
+	Tags []TagResponse `json:"tags"`
+}
+
+/* normalizeTags turns the tags of a request into sorted unique slugs, nil stays nil so that updates can keep the tags of a post */
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	slugs := []string{}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		slug := util.Slugify(tag)
+		if slug == "" || len(slug) > maxTagSlugLength {
+			return nil, errInvalidTag
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			slugs = append(slugs, slug)
+		}
+	}
+	sort.Strings(slugs)
+	return slugs, nil
+}
+
+/* addPostTags fills in the tags of posts with a single query */
+func (server *Server) addPostTags(ctx *gin.Context, posts []PostResponse) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	postIds := []uuid.UUID{}
+	for _, post := range posts {
+		postIds = append(postIds, uuid.MustParse(post.ID))
+	}
+
+	rows, err := server.DataStore.GetTagsByPostIDs(ctx, postIds)
+	if err != nil {
+		return err
+	}
+
+	tags := make(map[string][]string)
+	for _, row := range rows {
+		postId := row.PostID.String()
+		tags[postId] = append(tags[postId], row.Slug)
+	}
+	for i := range posts {
+		if postTags, ok := tags[posts[i].ID]; ok {
+			posts[i].Tags = postTags
+		}
+	}
+	return nil
+}
+
+/* returnListPosts returns a page of posts with their tags */
+func (server *Server) returnListPosts(ctx *gin.Context, posts []db.Post, limit int32, pointOfFailure string) {
+	server.returnListPostsResponse(ctx, GetListPostsResponse(posts, limit), pointOfFailure)
+}
+
+/* returnListPostsResponse adds the tags to the posts of the page and returns it */
+func (server *Server) returnListPostsResponse(ctx *gin.Context, rsp ListPostsResponse, pointOfFailure string) {
+	if err := server.addPostTags(ctx, rsp.Posts); err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.ReturnOK(ctx, rsp)
+}
+
+func (server *Server) GetPostsByTag(ctx *gin.Context) {
+	var req GetPostsByTagRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		logger.LogError(err.Error(), "GetPostsByTag")
+		server.BadRequestError(ctx)
+		return
+	}
+
+	page, ok := server.bindPage(ctx, "GetPostsByTag")
+	if !ok {
+		return
+	}
+
+	posts, err := server.DataStore.ListPublishedPostsByTag(ctx, db.ListPublishedPostsByTagParams{
+		Slug:              util.Slugify(req.Tag),
+		CursorPublishedAt: page.CursorKey,
+		CursorID:          page.CursorID,
+		PageLimit:         page.queryLimit(),
+	})
+	if err != nil {
+		logger.LogError(err.Error(), "GetPostsByTag")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	server.returnListPosts(ctx, posts, page.Limit, "GetPostsByTag")
+}
+
+/* GetTags lists the tags of published posts, the most used first */
+func (server *Server) GetTags(ctx *gin.Context) {
+	tags, err := server.DataStore.ListTagsWithPostCounts(ctx)
+	if err != nil {
+		logger.LogError(err.Error(), "GetTags")
+		server.InternalServerError(ctx)
+		return
+	}
+
+	rsp := ListTagsResponse{Tags: []TagResponse{}}
+	for _, tag := range tags {
+		rsp.Tags = append(rsp.Tags, TagResponse{Slug: tag.Slug, PostCount: tag.PostCount})
+	}
+	server.ReturnOK(ctx, rsp)
+}
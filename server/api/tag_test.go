@@ -0,0 +1,360 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := normalizeTags([]string{"Web Development", "golang", "web-development", "Go"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"go", "golang", "web-development"}, tags)
+
+	/* Leaving tags out is not the same as clearing them */
+	tags, err = normalizeTags(nil)
+	require.NoError(t, err)
+	require.Nil(t, tags)
+
+	tags, err = normalizeTags([]string{})
+	require.NoError(t, err)
+	require.NotNil(t, tags)
+	require.Empty(t, tags)
+
+	_, err = normalizeTags([]string{"!!!"})
+	require.ErrorIs(t, err, errInvalidTag)
+
+	_, err = normalizeTags([]string{string(bytes.Repeat([]byte("a"), maxTagSlugLength+1))})
+	require.ErrorIs(t, err, errInvalidTag)
+}
+
+func TestCreatePostWithTags(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	post.Status = db.StatusDraft
+
+	testCases := []struct {
+		name          string
+		tags          []string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			tags: []string{"Go", "Web Development", "go"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreatePostTxParams{
+					CreateNewPostParams: db.CreateNewPostParams{
+						Title:    post.Title,
+						Body:     post.Body,
+						Username: post.Username,
+						Status:   post.Status,
+						Category: post.Category,
+					},
+					Tags: []string{"go", "web-development"},
+				}
+				store.EXPECT().
+					GetCategoryBySlug(gomock.Any(), gomock.Eq(post.Category)).
+					Times(1).
+					Return(db.Category{Slug: post.Category}, nil)
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.CreatePostTxResult{Post: post, Tags: arg.Tags}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp PostResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, []string{"go", "web-development"}, rsp.Tags)
+			},
+		},
+		{
+			name: "InvalidTag",
+			tags: []string{"go", "#"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidTag.Error())
+			},
+		},
+		{
+			name: "TooManyTags",
+			tags: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreatePostTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"title":    post.Title,
+				"body":     post.Body,
+				"category": post.Category,
+				"username": post.Username,
+				"status":   post.Status,
+				"tags":     tc.tags,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api/post/create", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdatePostTags(t *testing.T) {
+	user, _ := generateDummyUser(t)
+	post := generateDummyPost(t, user)
+	post.ID = uuid.New()
+	post.Version = 1
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetPostById(gomock.Any(), gomock.Eq(post.ID)).
+		Times(1).
+		Return(post, nil)
+	store.EXPECT().
+		UpdatePostTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+			/* An empty list clears the tags of the post */
+			require.NotNil(t, arg.Tags)
+			require.Empty(t, arg.Tags)
+			return db.UpdatePostTxResult{Post: post, Tags: []string{}}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"post_id":  post.ID,
+		"body":     post.Body,
+		"username": post.Username,
+		"tags":     []string{},
+		"version":  post.Version,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPut, "/api/post/updateBody", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuth(t, request, server.Authenticator, authorizationTypeBearer, user.Username, time.Minute)
+	server.Router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestGetPostsByTag(t *testing.T) {
+	posts := generateDummyPublishedPosts(t, 2)
+
+	testCases := []struct {
+		name          string
+		tag           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			tag:  "Web%20Development",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPostsByTag(gomock.Any(), gomock.Eq(db.ListPublishedPostsByTagParams{
+						Slug:      "web-development",
+						PageLimit: defaultPageLimit + 1,
+					})).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Eq([]uuid.UUID{posts[0].ID, posts[1].ID})).
+					Times(1).
+					Return([]db.GetTagsByPostIDsRow{
+						{PostID: posts[0].ID, Slug: "golang"},
+						{PostID: posts[0].ID, Slug: "web-development"},
+						{PostID: posts[1].ID, Slug: "web-development"},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListPostsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Posts, 2)
+				require.Equal(t, []string{"golang", "web-development"}, rsp.Posts[0].Tags)
+				require.Equal(t, []string{"web-development"}, rsp.Posts[1].Tags)
+			},
+		},
+		{
+			name: "NoPosts",
+			tag:  "unused",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPostsByTag(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Post{}, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "TagsError",
+			tag:  "golang",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPostsByTag(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(posts, nil)
+				store.EXPECT().
+					GetTagsByPostIDs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			tag:  "golang",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListPublishedPostsByTag(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/post/getByTag/"+tc.tag, nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTagsWithPostCounts(gomock.Any()).
+					Times(1).
+					Return([]db.ListTagsWithPostCountsRow{
+						{Slug: "golang", PostCount: 3},
+						{Slug: "web-development", PostCount: 1},
+					}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ListTagsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, []TagResponse{
+					{Slug: "golang", PostCount: 3},
+					{Slug: "web-development", PostCount: 1},
+				}, rsp.Tags)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTagsWithPostCounts(gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/api/tag/getAll", nil)
+			require.NoError(t, err)
+
+			server.Router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
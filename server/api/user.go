@@ -3,8 +3,10 @@ package api
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/Oabraham1/open-blogger/server/auth"
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
 	logger "github.com/Oabraham1/open-blogger/server/log"
 	"github.com/Oabraham1/open-blogger/server/util"
@@ -14,15 +16,18 @@ import (
 
 type CreateUserAccountRequest struct {
 	Username  string `json:"username" binding:"required,alphanum"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required,min=6,max=1024"`
 	Email     string `json:"email" binding:"required,email"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
+	// InviteCode is required when registration is invite-only
+	InviteCode string `json:"invite_code"`
 }
 
+/* LoginUserAccountRequest identifies the account by its username or by its email address, both regardless of case */
 type LoginUserAccountRequest struct {
-	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Username string `json:"username" binding:"required,alphanum|email"`
+	Password string `json:"password" binding:"required,min=6,max=1024"`
 }
 
 type GetUserAccountByUsernameRequest struct {
@@ -39,29 +44,48 @@ type DeleteUserAccountRequest struct {
 }
 
 type UserAccountResponse struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	FullName string `json:"full_name"`
-	JoinedOn string `json:"joined_on"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	FullName      string `json:"full_name"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	JoinedOn      string `json:"joined_on"`
 }
 
 type LoginUserAccountResponse struct {
 	SessionID             uuid.UUID           `json:"session_id"`
-	AccessToken           string              `json:"access_token"`
+	AccessToken           string              `json:"access_token,omitempty"`
 	ExpiresAt             time.Time           `json:"expires_at"`
-	RefreshToken          string              `json:"refresh_token"`
+	RefreshToken          string              `json:"refresh_token,omitempty"`
 	RefreshTokenExpiresAt time.Time           `json:"refresh_token_expires_at"`
+	CSRFToken             string              `json:"csrf_token,omitempty"`
 	UserAccount           UserAccountResponse `json:"user_account"`
 }
 
+/*
+withCookieAuth replaces the tokens of the response with the CSRF token in cookie auth mode.
+The tokens are only kept in the HttpOnly cookies so that scripts reading the response cannot steal them.
+*/
+func (rsp LoginUserAccountResponse) withCookieAuth(csrfToken string) LoginUserAccountResponse {
+	if csrfToken == "" {
+		return rsp
+	}
+	rsp.AccessToken = ""
+	rsp.RefreshToken = ""
+	rsp.CSRFToken = csrfToken
+	return rsp
+}
+
 func GetUserAccountResponse(user db.User) UserAccountResponse {
 	return UserAccountResponse{
-		ID:       user.ID.String(),
-		Username: user.Username,
-		Email:    user.Email,
-		FullName: user.FirstName + " " + user.LastName,
-		JoinedOn: user.CreatedAt,
+		ID:            user.ID.String(),
+		Username:      user.Username,
+		Email:         user.Email,
+		FullName:      user.FirstName + " " + user.LastName,
+		Role:          string(user.Role),
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		JoinedOn:      user.CreatedAt,
 	}
 }
 
@@ -73,22 +97,49 @@ func (server *Server) CreateUserAccount(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := util.HashPassword(req.Password)
-	if err != nil {
-		server.InternalServerError(ctx)
+	if !server.checkRegistrationAllowed(ctx, req.InviteCode, "CreateUserAccount") {
 		return
 	}
 
-	arg := db.CreateNewUserParams{
-		Username:  req.Username,
-		Password:  hashedPassword,
-		Email:     req.Email,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
+	if err := server.PasswordPolicy.Validate(req.Password, req.Username); err != nil {
+		logger.LogError(err.Error(), "CreateUserAccount")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
 	}
 
-	user, err := server.DataStore.CreateNewUser(ctx, arg)
+	hashedPassword, err := server.PasswordHasher.Hash(req.Password)
 	if err != nil {
+		server.InternalServerError(ctx)
+		return
+	}
+
+	var verificationToken string
+	arg := db.CreateUserTxParams{
+		CreateNewUserParams: db.CreateNewUserParams{
+			Username:  req.Username,
+			Password:  hashedPassword,
+			Email:     req.Email,
+			FirstName: req.FirstName,
+			LastName:  req.LastName,
+		},
+		// the account is rolled back if the invite cannot be used or the verification token cannot be stored
+		AfterCreate: func(querier db.Querier, user db.User) error {
+			if err := server.consumeInvite(ctx, querier, req.InviteCode); err != nil {
+				return err
+			}
+			var err error
+			verificationToken, err = server.createVerificationToken(ctx, querier, user)
+			return err
+		},
+	}
+
+	result, err := server.DataStore.CreateUserTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, errInvalidInvite) {
+			logger.LogError(err.Error(), "CreateUserAccount")
+			ctx.JSON(http.StatusForbidden, errorResponse(errInvalidInvite))
+			return
+		}
 		if util.ErrorCode(err) == util.UniqueViolation {
 			logger.LogError(err.Error(), "CreateUserAccount")
 			server.ForbiddenError(ctx)
@@ -98,7 +149,12 @@ func (server *Server) CreateUserAccount(ctx *gin.Context) {
 		return
 	}
 
-	rsp := GetUserAccountResponse(user)
+	// the email is sent once the account is committed, the user can ask for another one when it fails
+	if err := server.mailVerificationToken(ctx, result.User, verificationToken); err != nil {
+		logger.LogError(err.Error(), "CreateUserAccount")
+	}
+
+	rsp := GetUserAccountResponse(result.User)
 	server.ReturnOK(ctx, rsp)
 }
 
@@ -110,10 +166,15 @@ func (server *Server) LoginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := server.DataStore.GetUserByUsername(ctx, req.Username)
+	if !server.checkLoginAllowed(ctx, req.Username, "LoginUser") {
+		return
+	}
+
+	user, err := server.getUserByLogin(ctx, req.Username)
 	if err != nil {
 		logger.LogError(err.Error(), "LoginUser")
 		if errors.Is(err, util.ErrRecordNotFound) {
+			server.recordLoginFailure(ctx, req.Username, "LoginUser")
 			server.NotFoundError(ctx)
 			return
 		}
@@ -121,50 +182,141 @@ func (server *Server) LoginUser(ctx *gin.Context) {
 		return
 	}
 
-	err = util.VerifyPassword(user.Password, req.Password)
+	// failed logins are counted for the account, so that switching to the email address does not reset the backoff
+	if !isSameUsername(req.Username, user.Username) && !server.checkLoginAllowed(ctx, user.Username, "LoginUser") {
+		return
+	}
+
+	needsRehash, err := server.PasswordHasher.Verify(user.Password, req.Password)
 	if err != nil {
 		logger.LogError(err.Error(), "LoginUser")
+		server.recordLoginFailure(ctx, user.Username, "LoginUser")
 		server.ForbiddenError(ctx)
 		return
 	}
 
-	token, payload, err := server.Authenticator.CreateToken(user.Username, server.Configurations.AccessTokenDuration)
+	if needsRehash {
+		server.rehashPassword(ctx, user, req.Password, "LoginUser")
+	}
+
+	// accounts with two-factor authentication get a challenge instead of a session, their failures are only reset once the second factor is verified
+	mfaEnabled, ok := server.isTOTPEnabled(ctx, user.Username, "LoginUser")
+	if !ok {
+		return
+	}
+	if mfaEnabled {
+		server.createMFAChallenge(ctx, user, "LoginUser")
+		return
+	}
+
+	rsp, ok := server.createLoginSession(ctx, user, "LoginUser")
+	if !ok {
+		return
+	}
+	server.resetLoginFailures(ctx, user.Username, "LoginUser")
+	server.ReturnOK(ctx, rsp)
+}
+
+/* getUserByLogin finds the user by email address when the login contains an @, which usernames never do, and by username otherwise */
+func (server *Server) getUserByLogin(ctx *gin.Context, login string) (db.User, error) {
+	if strings.Contains(login, "@") {
+		return server.DataStore.GetUserByEmail(ctx, login)
+	}
+	return server.DataStore.GetUserByUsername(ctx, login)
+}
+
+/*
+rehashPassword replaces a hash that uses an outdated algorithm or parameters with a new hash of the verified password.
+Failures are logged without failing the login, the old hash stays valid.
+*/
+func (server *Server) rehashPassword(ctx *gin.Context, user db.User, password string, pointOfFailure string) {
+	hashedPassword, err := server.PasswordHasher.Hash(password)
 	if err != nil {
-		logger.LogError(err.Error(), "LoginUser")
-		server.InternalServerError(ctx)
+		logger.LogError(err.Error(), pointOfFailure)
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.Authenticator.CreateToken(user.Username, server.Configurations.RefreshTokenDuration)
+	// the old hash is part of the condition so that a concurrent password change is not overwritten
+	_, err = server.DataStore.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		NewPassword: hashedPassword,
+		Username:    user.Username,
+		OldPassword: user.Password,
+	})
 	if err != nil {
-		logger.LogError(err.Error(), "LoginUser")
+		logger.LogError(err.Error(), pointOfFailure)
+	}
+}
+
+/*
+createLoginSession issues an access and refresh token pair for a new session and sets them as cookies in cookie auth mode,
+where the response carries the CSRF token instead of the tokens. It writes the error response and returns false on failure.
+*/
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User, pointOfFailure string) (LoginUserAccountResponse, bool) {
+	sessionParams, rsp, ok := server.issueLoginTokens(ctx, user, pointOfFailure)
+	if !ok {
+		return LoginUserAccountResponse{}, false
+	}
+
+	_, err := server.DataStore.CreateNewUserSession(ctx, sessionParams)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
 		server.InternalServerError(ctx)
-		return
+		return LoginUserAccountResponse{}, false
+	}
+
+	csrfToken, err := server.setAuthCookies(ctx, rsp.AccessToken, rsp.ExpiresAt, rsp.RefreshToken, rsp.RefreshTokenExpiresAt)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return LoginUserAccountResponse{}, false
+	}
+	return rsp.withCookieAuth(csrfToken), true
+}
+
+/*
+issueLoginTokens creates an access and refresh token pair for a new session of the requesting device
+without storing the session, it writes the error response and returns false on failure
+*/
+func (server *Server) issueLoginTokens(ctx *gin.Context, user db.User, pointOfFailure string) (db.CreateNewUserSessionParams, LoginUserAccountResponse, bool) {
+	sessionID, err := uuid.NewRandom()
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return db.CreateNewUserSessionParams{}, LoginUserAccountResponse{}, false
 	}
 
-	userSession, err := server.DataStore.CreateNewUserSession(ctx, db.CreateNewUserSessionParams{
+	token, payload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeAccess, server.Configurations.AccessTokenDuration)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return db.CreateNewUserSessionParams{}, LoginUserAccountResponse{}, false
+	}
+
+	refreshToken, refreshPayload, err := server.Authenticator.CreateToken(user.Username, string(user.Role), sessionID, auth.TokenTypeRefresh, server.Configurations.RefreshTokenDuration)
+	if err != nil {
+		logger.LogError(err.Error(), pointOfFailure)
+		server.InternalServerError(ctx)
+		return db.CreateNewUserSessionParams{}, LoginUserAccountResponse{}, false
+	}
+
+	sessionParams := db.CreateNewUserSessionParams{
+		ID:           sessionID,
+		FamilyID:     sessionID,
 		Username:     user.Username,
 		RefreshToken: refreshToken,
 		UserAgent:    ctx.Request.UserAgent(),
 		ClientIp:     ctx.ClientIP(),
 		ExpiresAt:    refreshPayload.ExpiredAt,
-	})
-	if err != nil {
-		logger.LogError(err.Error(), "LoginUser")
-		server.InternalServerError(ctx)
-		return
 	}
-
 	rsp := LoginUserAccountResponse{
-		SessionID:             userSession.ID,
+		SessionID:             sessionID,
 		AccessToken:           token,
 		ExpiresAt:             payload.ExpiredAt,
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 		UserAccount:           GetUserAccountResponse(user),
 	}
-
-	server.ReturnOK(ctx, rsp)
+	return sessionParams, rsp, true
 }
 
 func (server *Server) GetUserByUsername(ctx *gin.Context) {
@@ -183,7 +335,7 @@ func (server *Server) GetUserByUsername(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "GetUserByUsername")
 		server.UnauthorizedError(ctx)
 		return
@@ -216,7 +368,7 @@ func (server *Server) UpdateUserInterests(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "UpdateUserInterests")
 		server.UnauthorizedError(ctx)
 		return
@@ -264,7 +416,7 @@ func (server *Server) DeleteUserAccount(ctx *gin.Context) {
 		return
 	}
 
-	if authenticationPayload.Username != req.Username {
+	if !isSameUsername(authenticationPayload.Username, req.Username) {
 		logger.LogError("authentication payload username does not match request username", "DeleteUserAccount")
 		server.UnauthorizedError(ctx)
 		return
@@ -281,60 +433,31 @@ func (server *Server) DeleteUserAccount(ctx *gin.Context) {
 		return
 	}
 
-	// Get all Comments by User ID
-	comments, err := server.DataStore.GetCommentsByUserName(ctx, req.Username)
-	if err != nil {
-		logger.LogError(err.Error(), "DeleteUserAccount")
-		server.InternalServerError(ctx)
+	if !server.deleteUserAccountAndContent(ctx, user.Username, "DeleteUserAccount") {
 		return
 	}
-	for _, comment := range comments {
-		err = server.DataStore.DeleteCommentByID(ctx, comment.ID)
-		if err != nil {
-			logger.LogError(err.Error(), "DeleteUserAccount")
-			server.InternalServerError(ctx)
-			return
-		}
-	}
 
-	// Get all Posts by User ID
-	posts, err := server.DataStore.GetPostsByUserName(ctx, user.Username)
-	if err != nil {
-		logger.LogError(err.Error(), "DeleteUserAccount")
-		server.InternalServerError(ctx)
-		return
-	}
-	for _, post := range posts {
-		err = server.DataStore.DeletePostByID(ctx, post.ID)
-		if err != nil {
-			logger.LogError(err.Error(), "DeleteUserAccount")
-			server.InternalServerError(ctx)
-			return
-		}
-	}
+	server.ReturnOK(ctx, gin.H{"message": "User account deleted successfully"})
+}
 
-	// Get all UserSessions by Username
-	userSessions, err := server.DataStore.GetUserSessionsByUsername(ctx, user.Username)
+/* deleteUserAccountAndContent deletes a user with all of their comments, posts and sessions, it writes the error response and returns false on failure */
+func (server *Server) deleteUserAccountAndContent(ctx *gin.Context, username string, pointOfFailure string) bool {
+	err := server.DataStore.DeleteUserAccountTx(ctx, username)
 	if err != nil {
-		logger.LogError(err.Error(), "DeleteUserAccount")
-		server.InternalServerError(ctx)
-		return
-	}
-	for _, userSession := range userSessions {
-		err = server.DataStore.DeleteSessionById(ctx, userSession.ID)
-		if err != nil {
-			logger.LogError(err.Error(), "DeleteUserAccount")
-			server.InternalServerError(ctx)
-			return
+		logger.LogError(err.Error(), pointOfFailure)
+		if errors.Is(err, util.ErrRecordNotFound) {
+			server.NotFoundError(ctx)
+			return false
+		}
+		// someone has to be left to manage the roles
+		if errors.Is(err, db.ErrLastAdmin) {
+			server.ConflictError(ctx)
+			return false
 		}
-	}
-
-	err = server.DataStore.DeleteUserAccount(ctx, user.Username)
-	if err != nil {
-		logger.LogError(err.Error(), "DeleteUserAccount")
 		server.InternalServerError(ctx)
-		return
+		return false
 	}
 
-	server.ReturnOK(ctx, gin.H{"message": "User account deleted successfully"})
+	server.SessionCache.InvalidateUser(username)
+	return true
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,12 +19,23 @@ import (
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
 	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func addAuth(t *testing.T, request *http.Request, authenticator auth.Authenticator, authType string, username string, duration time.Duration) {
-	token, payload, err := authenticator.CreateToken(username, duration)
+	addAuthWithSession(t, request, authenticator, authType, username, uuid.New(), duration)
+}
+
+func addAuthWithSession(t *testing.T, request *http.Request, authenticator auth.Authenticator, authType string, username string, sessionID uuid.UUID, duration time.Duration) {
+	addAuthWithRole(t, request, authenticator, authType, username, auth.RoleAuthor, sessionID, duration)
+}
+
+func addAuthWithRole(t *testing.T, request *http.Request, authenticator auth.Authenticator, authType string, username string, role string, sessionID uuid.UUID, duration time.Duration) {
+	token, payload, err := authenticator.CreateToken(username, role, sessionID, auth.TokenTypeAccess, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 	require.NotEmpty(t, token)
@@ -31,13 +44,13 @@ func addAuth(t *testing.T, request *http.Request, authenticator auth.Authenticat
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
 }
 
-type eqCreateUserParamsMatcher struct {
+type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateNewUserParams
 	password string
 }
 
-func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateNewUserParams)
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
@@ -48,19 +61,19 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 	}
 
 	e.arg.Password = arg.Password
-	return reflect.DeepEqual(e.arg, arg)
+	return reflect.DeepEqual(e.arg, arg.CreateNewUserParams) && arg.AfterCreate != nil
 }
 
-func (e eqCreateUserParamsMatcher) String() string {
+func (e eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
-func EqCreateUserParams(arg db.CreateNewUserParams, password string) gomock.Matcher {
-	return eqCreateUserParamsMatcher{arg, password}
+func EqCreateUserTxParams(arg db.CreateNewUserParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
 }
 
 func generateDummyUser(t *testing.T) (user db.User, password string) {
-	password = "password123"
+	password = "correct horse battery"
 	hashedPassword, err := util.HashPassword(password)
 	require.NoError(t, err)
 	user = db.User{
@@ -69,6 +82,7 @@ func generateDummyUser(t *testing.T) (user db.User, password string) {
 		Email:     "test@email.com",
 		FirstName: "test",
 		LastName:  "user",
+		Role:      db.UserRoleAuthor,
 	}
 	return
 }
@@ -99,9 +113,20 @@ func TestCreateNewUserAccount(t *testing.T) {
 					Password:  user.Password,
 				}
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
 					Times(1).
-					Return(user, nil)
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						err := arg.AfterCreate(store, user)
+						return db.CreateUserTxResult{User: user}, err
+					})
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(defaultEmailVerificationTokenDuration), arg.ExpiresAt, time.Second)
+						return db.EmailVerificationToken{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -130,9 +155,35 @@ func TestCreateNewUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "VerificationEmailFailed",
+			body: gin.H{
+				"username":   user.Username,
+				"password":   password,
+				"email":      user.Email,
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						err := arg.AfterCreate(store, user)
+						return db.CreateUserTxResult{User: user}, err
+					})
+				store.EXPECT().
+					CreateEmailVerificationToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.EmailVerificationToken{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -149,14 +200,52 @@ func TestCreateNewUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, util.ErrUniqueViolation)
+					Return(db.CreateUserTxResult{}, util.ErrUniqueViolation)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
+		{
+			name: "CommonPassword",
+			body: gin.H{
+				"username":   user.Username,
+				"password":   "password123",
+				"email":      user.Email,
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordTooCommon.Error())
+			},
+		},
+		{
+			name: "PasswordContainsUsername",
+			body: gin.H{
+				"username":   user.Username,
+				"password":   user.Username + " is my password",
+				"email":      user.Email,
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, util.ErrPasswordContainsUsername.Error())
+			},
+		},
 		{
 			name: "InvalidUsername",
 			body: gin.H{
@@ -168,7 +257,7 @@ func TestCreateNewUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -186,7 +275,7 @@ func TestCreateNewUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -204,7 +293,25 @@ func TestCreateNewUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateNewUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooLongPassword",
+			body: gin.H{
+				"username":   user.Username,
+				"password":   strings.Repeat("a", util.MaxPasswordLength+1),
+				"email":      user.Email,
+				"first_name": user.FirstName,
+				"last_name":  user.LastName,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -259,6 +366,216 @@ func TestLoginUser(t *testing.T) {
 					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UsernameInAnotherCase",
+			body: gin.H{
+				"username": strings.ToUpper(user.Username),
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(strings.ToUpper(user.Username))).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.Session{ID: arg.ID, FamilyID: arg.FamilyID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLoginResponse(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "Email",
+			body: gin.H{
+				"username": strings.ToUpper(user.Email),
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(strings.ToUpper(user.Email))).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "EmailNotFound",
+			body: gin.H{
+				"username": "unknown@email.com",
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq("unknown@email.com")).
+					Times(1).
+					Return(db.User{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "LegacyBcryptHash",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				legacyUser := user
+				legacyUser.Password = string(bcryptHash)
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(legacyUser, nil)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RehashUserPasswordParams) (int64, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, legacyUser.Password, arg.OldPassword)
+						require.True(t, strings.HasPrefix(arg.NewPassword, "$argon2id$"))
+						require.NoError(t, util.VerifyPassword(arg.NewPassword, password))
+						return 1, nil
+					})
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RehashFailureDoesNotFailLogin",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+				require.NoError(t, err)
+				legacyUser := user
+				legacyUser.Password = string(bcryptHash)
+
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(legacyUser, nil)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), sql.ErrConnDone)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, util.ErrRecordNotFound)
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MFARequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username, ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.MfaChallenge{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+				store.EXPECT().
+					CreateNewUserSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp map[string]interface{}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, true, rsp["mfa_required"])
+				require.NotEmpty(t, rsp["challenge_token"])
+				require.NotContains(t, rsp, "access_token")
+			},
+		},
+		{
+			name: "UnconfirmedTOTP",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					GetUserTOTP(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username}, nil)
+				store.EXPECT().
+					CreateMFAChallenge(gomock.Any(), gomock.Any()).
+					Times(0)
 				store.EXPECT().
 					CreateNewUserSession(gomock.Any(), gomock.Any()).
 					Times(1)
@@ -315,6 +632,22 @@ func TestLoginUser(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			/* An oversized password is refused before it is hashed */
+			name: "TooLongPassword",
+			body: gin.H{
+				"username": user.Username,
+				"password": strings.Repeat("a", util.MaxPasswordLength+1),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InvalidUsername",
 			body: gin.H{
@@ -582,19 +915,7 @@ func TestDeleteUserAccount(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					GetCommentsByUserName(gomock.Any(), gomock.Eq(user.Username)).
-					Times(1).
-					Return([]db.Comment{}, nil)
-				store.EXPECT().
-					GetPostsByUserName(gomock.Any(), gomock.Eq(user.Username)).
-					Times(1).
-					Return([]db.Post{}, nil)
-				store.EXPECT().
-					GetUserSessionsByUsername(gomock.Any(), gomock.Eq(user.Username)).
-					Times(1).
-					Return([]db.Session{}, nil)
-				store.EXPECT().
-					DeleteUserAccount(gomock.Any(), gomock.Eq(user.Username)).
+					DeleteUserAccountTx(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(nil)
 			},
@@ -615,7 +936,7 @@ func TestDeleteUserAccount(t *testing.T) {
 			setUpAuthenticator: func(t *testing.T, request *http.Request, authenticator auth.Authenticator) {},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					DeleteUserAccount(gomock.Any(), gomock.Any()).
+					DeleteUserAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -630,7 +951,7 @@ func TestDeleteUserAccount(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					DeleteUserAccount(gomock.Any(), gomock.Any()).
+					DeleteUserAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -649,7 +970,7 @@ func TestDeleteUserAccount(t *testing.T) {
 					Times(1).
 					Return(db.User{}, util.ErrRecordNotFound)
 				store.EXPECT().
-					DeleteUserAccount(gomock.Any(), gomock.Any()).
+					DeleteUserAccountTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -7,6 +7,15 @@ import (
 	"github.com/google/uuid"
 )
 
+/* TokenType tells access tokens and refresh tokens of the same session apart */
+type TokenType string
+
+/* Types of session tokens, access tokens authenticate requests and refresh tokens only renew them */
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
 /* Errors returned by VeirfyAuthToken */
 var (
 	ErrInvalidToken = errors.New("token is invalid")
@@ -16,13 +25,18 @@ var (
 /* AuthPayload is the payload of a token */
 type AuthPayload struct {
 	ID        uuid.UUID `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	TokenType TokenType `json:"token_type"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// Scopes of a personal access token, session access tokens carry none and are not limited by scopes
+	Scopes []Scope `json:"scopes,omitempty"`
 }
 
 /* NewAuthPayload creates a new token payload */
-func NewAuthPayload(username string, duration time.Duration) (*AuthPayload, error) {
+func NewAuthPayload(username string, role string, sessionID uuid.UUID, tokenType TokenType, duration time.Duration) (*AuthPayload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
@@ -30,7 +44,10 @@ func NewAuthPayload(username string, duration time.Duration) (*AuthPayload, erro
 
 	payload := &AuthPayload{
 		ID:        tokenID,
+		SessionID: sessionID,
 		Username:  username,
+		Role:      role,
+		TokenType: tokenType,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -44,3 +61,21 @@ func (payload *AuthPayload) Valid() error {
 	}
 	return nil
 }
+
+/* IsPersonalAccessToken reports whether the payload belongs to a personal access token */
+func (payload *AuthPayload) IsPersonalAccessToken() bool {
+	return payload.Scopes != nil
+}
+
+/* HasScope checks if the token may act within the scope, session access tokens hold every scope */
+func (payload *AuthPayload) HasScope(scope Scope) bool {
+	if !payload.IsPersonalAccessToken() {
+		return true
+	}
+	for _, granted := range payload.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
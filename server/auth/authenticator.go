@@ -2,10 +2,20 @@ package auth
 
 import (
 	"time"
+
+	"github.com/google/uuid"
+)
+
+/* Token formats that can be selected with the TOKEN_FORMAT configuration */
+const (
+	TokenFormatPasetoLocal  = "paseto_local"
+	TokenFormatPasetoPublic = "paseto_public"
+	TokenFormatJWTHS256     = "jwt_hs256"
+	TokenFormatJWTEdDSA     = "jwt_eddsa"
 )
 
 /* Authenticator is an interface for creating and verifying tokens */
 type Authenticator interface {
-	CreateToken(username string, duration time.Duration) (string, *AuthPayload, error)
+	CreateToken(username string, role string, sessionID uuid.UUID, tokenType TokenType, duration time.Duration) (string, *AuthPayload, error)
 	VerifyToken(token string) (*AuthPayload, error)
 }
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+/* testAuthenticator runs the tests every Authenticator implementation has to pass */
+func testAuthenticator(t *testing.T, newAuthenticator func(t *testing.T) Authenticator) {
+	t.Run("CreateToken", func(t *testing.T) {
+		authenticator := newAuthenticator(t)
+
+		username := "testUser"
+		sessionID := uuid.New()
+		duration := time.Minute * 15
+
+		issuedAt := time.Now()
+		expiredAt := issuedAt.Add(duration)
+
+		token, payload, err := authenticator.CreateToken(username, RoleAuthor, sessionID, TokenTypeAccess, duration)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.NotEmpty(t, payload)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.Equal(t, RoleAuthor, payload.Role)
+		require.Equal(t, TokenTypeAccess, payload.TokenType)
+		require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+		require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+	})
+
+	t.Run("VerifyToken", func(t *testing.T) {
+		authenticator := newAuthenticator(t)
+
+		username := "testUser"
+		sessionID := uuid.New()
+		duration := time.Minute * 15
+
+		token, createdPayload, err := authenticator.CreateToken(username, RoleAuthor, sessionID, TokenTypeAccess, duration)
+		require.NoError(t, err)
+
+		payload, err := authenticator.VerifyToken(token)
+		require.NoError(t, err)
+		require.NotEmpty(t, payload)
+		require.Equal(t, createdPayload.ID, payload.ID)
+		require.Equal(t, username, payload.Username)
+		require.Equal(t, sessionID, payload.SessionID)
+		require.Equal(t, RoleAuthor, payload.Role)
+		require.Equal(t, TokenTypeAccess, payload.TokenType)
+		require.WithinDuration(t, createdPayload.IssuedAt, payload.IssuedAt, time.Second)
+		require.WithinDuration(t, createdPayload.ExpiredAt, payload.ExpiredAt, time.Second)
+	})
+
+	t.Run("ExpiredToken", func(t *testing.T) {
+		authenticator := newAuthenticator(t)
+
+		token, payload, err := authenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, -time.Minute)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+		require.NotEmpty(t, payload)
+
+		payload, err = authenticator.VerifyToken(token)
+		require.Error(t, err)
+		require.EqualError(t, err, ErrExpiredToken.Error())
+		require.Nil(t, payload)
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		authenticator := newAuthenticator(t)
+
+		payload, err := authenticator.VerifyToken("invalidToken")
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	})
+
+	t.Run("TokenFromAnotherKey", func(t *testing.T) {
+		token, _, err := newAuthenticator(t).CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+
+		payload, err := newAuthenticator(t).VerifyToken(token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	})
+}
+
+func TestPasetoAuthenticator(t *testing.T) {
+	testAuthenticator(t, func(t *testing.T) Authenticator {
+		authenticator, err := NewPasetoAuthenticator(randomSymmetricKey(t))
+		require.NoError(t, err)
+		return authenticator
+	})
+}
+
+func TestPasetoPublicAuthenticator(t *testing.T) {
+	testAuthenticator(t, func(t *testing.T) Authenticator {
+		_, privateKey := generateEd25519Key(t)
+		authenticator, err := NewPasetoPublicAuthenticator("key-1", hex.EncodeToString(privateKey), "")
+		require.NoError(t, err)
+		return authenticator
+	})
+}
+
+func TestHS256JWTAuthenticator(t *testing.T) {
+	testAuthenticator(t, func(t *testing.T) Authenticator {
+		authenticator, err := NewHS256JWTAuthenticator(randomSymmetricKey(t), "open-blogger", "open-blogger-api")
+		require.NoError(t, err)
+		return authenticator
+	})
+}
+
+func TestEdDSAJWTAuthenticator(t *testing.T) {
+	testAuthenticator(t, func(t *testing.T) Authenticator {
+		_, privateKey := generateEd25519Key(t)
+		authenticator, err := NewEdDSAJWTAuthenticator("key-1", hex.EncodeToString(privateKey), "", "open-blogger", "open-blogger-api")
+		require.NoError(t, err)
+		return authenticator
+	})
+}
+
+func TestNewPasetoAuthenticatorInvalidKeySize(t *testing.T) {
+	_, err := NewPasetoAuthenticator("tooShort")
+	require.Error(t, err)
+}
+
+func randomSymmetricKey(t *testing.T) string {
+	return uuid.NewString()[:32]
+}
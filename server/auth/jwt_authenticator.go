@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minHS256KeySize = 32
+
+/* jwtClaims maps an AuthPayload onto the registered JWT claims */
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	SessionID uuid.UUID `json:"sid"`
+	Role      string    `json:"role"`
+	TokenType TokenType `json:"token_type"`
+}
+
+/* JWTAuthenticator is a JWT token authenticator that signs with HS256 or EdDSA */
+type JWTAuthenticator struct {
+	SigningMethod    jwt.SigningMethod
+	SigningKeyID     string
+	SigningKey       interface{}
+	VerificationKeys map[string]interface{}
+	Issuer           string
+	Audience         string
+}
+
+/* NewHS256JWTAuthenticator creates a new JWT authenticator that signs tokens with a shared secret */
+func NewHS256JWTAuthenticator(secretKey string, issuer string, audience string) (Authenticator, error) {
+	if len(secretKey) < minHS256KeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minHS256KeySize)
+	}
+	authenticator := &JWTAuthenticator{
+		SigningMethod:    jwt.SigningMethodHS256,
+		SigningKey:       []byte(secretKey),
+		VerificationKeys: map[string]interface{}{"": []byte(secretKey)},
+		Issuer:           issuer,
+		Audience:         audience,
+	}
+	return authenticator, nil
+}
+
+/*
+NewEdDSAJWTAuthenticator creates a new JWT authenticator that signs tokens with an Ed25519 key.
+The keys use the same format as NewPasetoPublicAuthenticator and the key id is sent in the kid header.
+*/
+func NewEdDSAJWTAuthenticator(signingKeyID string, signingKey string, verificationKeys string, issuer string, audience string) (Authenticator, error) {
+	if signingKeyID == "" {
+		return nil, fmt.Errorf("signing key id is required")
+	}
+
+	privateKey, err := parseEd25519PrivateKey(signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys, err := ParseVerificationKeys(verificationKeys)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := publicKeys[signingKeyID]; ok {
+		return nil, fmt.Errorf("key id %q is used by both the signing key and a verification key", signingKeyID)
+	}
+
+	keyring := map[string]interface{}{signingKeyID: privateKey.Public()}
+	for keyID, publicKey := range publicKeys {
+		keyring[keyID] = publicKey
+	}
+
+	authenticator := &JWTAuthenticator{
+		SigningMethod:    jwt.SigningMethodEdDSA,
+		SigningKeyID:     signingKeyID,
+		SigningKey:       privateKey,
+		VerificationKeys: keyring,
+		Issuer:           issuer,
+		Audience:         audience,
+	}
+	return authenticator, nil
+}
+
+/* CreateToken creates a new token */
+func (j *JWTAuthenticator) CreateToken(username string, role string, sessionID uuid.UUID, tokenType TokenType, duration time.Duration) (string, *AuthPayload, error) {
+	payload, err := NewAuthPayload(username, role, sessionID, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	claims := jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			Subject:   payload.Username,
+			Issuer:    j.Issuer,
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			NotBefore: jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+		SessionID: payload.SessionID,
+		Role:      payload.Role,
+		TokenType: payload.TokenType,
+	}
+	if j.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.Audience}
+	}
+
+	jwtToken := jwt.NewWithClaims(j.SigningMethod, claims)
+	if j.SigningKeyID != "" {
+		jwtToken.Header["kid"] = j.SigningKeyID
+	}
+
+	token, err := jwtToken.SignedString(j.SigningKey)
+	return token, payload, err
+}
+
+/* VerifyToken verifies a token */
+func (j *JWTAuthenticator) VerifyToken(token string) (*AuthPayload, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{j.SigningMethod.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if j.Issuer != "" {
+		options = append(options, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		options = append(options, jwt.WithAudience(j.Audience))
+	}
+
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, j.verificationKey, options...)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &AuthPayload{
+		ID:        tokenID,
+		SessionID: claims.SessionID,
+		Username:  claims.Subject,
+		Role:      claims.Role,
+		TokenType: claims.TokenType,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+	}
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+/* verificationKey finds the key named by the kid header in the keyring */
+func (j *JWTAuthenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	keyID, _ := token.Header["kid"].(string)
+	key, ok := j.VerificationKeys[keyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
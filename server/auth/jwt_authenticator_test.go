@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const testJWTSecret = "01234567890123456789012345678901"
+
+func signTestJWT(t *testing.T, claims jwtClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return token
+}
+
+func validTestClaims() jwtClaims {
+	now := time.Now()
+	return jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   "testUser",
+			Issuer:    "open-blogger",
+			Audience:  jwt.ClaimStrings{"open-blogger-api"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+		SessionID: uuid.New(),
+		Role:      RoleAuthor,
+	}
+}
+
+func TestJWTAuthenticatorClaims(t *testing.T) {
+	authenticator, err := NewHS256JWTAuthenticator(testJWTSecret, "open-blogger", "open-blogger-api")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		token         func(t *testing.T) string
+		expectedError error
+	}{
+		{
+			name: "OK",
+			token: func(t *testing.T) string {
+				return signTestJWT(t, validTestClaims())
+			},
+		},
+		{
+			name: "WrongIssuer",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.Issuer = "someone-else"
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "WrongAudience",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.Audience = jwt.ClaimStrings{"another-api"}
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "NotYetValid",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute))
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "MissingExpiry",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.ExpiresAt = nil
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "Expired",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrExpiredToken,
+		},
+		{
+			name: "InvalidTokenID",
+			token: func(t *testing.T) string {
+				claims := validTestClaims()
+				claims.ID = "notAUUID"
+				return signTestJWT(t, claims)
+			},
+			expectedError: ErrInvalidToken,
+		},
+		{
+			name: "AlgorithmNone",
+			token: func(t *testing.T) string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validTestClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				require.NoError(t, err)
+				return token
+			},
+			expectedError: ErrInvalidToken,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := authenticator.VerifyToken(tc.token(t))
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				require.Nil(t, payload)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "testUser", payload.Username)
+			require.Equal(t, RoleAuthor, payload.Role)
+		})
+	}
+}
+
+func TestEdDSAJWTAuthenticatorKeyRotation(t *testing.T) {
+	oldPublicKey, oldPrivateKey := generateEd25519Key(t)
+	_, newPrivateKey := generateEd25519Key(t)
+
+	oldAuthenticator, err := NewEdDSAJWTAuthenticator("key-1", hex.EncodeToString(oldPrivateKey), "", "", "")
+	require.NoError(t, err)
+	oldToken, _, err := oldAuthenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	retiredKeys := fmt.Sprintf("key-1:%s", hex.EncodeToString(oldPublicKey))
+	newAuthenticator, err := NewEdDSAJWTAuthenticator("key-2", hex.EncodeToString(newPrivateKey), retiredKeys, "", "")
+	require.NoError(t, err)
+
+	payload, err := newAuthenticator.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "testUser", payload.Username)
+
+	rotatedAuthenticator, err := NewEdDSAJWTAuthenticator("key-2", hex.EncodeToString(newPrivateKey), "", "", "")
+	require.NoError(t, err)
+	_, err = rotatedAuthenticator.VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestEdDSAJWTAuthenticatorRejectsHS256(t *testing.T) {
+	publicKey, privateKey := generateEd25519Key(t)
+	authenticator, err := NewEdDSAJWTAuthenticator("key-1", hex.EncodeToString(privateKey), "", "", "")
+	require.NoError(t, err)
+
+	/* An HS256 token keyed with the public key must not pass as an EdDSA token */
+	claims := validTestClaims()
+	claims.Issuer = ""
+	claims.Audience = nil
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = "key-1"
+	token, err := forged.SignedString([]byte(publicKey))
+	require.NoError(t, err)
+
+	payload, err := authenticator.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestNewJWTAuthenticatorInvalidKeys(t *testing.T) {
+	_, err := NewHS256JWTAuthenticator("tooShort", "", "")
+	require.Error(t, err)
+
+	_, err = NewEdDSAJWTAuthenticator("", "", "", "", "")
+	require.Error(t, err)
+
+	_, err = NewEdDSAJWTAuthenticator("key-1", "not-hex", "", "", "")
+	require.Error(t, err)
+}
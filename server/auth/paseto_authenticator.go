@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
@@ -27,8 +28,8 @@ func NewPasetoAuthenticator(symmetricKey string) (Authenticator, error) {
 }
 
 /* CreateToken creates a new token */
-func (p *PasetoAuthenticator) CreateToken(username string, duration time.Duration) (string, *AuthPayload, error) {
-	payload, err := NewAuthPayload(username, duration)
+func (p *PasetoAuthenticator) CreateToken(username string, role string, sessionID uuid.UUID, tokenType TokenType, duration time.Duration) (string, *AuthPayload, error) {
+	payload, err := NewAuthPayload(username, role, sessionID, tokenType, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -42,7 +43,7 @@ func (p *PasetoAuthenticator) VerifyToken(token string) (*AuthPayload, error) {
 	payload := &AuthPayload{}
 	err := p.Paseto.Decrypt(token, p.SymmetricKey, payload, nil)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidToken
 	}
 	err = payload.Valid()
 	if err != nil {
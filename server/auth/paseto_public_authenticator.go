@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const pasetoV4PublicHeader = "v4.public."
+
+/* pasetoFooter is the footer of a v4.public token, it tells the verifier which key signed the token */
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+/*
+PasetoPublicAuthenticator creates v4.public tokens signed with an Ed25519 key.
+Tokens are verified against a keyring so that retired keys keep working until
+the tokens they signed expire.
+*/
+type PasetoPublicAuthenticator struct {
+	SigningKeyID     string
+	SigningKey       ed25519.PrivateKey
+	VerificationKeys map[string]ed25519.PublicKey
+}
+
+/*
+NewPasetoPublicAuthenticator creates a new v4.public paseto authenticator.
+signingKey is the hex encoded Ed25519 private key (or its 32 byte seed) used for new tokens.
+verificationKeys is a comma separated list of kid:hex_public_key pairs for retired keys.
+*/
+func NewPasetoPublicAuthenticator(signingKeyID string, signingKey string, verificationKeys string) (Authenticator, error) {
+	if signingKeyID == "" {
+		return nil, fmt.Errorf("signing key id is required")
+	}
+
+	privateKey, err := parseEd25519PrivateKey(signingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := ParseVerificationKeys(verificationKeys)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := keyring[signingKeyID]; ok {
+		return nil, fmt.Errorf("key id %q is used by both the signing key and a verification key", signingKeyID)
+	}
+	keyring[signingKeyID] = privateKey.Public().(ed25519.PublicKey)
+
+	authenticator := &PasetoPublicAuthenticator{
+		SigningKeyID:     signingKeyID,
+		SigningKey:       privateKey,
+		VerificationKeys: keyring,
+	}
+	return authenticator, nil
+}
+
+/* ParseVerificationKeys parses a comma separated list of kid:hex_public_key pairs */
+func ParseVerificationKeys(keys string) (map[string]ed25519.PublicKey, error) {
+	keyring := make(map[string]ed25519.PublicKey)
+	for _, entry := range strings.Split(keys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		keyID, encodedKey, ok := strings.Cut(entry, ":")
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("invalid verification key %q: expected kid:hex_public_key", entry)
+		}
+		if _, exists := keyring[keyID]; exists {
+			return nil, fmt.Errorf("duplicate verification key id %q", keyID)
+		}
+
+		publicKey, err := hex.DecodeString(encodedKey)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid verification key %q: must be %d hex encoded bytes", keyID, ed25519.PublicKeySize)
+		}
+		keyring[keyID] = ed25519.PublicKey(publicKey)
+	}
+	return keyring, nil
+}
+
+func parseEd25519PrivateKey(encodedKey string) (ed25519.PrivateKey, error) {
+	key, err := hex.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf("invalid signing key size: must be %d or %d hex encoded bytes", ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+/* CreateToken creates a new token */
+func (p *PasetoPublicAuthenticator) CreateToken(username string, role string, sessionID uuid.UUID, tokenType TokenType, duration time.Duration) (string, *AuthPayload, error) {
+	payload, err := NewAuthPayload(username, role, sessionID, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+	footer, err := json.Marshal(pasetoFooter{KeyID: p.SigningKeyID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(p.SigningKey, preAuthEncode([]byte(pasetoV4PublicHeader), message, footer, nil))
+
+	token := pasetoV4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...)) +
+		"." + base64.RawURLEncoding.EncodeToString(footer)
+	return token, payload, nil
+}
+
+/* VerifyToken verifies a token */
+func (p *PasetoPublicAuthenticator) VerifyToken(token string) (*AuthPayload, error) {
+	if !strings.HasPrefix(token, pasetoV4PublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, pasetoV4PublicHeader), ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+	footer, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var decodedFooter pasetoFooter
+	if err := json.Unmarshal(footer, &decodedFooter); err != nil {
+		return nil, ErrInvalidToken
+	}
+	publicKey, ok := p.VerificationKeys[decodedFooter.KeyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, preAuthEncode([]byte(pasetoV4PublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &AuthPayload{}
+	if err := json.Unmarshal(message, payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+/* preAuthEncode is the PAE function from the paseto specification */
+func preAuthEncode(pieces ...[]byte) []byte {
+	var buffer bytes.Buffer
+	writeLength := func(length int) {
+		var encoded [8]byte
+		binary.LittleEndian.PutUint64(encoded[:], uint64(length)&^(1<<63))
+		buffer.Write(encoded[:])
+	}
+
+	writeLength(len(pieces))
+	for _, piece := range pieces {
+		writeLength(len(piece))
+		buffer.Write(piece)
+	}
+	return buffer.Bytes()
+}
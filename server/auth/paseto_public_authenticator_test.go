@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func generateEd25519Key(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return publicKey, privateKey
+}
+
+func TestPasetoPublicAuthenticatorFooter(t *testing.T) {
+	_, privateKey := generateEd25519Key(t)
+	pasetoAuthenticator, err := NewPasetoPublicAuthenticator("key-1", hex.EncodeToString(privateKey.Seed()), "")
+	require.NoError(t, err)
+
+	token, _, err := pasetoAuthenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, pasetoV4PublicHeader))
+
+	/* The footer names the signing key */
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 4)
+	footer, err := base64.RawURLEncoding.DecodeString(parts[3])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"kid":"key-1"}`, string(footer))
+}
+
+func TestPasetoPublicAuthenticatorKeyRotation(t *testing.T) {
+	oldPublicKey, oldPrivateKey := generateEd25519Key(t)
+	_, newPrivateKey := generateEd25519Key(t)
+
+	oldAuthenticator, err := NewPasetoPublicAuthenticator("key-1", hex.EncodeToString(oldPrivateKey), "")
+	require.NoError(t, err)
+	oldToken, _, err := oldAuthenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	/* After rotation the retired key still verifies the tokens it signed */
+	retiredKeys := fmt.Sprintf("key-1:%s", hex.EncodeToString(oldPublicKey))
+	newAuthenticator, err := NewPasetoPublicAuthenticator("key-2", hex.EncodeToString(newPrivateKey), retiredKeys)
+	require.NoError(t, err)
+
+	payload, err := newAuthenticator.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "testUser", payload.Username)
+
+	newToken, _, err := newAuthenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	_, err = newAuthenticator.VerifyToken(newToken)
+	require.NoError(t, err)
+
+	/* Once the key is removed from the keyring its tokens are rejected */
+	rotatedAuthenticator, err := NewPasetoPublicAuthenticator("key-2", hex.EncodeToString(newPrivateKey), "")
+	require.NoError(t, err)
+	_, err = rotatedAuthenticator.VerifyToken(oldToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	_, err = rotatedAuthenticator.VerifyToken(newToken)
+	require.NoError(t, err)
+}
+
+func TestPasetoPublicAuthenticatorInvalidToken(t *testing.T) {
+	_, privateKey := generateEd25519Key(t)
+	pasetoAuthenticator, err := NewPasetoPublicAuthenticator("key-1", hex.EncodeToString(privateKey), "")
+	require.NoError(t, err)
+
+	token, _, err := pasetoAuthenticator.CreateToken("testUser", RoleAuthor, uuid.New(), TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+
+	/* A key with the same id but different material does not verify the token */
+	_, otherPrivateKey := generateEd25519Key(t)
+	otherAuthenticator, err := NewPasetoPublicAuthenticator("key-1", hex.EncodeToString(otherPrivateKey), "")
+	require.NoError(t, err)
+
+	forgedFooter := base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"key-2"}`))
+	body, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	body[0] ^= 1
+	tamperedBody := base64.RawURLEncoding.EncodeToString(body)
+
+	testCases := []struct {
+		name          string
+		authenticator Authenticator
+		token         string
+	}{
+		{"WrongKey", otherAuthenticator, token},
+		{"TamperedBody", pasetoAuthenticator, strings.Join([]string{parts[0], parts[1], tamperedBody, parts[3]}, ".")},
+		{"UnknownKeyID", pasetoAuthenticator, strings.Join([]string{parts[0], parts[1], parts[2], forgedFooter}, ".")},
+		{"MissingFooter", pasetoAuthenticator, strings.Join(parts[:3], ".")},
+		{"WrongVersion", pasetoAuthenticator, strings.Replace(token, "v4.public.", "v2.public.", 1)},
+		{"Garbage", pasetoAuthenticator, "v4.public.!!!.!!!"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := tc.authenticator.VerifyToken(tc.token)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestNewPasetoPublicAuthenticatorInvalidKeys(t *testing.T) {
+	publicKey, privateKey := generateEd25519Key(t)
+	signingKey := hex.EncodeToString(privateKey)
+
+	_, err := NewPasetoPublicAuthenticator("", signingKey, "")
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicAuthenticator("key-1", "not-hex", "")
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicAuthenticator("key-1", hex.EncodeToString([]byte("short")), "")
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicAuthenticator("key-1", signingKey, "key-0")
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicAuthenticator("key-1", signingKey, "key-0:abcd")
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicAuthenticator("key-1", signingKey, "key-1:"+hex.EncodeToString(publicKey))
+	require.Error(t, err)
+}
+
+func TestParseVerificationKeys(t *testing.T) {
+	firstKey, _ := generateEd25519Key(t)
+	secondKey, _ := generateEd25519Key(t)
+
+	keys := fmt.Sprintf("key-1:%s, key-2:%s", hex.EncodeToString(firstKey), hex.EncodeToString(secondKey))
+	keyring, err := ParseVerificationKeys(keys)
+	require.NoError(t, err)
+	require.Len(t, keyring, 2)
+	require.Equal(t, firstKey, keyring["key-1"])
+	require.Equal(t, secondKey, keyring["key-2"])
+
+	_, err = ParseVerificationKeys(fmt.Sprintf("key-1:%s,key-1:%s", hex.EncodeToString(firstKey), hex.EncodeToString(secondKey)))
+	require.Error(t, err)
+
+	keyring, err = ParseVerificationKeys("")
+	require.NoError(t, err)
+	require.Empty(t, keyring)
+}
+
+/* Test vector 4-S-1 from the paseto specification */
+func TestPasetoV4PublicSpecVector(t *testing.T) {
+	secretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	expectedToken := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	signature := ed25519.Sign(ed25519.PrivateKey(secretKey), preAuthEncode([]byte(pasetoV4PublicHeader), message, nil, nil))
+	token := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	require.Equal(t, expectedToken, token)
+}
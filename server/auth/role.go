@@ -0,0 +1,70 @@
+package auth
+
+/* Roles a user can hold, they match the user_role enum in the database */
+const (
+	RoleReader    = "reader"
+	RoleAuthor    = "author"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+/* Permission is an action that is granted to one or more roles */
+type Permission string
+
+/* Permissions checked by the API */
+const (
+	PermissionWritePosts       Permission = "posts:write"
+	PermissionWriteComments    Permission = "comments:write"
+	PermissionDeleteAnyComment Permission = "comments:delete_any"
+	PermissionDeleteAnyPost    Permission = "posts:delete_any"
+	PermissionDeleteAnyUser    Permission = "users:delete_any"
+	PermissionManageRoles      Permission = "users:manage_roles"
+	PermissionCreateInvites    Permission = "invites:create"
+	PermissionManageInvites    Permission = "invites:manage"
+	PermissionManageCategories Permission = "categories:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleReader: {
+		PermissionWriteComments,
+		PermissionCreateInvites,
+	},
+	RoleAuthor: {
+		PermissionWriteComments,
+		PermissionWritePosts,
+		PermissionCreateInvites,
+	},
+	RoleModerator: {
+		PermissionWriteComments,
+		PermissionWritePosts,
+		PermissionDeleteAnyComment,
+		PermissionCreateInvites,
+	},
+	RoleAdmin: {
+		PermissionWriteComments,
+		PermissionWritePosts,
+		PermissionDeleteAnyComment,
+		PermissionDeleteAnyPost,
+		PermissionDeleteAnyUser,
+		PermissionManageRoles,
+		PermissionCreateInvites,
+		PermissionManageInvites,
+		PermissionManageCategories,
+	},
+}
+
+/* IsValidRole checks if the role is one of the known roles */
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+/* HasPermission checks if the role is granted the permission */
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidRole(t *testing.T) {
+	for _, role := range []string{RoleReader, RoleAuthor, RoleModerator, RoleAdmin} {
+		require.True(t, IsValidRole(role))
+	}
+	require.False(t, IsValidRole("superuser"))
+	require.False(t, IsValidRole(""))
+}
+
+func TestHasPermission(t *testing.T) {
+	require.True(t, HasPermission(RoleReader, PermissionWriteComments))
+	require.False(t, HasPermission(RoleReader, PermissionWritePosts))
+
+	require.True(t, HasPermission(RoleAuthor, PermissionWritePosts))
+	require.False(t, HasPermission(RoleAuthor, PermissionDeleteAnyComment))
+
+	require.True(t, HasPermission(RoleModerator, PermissionDeleteAnyComment))
+	require.False(t, HasPermission(RoleModerator, PermissionDeleteAnyPost))
+
+	require.True(t, HasPermission(RoleAdmin, PermissionDeleteAnyPost))
+	require.True(t, HasPermission(RoleAdmin, PermissionDeleteAnyUser))
+	require.True(t, HasPermission(RoleAdmin, PermissionManageRoles))
+
+	require.True(t, HasPermission(RoleReader, PermissionCreateInvites))
+	require.False(t, HasPermission(RoleModerator, PermissionManageInvites))
+	require.True(t, HasPermission(RoleAdmin, PermissionManageInvites))
+
+	require.False(t, HasPermission(RoleModerator, PermissionManageCategories))
+	require.True(t, HasPermission(RoleAdmin, PermissionManageCategories))
+
+	require.False(t, HasPermission("unknown", PermissionWriteComments))
+}
+
+func TestScopes(t *testing.T) {
+	for _, scope := range []Scope{ScopeRead, ScopePostsWrite, ScopeCommentsWrite} {
+		require.True(t, IsValidScope(string(scope)))
+	}
+	require.False(t, IsValidScope(string(PermissionManageRoles)))
+	require.False(t, IsValidScope(""))
+
+	scope, ok := ScopeForPermission(PermissionWritePosts)
+	require.True(t, ok)
+	require.Equal(t, ScopePostsWrite, scope)
+	_, ok = ScopeForPermission(PermissionDeleteAnyUser)
+	require.False(t, ok)
+
+	/* Session access tokens are not limited by scopes */
+	sessionPayload := &AuthPayload{}
+	require.False(t, sessionPayload.IsPersonalAccessToken())
+	require.True(t, sessionPayload.HasScope(ScopePostsWrite))
+
+	tokenPayload := &AuthPayload{Scopes: []Scope{ScopeRead}}
+	require.True(t, tokenPayload.IsPersonalAccessToken())
+	require.True(t, tokenPayload.HasScope(ScopeRead))
+	require.False(t, tokenPayload.HasScope(ScopePostsWrite))
+}
@@ -0,0 +1,35 @@
+package auth
+
+/* PersonalAccessTokenPrefix marks personal access tokens so they can be told apart from session access tokens */
+const PersonalAccessTokenPrefix = "obpat_"
+
+/* Scope limits what a personal access token may do on behalf of its owner */
+type Scope string
+
+/* Scopes that can be granted to a personal access token */
+const (
+	ScopeRead          Scope = "read"
+	ScopePostsWrite    Scope = "posts:write"
+	ScopeCommentsWrite Scope = "comments:write"
+)
+
+/* permissionScopes maps permissions to the scope a personal access token needs to use them, permissions without a scope are never granted to tokens */
+var permissionScopes = map[Permission]Scope{
+	PermissionWritePosts:    ScopePostsWrite,
+	PermissionWriteComments: ScopeCommentsWrite,
+}
+
+/* IsValidScope checks if the scope is one of the known scopes */
+func IsValidScope(scope string) bool {
+	switch Scope(scope) {
+	case ScopeRead, ScopePostsWrite, ScopeCommentsWrite:
+		return true
+	}
+	return false
+}
+
+/* ScopeForPermission returns the scope a personal access token needs for the permission */
+func ScopeForPermission(permission Permission) (Scope, bool) {
+	scope, ok := permissionScopes[permission]
+	return scope, ok
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	/* TOTPIssuer is the issuer shown by authenticator apps */
+	TOTPIssuer = "Open Blogger"
+	totpPeriod = 30
+	totpSkew   = 1
+
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLength   = 10
+)
+
+/* ErrInvalidTOTPCode is returned by ValidateTOTPCode when the code does not match any allowed time step */
+var ErrInvalidTOTPCode = errors.New("totp code is invalid")
+
+/* GenerateTOTPKey generates a new RFC 6238 secret for the account together with its otpauth URI */
+func GenerateTOTPKey(accountName string) (secret string, uri string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      TOTPIssuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+/*
+ValidateTOTPCode checks a code against the secret at time t, allowing one step of clock skew
+in both directions. It returns the time step the code belongs to so that callers can reject
+codes that have already been used.
+*/
+func ValidateTOTPCode(secret string, code string, t time.Time) (int64, error) {
+	code = strings.TrimSpace(code)
+	currentStep := t.Unix() / totpPeriod
+
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		step := currentStep + offset
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, nil
+		}
+	}
+	return 0, ErrInvalidTOTPCode
+}
+
+/* GenerateRecoveryCodes generates count one-time recovery codes formatted as xxxxx-xxxxx */
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	for i := range codes {
+		var builder strings.Builder
+		for j := 0; j < recoveryCodeLength; j++ {
+			if j == recoveryCodeLength/2 {
+				builder.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			builder.WriteByte(recoveryCodeAlphabet[n.Int64()])
+		}
+		codes[i] = builder.String()
+	}
+	return codes, nil
+}
+
+/* NormalizeRecoveryCode strips separators and case so that a recovery code can be hashed and compared */
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTOTPKey(t *testing.T) {
+	secret, uri, err := GenerateTOTPKey("testUser")
+	require.NoError(t, err)
+	require.NotEmpty(t, secret)
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+	require.Contains(t, uri, "secret="+secret)
+	require.Contains(t, uri, "issuer=Open%20Blogger")
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, _, err := GenerateTOTPKey("testUser")
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step, err := ValidateTOTPCode(secret, code, now)
+	require.NoError(t, err)
+	require.Equal(t, now.Unix()/totpPeriod, step)
+
+	/* A code from the previous step is accepted for clock skew */
+	previousCode, err := totp.GenerateCode(secret, now.Add(-totpPeriod*time.Second))
+	require.NoError(t, err)
+	previousStep, err := ValidateTOTPCode(secret, previousCode, now)
+	require.NoError(t, err)
+	require.Equal(t, step-1, previousStep)
+
+	/* Codes outside of the skew window are rejected */
+	oldCode, err := totp.GenerateCode(secret, now.Add(-5*totpPeriod*time.Second))
+	require.NoError(t, err)
+	if oldCode != code && oldCode != previousCode {
+		_, err = ValidateTOTPCode(secret, oldCode, now)
+		require.ErrorIs(t, err, ErrInvalidTOTPCode)
+	}
+
+	_, err = ValidateTOTPCode(secret, "abcdef", now)
+	require.ErrorIs(t, err, ErrInvalidTOTPCode)
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, recoveryCodeLength+1)
+		require.Equal(t, byte('-'), code[recoveryCodeLength/2])
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+
+	require.Equal(t, "abcde12345", NormalizeRecoveryCode("ABCDE-12345"))
+	require.Equal(t, "abcde12345", NormalizeRecoveryCode("abcde 12345"))
+}
@@ -3,6 +3,10 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Oabraham1/open-blogger/server/api"
 	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
@@ -10,6 +14,9 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// long enough for a background task, such as an email, to finish after the last request
+const shutdownTimeout = 90 * time.Second
+
 func main() {
 	config, err := util.LoadConfig("../")
 	if err != nil {
@@ -31,8 +38,21 @@ func runGinServer(config util.Config, store db.Store) {
 		log.Fatal("cannot create server %w", err)
 	}
 
-	err = server.StartServer(config.HTTPServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server")
+	go func() {
+		err := server.StartServer(config.HTTPServerAddress)
+		if err != nil {
+			log.Fatal("cannot start server")
+		}
+	}()
+
+	// on SIGTERM the requests in flight and the queued emails are finished before the process exits
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatal("cannot shut down server:", err)
 	}
 }
@@ -0,0 +1,2 @@
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "is_used";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "family_id";
@@ -0,0 +1,11 @@
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "is_used" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'ID of the login session every rotated refresh token descends from';
+
+COMMENT ON COLUMN "sessions"."is_used" IS 'Set once the refresh token has been exchanged for a new one';
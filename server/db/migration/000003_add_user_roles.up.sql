@@ -0,0 +1,8 @@
+CREATE TYPE "user_role" AS ENUM (
+  'reader',
+  'author',
+  'moderator',
+  'admin'
+);
+
+ALTER TABLE "users" ADD COLUMN "role" user_role NOT NULL DEFAULT 'author';
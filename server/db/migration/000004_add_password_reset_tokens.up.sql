@@ -0,0 +1,14 @@
+CREATE TABLE "password_reset_tokens" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "password_reset_tokens"."token_hash" IS 'SHA-256 hash of the token emailed to the user';
+
+CREATE INDEX ON "password_reset_tokens" ("username", "created_at");
+
+ALTER TABLE "password_reset_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz;
+
+-- accounts from before email verification are trusted, otherwise turning on REQUIRE_EMAIL_VERIFICATION would lock them out
+UPDATE "users" SET "email_verified_at" = now();
+
+COMMENT ON COLUMN "users"."email_verified_at" IS 'NULL until the user follows the verification link';
+
+CREATE TABLE "email_verification_tokens" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "email_verification_tokens"."token_hash" IS 'SHA-256 hash of the token emailed to the user';
+
+CREATE INDEX ON "email_verification_tokens" ("username", "created_at");
+
+ALTER TABLE "email_verification_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "mfa_challenges";
+DROP TABLE IF EXISTS "totp_recovery_codes";
+DROP TABLE IF EXISTS "user_totp";
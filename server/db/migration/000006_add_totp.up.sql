@@ -0,0 +1,43 @@
+CREATE TABLE "user_totp" (
+  "username" varchar PRIMARY KEY,
+  "secret_ciphertext" bytea NOT NULL,
+  "confirmed_at" timestamptz,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "user_totp"."secret_ciphertext" IS 'AES-256-GCM encrypted TOTP secret';
+
+COMMENT ON COLUMN "user_totp"."confirmed_at" IS 'NULL until the user confirms enrollment with a first code';
+
+COMMENT ON COLUMN "user_totp"."last_used_step" IS 'Time step of the last accepted code, older or equal steps are rejected';
+
+CREATE TABLE "totp_recovery_codes" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "code_hash" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "totp_recovery_codes"."code_hash" IS 'SHA-256 hash of the normalized recovery code';
+
+CREATE UNIQUE INDEX ON "totp_recovery_codes" ("username", "code_hash");
+
+CREATE TABLE "mfa_challenges" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "mfa_challenges"."token_hash" IS 'SHA-256 hash of the challenge token returned by LoginUser';
+
+ALTER TABLE "user_totp" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "totp_recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "mfa_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
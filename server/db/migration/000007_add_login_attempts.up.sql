@@ -0,0 +1,23 @@
+CREATE TABLE "login_attempts" (
+  "key" varchar PRIMARY KEY,
+  "failures" int NOT NULL DEFAULT 0,
+  "last_failure_at" timestamptz NOT NULL DEFAULT (now()),
+  "blocked_until" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "login_attempts"."key" IS 'Username or client IP the failures are counted for';
+
+CREATE TABLE "audit_logs" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "action" varchar NOT NULL,
+  "username" varchar NOT NULL DEFAULT '',
+  "client_ip" varchar NOT NULL DEFAULT '',
+  "details" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "audit_logs"."username" IS 'Not a foreign key so that entries outlive the account they refer to';
+
+CREATE INDEX ON "audit_logs" ("username", "created_at");
+
+CREATE INDEX ON "audit_logs" ("action", "created_at");
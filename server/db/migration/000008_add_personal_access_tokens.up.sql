@@ -0,0 +1,18 @@
+CREATE TABLE "personal_access_tokens" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "token_hash" varchar UNIQUE NOT NULL,
+  "scopes" varchar[] NOT NULL,
+  "expires_at" timestamptz,
+  "last_used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "personal_access_tokens"."token_hash" IS 'SHA-256 hash of the token shown to the user once at creation';
+
+COMMENT ON COLUMN "personal_access_tokens"."expires_at" IS 'NULL for tokens that do not expire';
+
+CREATE INDEX ON "personal_access_tokens" ("username");
+
+ALTER TABLE "personal_access_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
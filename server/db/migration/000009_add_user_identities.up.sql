@@ -0,0 +1,35 @@
+CREATE TABLE "user_identities" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "provider" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "email" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "user_identities"."provider" IS 'Name of the OpenID Connect provider in the config';
+
+COMMENT ON COLUMN "user_identities"."subject" IS 'Subject identifier the provider issued for the account';
+
+CREATE UNIQUE INDEX ON "user_identities" ("provider", "subject");
+
+CREATE INDEX ON "user_identities" ("username");
+
+CREATE TABLE "oidc_login_states" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "state_hash" varchar UNIQUE NOT NULL,
+  "provider" varchar NOT NULL,
+  "code_verifier" varchar NOT NULL,
+  "nonce" varchar NOT NULL,
+  "link_username" varchar,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "oidc_login_states"."state_hash" IS 'SHA-256 hash of the state parameter sent to the provider';
+
+COMMENT ON COLUMN "oidc_login_states"."link_username" IS 'Set when a signed in user links the identity to their account';
+
+ALTER TABLE "user_identities" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "oidc_login_states" ADD FOREIGN KEY ("link_username") REFERENCES "users" ("username") ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "comments_username_lower_idx";
+DROP INDEX IF EXISTS "posts_username_lower_idx";
+DROP INDEX IF EXISTS "users_email_lower_idx";
+DROP INDEX IF EXISTS "users_username_lower_idx";
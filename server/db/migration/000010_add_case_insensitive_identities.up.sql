@@ -0,0 +1,26 @@
+-- accounts whose username or email only differ in case have to be renamed or merged by hand before the unique indexes
+-- can be created, the migration fails and lists them so that nothing is changed silently
+DO $$
+DECLARE
+  conflicts text;
+BEGIN
+  SELECT string_agg(conflict, '; ') INTO conflicts FROM (
+    SELECT 'username ' || lower("username") || ' is used by ' || string_agg("username", ', ' ORDER BY "created_at") AS conflict
+    FROM "users" GROUP BY lower("username") HAVING count(*) > 1
+    UNION ALL
+    SELECT 'email ' || lower("email") || ' is used by ' || string_agg("username", ', ' ORDER BY "created_at") AS conflict
+    FROM "users" GROUP BY lower("email") HAVING count(*) > 1
+  ) AS conflicts;
+
+  IF conflicts IS NOT NULL THEN
+    RAISE EXCEPTION 'users that only differ in case have to be resolved first: %', conflicts;
+  END IF;
+END $$;
+
+CREATE UNIQUE INDEX "users_username_lower_idx" ON "users" (lower("username"));
+
+CREATE UNIQUE INDEX "users_email_lower_idx" ON "users" (lower("email"));
+
+CREATE INDEX "posts_username_lower_idx" ON "posts" (lower("username"));
+
+CREATE INDEX "comments_username_lower_idx" ON "comments" (lower("username"));
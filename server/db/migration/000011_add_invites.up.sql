@@ -0,0 +1,17 @@
+CREATE TABLE "invites" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "code_hash" varchar UNIQUE NOT NULL,
+  "inviter_username" varchar NOT NULL,
+  "max_uses" integer NOT NULL DEFAULT 1 CHECK ("max_uses" > 0),
+  "uses" integer NOT NULL DEFAULT 0 CHECK ("uses" <= "max_uses"),
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "invites"."code_hash" IS 'SHA-256 hash of the invite code shown to the inviter once at creation';
+
+COMMENT ON COLUMN "invites"."max_uses" IS 'Number of accounts that can be registered with the code';
+
+CREATE INDEX ON "invites" ("inviter_username");
+
+ALTER TABLE "invites" ADD FOREIGN KEY ("inviter_username") REFERENCES "users" ("username") ON DELETE CASCADE;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "comments_post_id_ordered_at_id_idx";
+DROP INDEX IF EXISTS "posts_username_lower_status_ordered_at_id_idx";
+DROP INDEX IF EXISTS "posts_username_lower_status_published_at_id_idx";
+DROP INDEX IF EXISTS "posts_category_status_published_at_id_idx";
+DROP INDEX IF EXISTS "posts_status_published_at_id_idx";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "ordered_at";
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "ordered_at";
+ALTER TABLE "posts" ALTER COLUMN "created_at" SET DEFAULT TO_CHAR(NOW() AT TIME ZONE 'UTC', 'YYYY/MM/DD HH12:MI:SS');
+ALTER TABLE "comments" ALTER COLUMN "created_at" SET DEFAULT TO_CHAR(NOW() AT TIME ZONE 'UTC', 'YYYY/MM/DD HH12:MI:SS');
@@ -0,0 +1,43 @@
+-- comments and posts were stamped with a 12-hour clock without an AM/PM marker, new rows are stamped with a 24-hour clock.
+-- The stamps of existing rows are left as they are.
+ALTER TABLE "comments" ALTER COLUMN "created_at" SET DEFAULT TO_CHAR(NOW() AT TIME ZONE 'UTC', 'YYYY/MM/DD HH24:MI:SS');
+
+ALTER TABLE "posts" ALTER COLUMN "created_at" SET DEFAULT TO_CHAR(NOW() AT TIME ZONE 'UTC', 'YYYY/MM/DD HH24:MI:SS');
+
+-- Comments and drafts are listed by "ordered_at" instead of the text stamps, which do not sort once both clocks are mixed.
+-- Existing rows get the date and clock reading of their stamp, read as UTC on a 24-hour clock. The stamps never
+-- held AM or PM, so an afternoon row is ordered as the same hour in the morning and a 12:xx row as noon, which is
+-- the order the text stamps already sorted in. Rows whose stamp cannot be read are ordered at the Unix epoch,
+-- before every other row. The "created_at" stamps are not changed, so nothing is lost and the down migration only
+-- drops the new column.
+CREATE FUNCTION pg_temp.legacy_stamp_time("stamp" text) RETURNS timestamptz LANGUAGE plpgsql AS $$
+  BEGIN
+    IF "stamp" !~ '^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}$' THEN
+      RETURN 'epoch';
+    END IF;
+    RETURN TO_TIMESTAMP("stamp", 'YYYY/MM/DD HH24:MI:SS')::timestamp AT TIME ZONE 'UTC';
+  EXCEPTION WHEN OTHERS THEN
+    RETURN 'epoch';
+  END
+$$;
+
+ALTER TABLE "comments" ADD COLUMN "ordered_at" timestamptz;
+UPDATE "comments" SET "ordered_at" = pg_temp.legacy_stamp_time("created_at");
+ALTER TABLE "comments" ALTER COLUMN "ordered_at" SET DEFAULT (now()), ALTER COLUMN "ordered_at" SET NOT NULL;
+
+ALTER TABLE "posts" ADD COLUMN "ordered_at" timestamptz;
+UPDATE "posts" SET "ordered_at" = pg_temp.legacy_stamp_time("created_at");
+ALTER TABLE "posts" ALTER COLUMN "ordered_at" SET DEFAULT (now()), ALTER COLUMN "ordered_at" SET NOT NULL;
+
+DROP FUNCTION pg_temp.legacy_stamp_time(text);
+
+CREATE INDEX "posts_status_published_at_id_idx" ON "posts" ("status", "published_at" DESC, "id" DESC);
+
+CREATE INDEX "posts_category_status_published_at_id_idx" ON "posts" ("category", "status", "published_at" DESC, "id" DESC);
+
+CREATE INDEX "posts_username_lower_status_published_at_id_idx" ON "posts" (lower("username"), "status", "published_at" DESC, "id" DESC);
+
+-- drafts have no publication time, they are listed by creation time
+CREATE INDEX "posts_username_lower_status_ordered_at_id_idx" ON "posts" (lower("username"), "status", "ordered_at" DESC, "id" DESC);
+
+CREATE INDEX "comments_post_id_ordered_at_id_idx" ON "comments" ("post_id", "ordered_at", "id");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "sessions_family_id_idx";
+DROP INDEX IF EXISTS "sessions_username_created_at_idx";
+DROP INDEX IF EXISTS "comments_username_idx";
+DROP INDEX IF EXISTS "posts_username_idx";
@@ -0,0 +1,8 @@
+-- the foreign keys to users compare the username as it is stored, so the lower() indexes do not help to check them
+CREATE INDEX "posts_username_idx" ON "posts" ("username");
+
+CREATE INDEX "comments_username_idx" ON "comments" ("username");
+
+CREATE INDEX "sessions_username_created_at_idx" ON "sessions" ("username", "created_at" DESC);
+
+CREATE INDEX "sessions_family_id_idx" ON "sessions" ("family_id");
@@ -0,0 +1,12 @@
+-- Search indexes an expression rather than a generated tsvector column. sqlc puts every column of posts in db.Post,
+-- so a stored search_vector would be read by every post query and carried by the model while only the search uses it.
+-- The function has to be IMMUTABLE to be indexed, it pins the 'english' configuration instead of reading the default,
+-- and the search query calls it with the same arguments so the planner matches it to the index.
+CREATE FUNCTION "post_search_vector"("title" text, "body" text) RETURNS tsvector
+  LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT setweight(to_tsvector('english', "title"), 'A') || setweight(to_tsvector('english', "body"), 'B')
+  $$;
+
+COMMENT ON FUNCTION "post_search_vector"(text, text) IS 'Lexemes of the title weighted A and of the body weighted B for full-text search';
+
+CREATE INDEX "posts_search_vector_idx" ON "posts" USING GIN (post_search_vector("title", "body"));
@@ -0,0 +1,28 @@
+CREATE TABLE "tags" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "slug" varchar UNIQUE NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "post_tags" (
+  "post_id" uuid NOT NULL,
+  "tag_id" uuid NOT NULL,
+  PRIMARY KEY ("post_id", "tag_id")
+);
+
+COMMENT ON COLUMN "tags"."slug" IS 'Lowercase letters and digits with words joined by hyphens';
+
+ALTER TABLE "post_tags" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "post_tags" ADD FOREIGN KEY ("tag_id") REFERENCES "tags" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "post_tags_tag_id_idx" ON "post_tags" ("tag_id");
+
+-- every existing category becomes a tag of its posts, slugged the same way as util.Slugify
+INSERT INTO "tags" ("slug")
+SELECT DISTINCT trim(both '-' from regexp_replace(lower("category"), '[^a-z0-9]+', '-', 'g')) FROM "posts"
+WHERE trim(both '-' from regexp_replace(lower("category"), '[^a-z0-9]+', '-', 'g')) <> '';
+
+INSERT INTO "post_tags" ("post_id", "tag_id")
+SELECT "posts"."id", "tags"."id" FROM "posts"
+JOIN "tags" ON "tags"."slug" = trim(both '-' from regexp_replace(lower("posts"."category"), '[^a-z0-9]+', '-', 'g'));
@@ -0,0 +1,2 @@
+ALTER TABLE "posts" DROP CONSTRAINT IF EXISTS "posts_category_fkey";
+DROP TABLE IF EXISTS "categories";
@@ -0,0 +1,31 @@
+CREATE TABLE "categories" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "slug" varchar UNIQUE NOT NULL,
+  "name" varchar NOT NULL,
+  "description" text NOT NULL DEFAULT '',
+  "parent_id" uuid,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "categories"."slug" IS 'Lowercase letters and digits with words joined by hyphens, posts reference categories by slug';
+
+COMMENT ON COLUMN "categories"."parent_id" IS 'Null for top level categories';
+
+ALTER TABLE "categories" ADD FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON DELETE RESTRICT;
+
+CREATE INDEX "categories_parent_id_idx" ON "categories" ("parent_id");
+
+-- existing categories become top level categories, posts without letters or digits in their category are uncategorized
+INSERT INTO "categories" ("slug", "name") VALUES ('uncategorized', 'Uncategorized');
+
+INSERT INTO "categories" ("slug", "name")
+SELECT "slug", min("category") FROM (
+  SELECT trim(both '-' from regexp_replace(lower("category"), '[^a-z0-9]+', '-', 'g')) AS "slug", "category" FROM "posts"
+) AS "post_categories"
+WHERE "slug" <> ''
+GROUP BY "slug"
+ON CONFLICT ("slug") DO NOTHING;
+
+UPDATE "posts" SET "category" = coalesce(nullif(trim(both '-' from regexp_replace(lower("category"), '[^a-z0-9]+', '-', 'g')), ''), 'uncategorized');
+
+ALTER TABLE "posts" ADD FOREIGN KEY ("category") REFERENCES "categories" ("slug") ON UPDATE CASCADE;
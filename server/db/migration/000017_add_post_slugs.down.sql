@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "post_slug_history";
+DROP INDEX IF EXISTS "posts_username_slug_idx";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "slug";
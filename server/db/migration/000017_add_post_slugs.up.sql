@@ -0,0 +1,39 @@
+ALTER TABLE "posts" ADD COLUMN "slug" varchar;
+
+-- existing posts are slugged the same way as util.Slugify, later posts with the same title get a numbered suffix
+WITH "slugged" AS (
+  SELECT "id", "username", "created_at",
+    coalesce(nullif(trim(trailing '-' from left(trim(both '-' from regexp_replace(lower("title"), '[^a-z0-9]+', '-', 'g')), 80)), ''), 'post') AS "base"
+  FROM "posts"
+), "numbered" AS (
+  SELECT "id", "base", row_number() OVER (PARTITION BY lower("username"), "base" ORDER BY "created_at", "id") AS "n"
+  FROM "slugged"
+)
+UPDATE "posts" SET "slug" = CASE WHEN "numbered"."n" = 1 THEN "numbered"."base" ELSE "numbered"."base" || '-' || "numbered"."n" END
+FROM "numbered" WHERE "posts"."id" = "numbered"."id";
+
+-- a numbered suffix can still clash with a title that ends in a number, those posts fall back to their id
+UPDATE "posts" SET "slug" = "slug" || '-' || left("id"::text, 8) WHERE "id" IN (
+  SELECT "id" FROM (
+    SELECT "id", row_number() OVER (PARTITION BY lower("username"), "slug" ORDER BY "created_at", "id") AS "n" FROM "posts"
+  ) AS "duplicates" WHERE "n" > 1
+);
+
+ALTER TABLE "posts" ALTER COLUMN "slug" SET NOT NULL;
+
+COMMENT ON COLUMN "posts"."slug" IS 'Unique per author, made from the title and used in the permalink of the post';
+
+CREATE UNIQUE INDEX "posts_username_slug_idx" ON "posts" (lower("username"), "slug");
+
+CREATE TABLE "post_slug_history" (
+  "post_id" uuid NOT NULL,
+  "slug" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("post_id", "slug")
+);
+
+COMMENT ON COLUMN "post_slug_history"."slug" IS 'A previous slug of the post, its permalink redirects to the current one';
+
+ALTER TABLE "post_slug_history" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX "post_slug_history_slug_idx" ON "post_slug_history" ("slug");
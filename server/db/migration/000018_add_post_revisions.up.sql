@@ -0,0 +1,20 @@
+CREATE TABLE "post_revisions" (
+  "id" uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+  "post_id" uuid NOT NULL,
+  "number" integer NOT NULL,
+  "title" varchar NOT NULL,
+  "body" text NOT NULL,
+  "restored_from" integer,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  UNIQUE ("post_id", "number")
+);
+
+COMMENT ON COLUMN "post_revisions"."number" IS 'Counts the revisions of a post from 1';
+
+COMMENT ON COLUMN "post_revisions"."restored_from" IS 'Number of the revision this revision restored';
+
+ALTER TABLE "post_revisions" ADD FOREIGN KEY ("post_id") REFERENCES "posts" ("id") ON DELETE CASCADE;
+
+-- the current title and body of every existing post is its first revision
+INSERT INTO "post_revisions" ("post_id", "number", "title", "body")
+SELECT "id", 1, "title", "body" FROM "posts";
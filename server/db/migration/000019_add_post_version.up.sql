@@ -0,0 +1,3 @@
+ALTER TABLE "posts" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "posts"."version" IS 'Goes up by one on every update, an update must name the version it changes';
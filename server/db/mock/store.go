@@ -36,6 +36,240 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AddPostSlugHistory mocks base method.
+func (m *MockStore) AddPostSlugHistory(ctx context.Context, arg db.AddPostSlugHistoryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPostSlugHistory", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPostSlugHistory indicates an expected call of AddPostSlugHistory.
+func (mr *MockStoreMockRecorder) AddPostSlugHistory(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPostSlugHistory", reflect.TypeOf((*MockStore)(nil).AddPostSlugHistory), ctx, arg)
+}
+
+// AddPostTag mocks base method.
+func (m *MockStore) AddPostTag(ctx context.Context, arg db.AddPostTagParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPostTag", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPostTag indicates an expected call of AddPostTag.
+func (mr *MockStoreMockRecorder) AddPostTag(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPostTag", reflect.TypeOf((*MockStore)(nil).AddPostTag), ctx, arg)
+}
+
+// BlockLoginAttempt mocks base method.
+func (m *MockStore) BlockLoginAttempt(ctx context.Context, arg db.BlockLoginAttemptParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockLoginAttempt", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockLoginAttempt indicates an expected call of BlockLoginAttempt.
+func (mr *MockStoreMockRecorder) BlockLoginAttempt(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockLoginAttempt", reflect.TypeOf((*MockStore)(nil).BlockLoginAttempt), ctx, arg)
+}
+
+// BlockSessionFamily mocks base method.
+func (m *MockStore) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSessionFamily", ctx, familyID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSessionFamily indicates an expected call of BlockSessionFamily.
+func (mr *MockStoreMockRecorder) BlockSessionFamily(ctx, familyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSessionFamily", reflect.TypeOf((*MockStore)(nil).BlockSessionFamily), ctx, familyID)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), ctx, username)
+}
+
+// BlockUserSessionsExceptFamily mocks base method.
+func (m *MockStore) BlockUserSessionsExceptFamily(ctx context.Context, arg db.BlockUserSessionsExceptFamilyParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessionsExceptFamily", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessionsExceptFamily indicates an expected call of BlockUserSessionsExceptFamily.
+func (mr *MockStoreMockRecorder) BlockUserSessionsExceptFamily(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessionsExceptFamily", reflect.TypeOf((*MockStore)(nil).BlockUserSessionsExceptFamily), ctx, arg)
+}
+
+// ChangePasswordTx mocks base method.
+func (m *MockStore) ChangePasswordTx(ctx context.Context, arg db.ChangePasswordTxParams) (db.ChangePasswordTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePasswordTx", ctx, arg)
+	ret0, _ := ret[0].(db.ChangePasswordTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePasswordTx indicates an expected call of ChangePasswordTx.
+func (mr *MockStoreMockRecorder) ChangePasswordTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePasswordTx", reflect.TypeOf((*MockStore)(nil).ChangePasswordTx), ctx, arg)
+}
+
+// ConfirmTOTPTx mocks base method.
+func (m *MockStore) ConfirmTOTPTx(ctx context.Context, arg db.ConfirmTOTPTxParams) (db.UserTotp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTPTx", ctx, arg)
+	ret0, _ := ret[0].(db.UserTotp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTPTx indicates an expected call of ConfirmTOTPTx.
+func (mr *MockStoreMockRecorder) ConfirmTOTPTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTPTx", reflect.TypeOf((*MockStore)(nil).ConfirmTOTPTx), ctx, arg)
+}
+
+// ConfirmUserTOTP mocks base method.
+func (m *MockStore) ConfirmUserTOTP(ctx context.Context, arg db.ConfirmUserTOTPParams) (db.UserTotp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmUserTOTP", ctx, arg)
+	ret0, _ := ret[0].(db.UserTotp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmUserTOTP indicates an expected call of ConfirmUserTOTP.
+func (mr *MockStoreMockRecorder) ConfirmUserTOTP(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmUserTOTP", reflect.TypeOf((*MockStore)(nil).ConfirmUserTOTP), ctx, arg)
+}
+
+// ConsumeOIDCLoginState mocks base method.
+func (m *MockStore) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (db.OidcLoginState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCLoginState", ctx, stateHash)
+	ret0, _ := ret[0].(db.OidcLoginState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCLoginState indicates an expected call of ConsumeOIDCLoginState.
+func (mr *MockStoreMockRecorder) ConsumeOIDCLoginState(ctx, stateHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCLoginState", reflect.TypeOf((*MockStore)(nil).ConsumeOIDCLoginState), ctx, stateHash)
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockStore) CreateAuditLog(ctx context.Context, arg db.CreateAuditLogParams) (db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", ctx, arg)
+	ret0, _ := ret[0].(db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockStoreMockRecorder) CreateAuditLog(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockStore)(nil).CreateAuditLog), ctx, arg)
+}
+
+// CreateCategory mocks base method.
+func (m *MockStore) CreateCategory(ctx context.Context, arg db.CreateCategoryParams) (db.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCategory", ctx, arg)
+	ret0, _ := ret[0].(db.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCategory indicates an expected call of CreateCategory.
+func (mr *MockStoreMockRecorder) CreateCategory(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCategory", reflect.TypeOf((*MockStore)(nil).CreateCategory), ctx, arg)
+}
+
+// CreateEmailVerificationToken mocks base method.
+func (m *MockStore) CreateEmailVerificationToken(ctx context.Context, arg db.CreateEmailVerificationTokenParams) (db.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerificationToken", ctx, arg)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerificationToken indicates an expected call of CreateEmailVerificationToken.
+func (mr *MockStoreMockRecorder) CreateEmailVerificationToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerificationToken", reflect.TypeOf((*MockStore)(nil).CreateEmailVerificationToken), ctx, arg)
+}
+
+// CreateInvite mocks base method.
+func (m *MockStore) CreateInvite(ctx context.Context, arg db.CreateInviteParams) (db.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, arg)
+	ret0, _ := ret[0].(db.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockStoreMockRecorder) CreateInvite(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockStore)(nil).CreateInvite), ctx, arg)
+}
+
+// CreateInviteTx mocks base method.
+func (m *MockStore) CreateInviteTx(ctx context.Context, arg db.CreateInviteTxParams) (db.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInviteTx", ctx, arg)
+	ret0, _ := ret[0].(db.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInviteTx indicates an expected call of CreateInviteTx.
+func (mr *MockStoreMockRecorder) CreateInviteTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInviteTx", reflect.TypeOf((*MockStore)(nil).CreateInviteTx), ctx, arg)
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockStore) CreateMFAChallenge(ctx context.Context, arg db.CreateMFAChallengeParams) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", ctx, arg)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockStoreMockRecorder) CreateMFAChallenge(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockStore)(nil).CreateMFAChallenge), ctx, arg)
+}
+
 // CreateNewComment mocks base method.
 func (m *MockStore) CreateNewComment(ctx context.Context, arg db.CreateNewCommentParams) (db.Comment, error) {
 	m.ctrl.T.Helper()
@@ -45,306 +279,1210 @@ func (m *MockStore) CreateNewComment(ctx context.Context, arg db.CreateNewCommen
 	return ret0, ret1
 }
 
-// CreateNewComment indicates an expected call of CreateNewComment.
-func (mr *MockStoreMockRecorder) CreateNewComment(ctx, arg interface{}) *gomock.Call {
+// CreateNewComment indicates an expected call of CreateNewComment.
+func (mr *MockStoreMockRecorder) CreateNewComment(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewComment", reflect.TypeOf((*MockStore)(nil).CreateNewComment), ctx, arg)
+}
+
+// CreateNewPost mocks base method.
+func (m *MockStore) CreateNewPost(ctx context.Context, arg db.CreateNewPostParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewPost", ctx, arg)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewPost indicates an expected call of CreateNewPost.
+func (mr *MockStoreMockRecorder) CreateNewPost(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewPost", reflect.TypeOf((*MockStore)(nil).CreateNewPost), ctx, arg)
+}
+
+// CreateNewUser mocks base method.
+func (m *MockStore) CreateNewUser(ctx context.Context, arg db.CreateNewUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewUser", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewUser indicates an expected call of CreateNewUser.
+func (mr *MockStoreMockRecorder) CreateNewUser(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewUser", reflect.TypeOf((*MockStore)(nil).CreateNewUser), ctx, arg)
+}
+
+// CreateNewUserSession mocks base method.
+func (m *MockStore) CreateNewUserSession(ctx context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateNewUserSession", ctx, arg)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateNewUserSession indicates an expected call of CreateNewUserSession.
+func (mr *MockStoreMockRecorder) CreateNewUserSession(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewUserSession", reflect.TypeOf((*MockStore)(nil).CreateNewUserSession), ctx, arg)
+}
+
+// CreateOIDCLoginState mocks base method.
+func (m *MockStore) CreateOIDCLoginState(ctx context.Context, arg db.CreateOIDCLoginStateParams) (db.OidcLoginState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCLoginState", ctx, arg)
+	ret0, _ := ret[0].(db.OidcLoginState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOIDCLoginState indicates an expected call of CreateOIDCLoginState.
+func (mr *MockStoreMockRecorder) CreateOIDCLoginState(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCLoginState", reflect.TypeOf((*MockStore)(nil).CreateOIDCLoginState), ctx, arg)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockStore) CreatePasswordResetToken(ctx context.Context, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", ctx, arg)
+	ret0, _ := ret[0].(db.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockStoreMockRecorder) CreatePasswordResetToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockStore)(nil).CreatePasswordResetToken), ctx, arg)
+}
+
+// CreatePersonalAccessToken mocks base method.
+func (m *MockStore) CreatePersonalAccessToken(ctx context.Context, arg db.CreatePersonalAccessTokenParams) (db.PersonalAccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePersonalAccessToken", ctx, arg)
+	ret0, _ := ret[0].(db.PersonalAccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePersonalAccessToken indicates an expected call of CreatePersonalAccessToken.
+func (mr *MockStoreMockRecorder) CreatePersonalAccessToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePersonalAccessToken", reflect.TypeOf((*MockStore)(nil).CreatePersonalAccessToken), ctx, arg)
+}
+
+// CreatePostRevision mocks base method.
+func (m *MockStore) CreatePostRevision(ctx context.Context, arg db.CreatePostRevisionParams) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostRevision", ctx, arg)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePostRevision indicates an expected call of CreatePostRevision.
+func (mr *MockStoreMockRecorder) CreatePostRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostRevision", reflect.TypeOf((*MockStore)(nil).CreatePostRevision), ctx, arg)
+}
+
+// CreatePostTx mocks base method.
+func (m *MockStore) CreatePostTx(ctx context.Context, arg db.CreatePostTxParams) (db.CreatePostTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePostTx", ctx, arg)
+	ret0, _ := ret[0].(db.CreatePostTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePostTx indicates an expected call of CreatePostTx.
+func (mr *MockStoreMockRecorder) CreatePostTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostTx", reflect.TypeOf((*MockStore)(nil).CreatePostTx), ctx, arg)
+}
+
+// CreateTOTPRecoveryCode mocks base method.
+func (m *MockStore) CreateTOTPRecoveryCode(ctx context.Context, arg db.CreateTOTPRecoveryCodeParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTOTPRecoveryCode", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTOTPRecoveryCode indicates an expected call of CreateTOTPRecoveryCode.
+func (mr *MockStoreMockRecorder) CreateTOTPRecoveryCode(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTOTPRecoveryCode", reflect.TypeOf((*MockStore)(nil).CreateTOTPRecoveryCode), ctx, arg)
+}
+
+// CreateUserIdentity mocks base method.
+func (m *MockStore) CreateUserIdentity(ctx context.Context, arg db.CreateUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserIdentity", ctx, arg)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserIdentity indicates an expected call of CreateUserIdentity.
+func (mr *MockStoreMockRecorder) CreateUserIdentity(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserIdentity", reflect.TypeOf((*MockStore)(nil).CreateUserIdentity), ctx, arg)
+}
+
+// CreateUserTx mocks base method.
+func (m *MockStore) CreateUserTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUserTx", ctx, arg)
+	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUserTx indicates an expected call of CreateUserTx.
+func (mr *MockStoreMockRecorder) CreateUserTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), ctx, arg)
+}
+
+// DeleteCategory mocks base method.
+func (m *MockStore) DeleteCategory(ctx context.Context, id uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCategory", ctx, id)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCategory indicates an expected call of DeleteCategory.
+func (mr *MockStoreMockRecorder) DeleteCategory(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCategory", reflect.TypeOf((*MockStore)(nil).DeleteCategory), ctx, id)
+}
+
+// DeleteCommentByID mocks base method.
+func (m *MockStore) DeleteCommentByID(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommentByID", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCommentByID indicates an expected call of DeleteCommentByID.
+func (mr *MockStoreMockRecorder) DeleteCommentByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommentByID", reflect.TypeOf((*MockStore)(nil).DeleteCommentByID), ctx, id)
+}
+
+// DeleteEmailVerificationTokensByUsername mocks base method.
+func (m *MockStore) DeleteEmailVerificationTokensByUsername(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmailVerificationTokensByUsername", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEmailVerificationTokensByUsername indicates an expected call of DeleteEmailVerificationTokensByUsername.
+func (mr *MockStoreMockRecorder) DeleteEmailVerificationTokensByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmailVerificationTokensByUsername", reflect.TypeOf((*MockStore)(nil).DeleteEmailVerificationTokensByUsername), ctx, username)
+}
+
+// DeleteInvite mocks base method.
+func (m *MockStore) DeleteInvite(ctx context.Context, arg db.DeleteInviteParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteInvite", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteInvite indicates an expected call of DeleteInvite.
+func (mr *MockStoreMockRecorder) DeleteInvite(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInvite", reflect.TypeOf((*MockStore)(nil).DeleteInvite), ctx, arg)
+}
+
+// DeleteLoginAttempt mocks base method.
+func (m *MockStore) DeleteLoginAttempt(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLoginAttempt indicates an expected call of DeleteLoginAttempt.
+func (mr *MockStoreMockRecorder) DeleteLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLoginAttempt", reflect.TypeOf((*MockStore)(nil).DeleteLoginAttempt), ctx, key)
+}
+
+// DeletePersonalAccessToken mocks base method.
+func (m *MockStore) DeletePersonalAccessToken(ctx context.Context, arg db.DeletePersonalAccessTokenParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePersonalAccessToken", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePersonalAccessToken indicates an expected call of DeletePersonalAccessToken.
+func (mr *MockStoreMockRecorder) DeletePersonalAccessToken(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersonalAccessToken", reflect.TypeOf((*MockStore)(nil).DeletePersonalAccessToken), ctx, arg)
+}
+
+// DeletePersonalAccessTokensByUsername mocks base method.
+func (m *MockStore) DeletePersonalAccessTokensByUsername(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePersonalAccessTokensByUsername", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePersonalAccessTokensByUsername indicates an expected call of DeletePersonalAccessTokensByUsername.
+func (mr *MockStoreMockRecorder) DeletePersonalAccessTokensByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePersonalAccessTokensByUsername", reflect.TypeOf((*MockStore)(nil).DeletePersonalAccessTokensByUsername), ctx, username)
+}
+
+// DeleteCommentsByPostID mocks base method.
+func (m *MockStore) DeleteCommentsByPostID(ctx context.Context, postID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCommentsByPostID", ctx, postID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCommentsByPostID indicates an expected call of DeleteCommentsByPostID.
+func (mr *MockStoreMockRecorder) DeleteCommentsByPostID(ctx, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommentsByPostID", reflect.TypeOf((*MockStore)(nil).DeleteCommentsByPostID), ctx, postID)
+}
+
+// DeletePostByID mocks base method.
+func (m *MockStore) DeletePostByID(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePostByID", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePostByID indicates an expected call of DeletePostByID.
+func (mr *MockStoreMockRecorder) DeletePostByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostByID", reflect.TypeOf((*MockStore)(nil).DeletePostByID), ctx, id)
+}
+
+// DeletePostSlugHistory mocks base method.
+func (m *MockStore) DeletePostSlugHistory(ctx context.Context, arg db.DeletePostSlugHistoryParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePostSlugHistory", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePostSlugHistory indicates an expected call of DeletePostSlugHistory.
+func (mr *MockStoreMockRecorder) DeletePostSlugHistory(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostSlugHistory", reflect.TypeOf((*MockStore)(nil).DeletePostSlugHistory), ctx, arg)
+}
+
+// DeletePostTags mocks base method.
+func (m *MockStore) DeletePostTags(ctx context.Context, postID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePostTags", ctx, postID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePostTags indicates an expected call of DeletePostTags.
+func (mr *MockStoreMockRecorder) DeletePostTags(ctx, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostTags", reflect.TypeOf((*MockStore)(nil).DeletePostTags), ctx, postID)
+}
+
+// DeleteSessionById mocks base method.
+func (m *MockStore) DeleteSessionById(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSessionById", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSessionById indicates an expected call of DeleteSessionById.
+func (mr *MockStoreMockRecorder) DeleteSessionById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionById", reflect.TypeOf((*MockStore)(nil).DeleteSessionById), ctx, id)
+}
+
+// DeleteTOTPRecoveryCodesByUsername mocks base method.
+func (m *MockStore) DeleteTOTPRecoveryCodesByUsername(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTPRecoveryCodesByUsername", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTPRecoveryCodesByUsername indicates an expected call of DeleteTOTPRecoveryCodesByUsername.
+func (mr *MockStoreMockRecorder) DeleteTOTPRecoveryCodesByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTPRecoveryCodesByUsername", reflect.TypeOf((*MockStore)(nil).DeleteTOTPRecoveryCodesByUsername), ctx, username)
+}
+
+// DeleteUserAccount mocks base method.
+func (m *MockStore) DeleteUserAccount(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserAccount", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserAccount indicates an expected call of DeleteUserAccount.
+func (mr *MockStoreMockRecorder) DeleteUserAccount(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserAccount", reflect.TypeOf((*MockStore)(nil).DeleteUserAccount), ctx, username)
+}
+
+// DeleteUserAccountTx mocks base method.
+func (m *MockStore) DeleteUserAccountTx(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserAccountTx", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserAccountTx indicates an expected call of DeleteUserAccountTx.
+func (mr *MockStoreMockRecorder) DeleteUserAccountTx(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserAccountTx", reflect.TypeOf((*MockStore)(nil).DeleteUserAccountTx), ctx, username)
+}
+
+// GetAuditLogsByUsername mocks base method.
+func (m *MockStore) GetAuditLogsByUsername(ctx context.Context, username string) ([]db.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditLogsByUsername", ctx, username)
+	ret0, _ := ret[0].([]db.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditLogsByUsername indicates an expected call of GetAuditLogsByUsername.
+func (mr *MockStoreMockRecorder) GetAuditLogsByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditLogsByUsername", reflect.TypeOf((*MockStore)(nil).GetAuditLogsByUsername), ctx, username)
+}
+
+// GetCategoryAncestorIDs mocks base method.
+func (m *MockStore) GetCategoryAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryAncestorIDs", ctx, id)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryAncestorIDs indicates an expected call of GetCategoryAncestorIDs.
+func (mr *MockStoreMockRecorder) GetCategoryAncestorIDs(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryAncestorIDs", reflect.TypeOf((*MockStore)(nil).GetCategoryAncestorIDs), ctx, id)
+}
+
+// GetCategoryBySlug mocks base method.
+func (m *MockStore) GetCategoryBySlug(ctx context.Context, slug string) (db.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCategoryBySlug", ctx, slug)
+	ret0, _ := ret[0].(db.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCategoryBySlug indicates an expected call of GetCategoryBySlug.
+func (mr *MockStoreMockRecorder) GetCategoryBySlug(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCategoryBySlug", reflect.TypeOf((*MockStore)(nil).GetCategoryBySlug), ctx, slug)
+}
+
+// GetCommentByID mocks base method.
+func (m *MockStore) GetCommentByID(ctx context.Context, id uuid.UUID) (db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentByID", ctx, id)
+	ret0, _ := ret[0].(db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentByID indicates an expected call of GetCommentByID.
+func (mr *MockStoreMockRecorder) GetCommentByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByID", reflect.TypeOf((*MockStore)(nil).GetCommentByID), ctx, id)
+}
+
+// GetCommentsByUserName mocks base method.
+func (m *MockStore) GetCommentsByUserName(ctx context.Context, username string) ([]db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentsByUserName", ctx, username)
+	ret0, _ := ret[0].([]db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentsByUserName indicates an expected call of GetCommentsByUserName.
+func (mr *MockStoreMockRecorder) GetCommentsByUserName(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByUserName", reflect.TypeOf((*MockStore)(nil).GetCommentsByUserName), ctx, username)
+}
+
+// GetEmailVerificationTokenByHash mocks base method.
+func (m *MockStore) GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (db.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailVerificationTokenByHash indicates an expected call of GetEmailVerificationTokenByHash.
+func (mr *MockStoreMockRecorder) GetEmailVerificationTokenByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationTokenByHash", reflect.TypeOf((*MockStore)(nil).GetEmailVerificationTokenByHash), ctx, tokenHash)
+}
+
+// GetInvitesByInviter mocks base method.
+func (m *MockStore) GetInvitesByInviter(ctx context.Context, inviterUsername string) ([]db.Invite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitesByInviter", ctx, inviterUsername)
+	ret0, _ := ret[0].([]db.Invite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitesByInviter indicates an expected call of GetInvitesByInviter.
+func (mr *MockStoreMockRecorder) GetInvitesByInviter(ctx, inviterUsername interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitesByInviter", reflect.TypeOf((*MockStore)(nil).GetInvitesByInviter), ctx, inviterUsername)
+}
+
+// GetLatestEmailVerificationToken mocks base method.
+func (m *MockStore) GetLatestEmailVerificationToken(ctx context.Context, username string) (db.EmailVerificationToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestEmailVerificationToken", ctx, username)
+	ret0, _ := ret[0].(db.EmailVerificationToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestEmailVerificationToken indicates an expected call of GetLatestEmailVerificationToken.
+func (mr *MockStoreMockRecorder) GetLatestEmailVerificationToken(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestEmailVerificationToken", reflect.TypeOf((*MockStore)(nil).GetLatestEmailVerificationToken), ctx, username)
+}
+
+// GetLatestPasswordResetToken mocks base method.
+func (m *MockStore) GetLatestPasswordResetToken(ctx context.Context, username string) (db.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestPasswordResetToken", ctx, username)
+	ret0, _ := ret[0].(db.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestPasswordResetToken indicates an expected call of GetLatestPasswordResetToken.
+func (mr *MockStoreMockRecorder) GetLatestPasswordResetToken(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestPasswordResetToken", reflect.TypeOf((*MockStore)(nil).GetLatestPasswordResetToken), ctx, username)
+}
+
+// GetLatestPostRevision mocks base method.
+func (m *MockStore) GetLatestPostRevision(ctx context.Context, postID uuid.UUID) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestPostRevision", ctx, postID)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestPostRevision indicates an expected call of GetLatestPostRevision.
+func (mr *MockStoreMockRecorder) GetLatestPostRevision(ctx, postID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestPostRevision", reflect.TypeOf((*MockStore)(nil).GetLatestPostRevision), ctx, postID)
+}
+
+// GetLoginAttempt mocks base method.
+func (m *MockStore) GetLoginAttempt(ctx context.Context, key string) (db.LoginAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginAttempt", ctx, key)
+	ret0, _ := ret[0].(db.LoginAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginAttempt indicates an expected call of GetLoginAttempt.
+func (mr *MockStoreMockRecorder) GetLoginAttempt(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginAttempt", reflect.TypeOf((*MockStore)(nil).GetLoginAttempt), ctx, key)
+}
+
+// GetMFAChallengeByHash mocks base method.
+func (m *MockStore) GetMFAChallengeByHash(ctx context.Context, tokenHash string) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFAChallengeByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFAChallengeByHash indicates an expected call of GetMFAChallengeByHash.
+func (mr *MockStoreMockRecorder) GetMFAChallengeByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFAChallengeByHash", reflect.TypeOf((*MockStore)(nil).GetMFAChallengeByHash), ctx, tokenHash)
+}
+
+// GetPasswordResetTokenByHash mocks base method.
+func (m *MockStore) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (db.PasswordResetToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordResetTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(db.PasswordResetToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordResetTokenByHash indicates an expected call of GetPasswordResetTokenByHash.
+func (mr *MockStoreMockRecorder) GetPasswordResetTokenByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordResetTokenByHash", reflect.TypeOf((*MockStore)(nil).GetPasswordResetTokenByHash), ctx, tokenHash)
+}
+
+// GetPersonalAccessTokenByHash mocks base method.
+func (m *MockStore) GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (db.GetPersonalAccessTokenByHashRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonalAccessTokenByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(db.GetPersonalAccessTokenByHashRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonalAccessTokenByHash indicates an expected call of GetPersonalAccessTokenByHash.
+func (mr *MockStoreMockRecorder) GetPersonalAccessTokenByHash(ctx, tokenHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonalAccessTokenByHash", reflect.TypeOf((*MockStore)(nil).GetPersonalAccessTokenByHash), ctx, tokenHash)
+}
+
+// GetPersonalAccessTokensByUsername mocks base method.
+func (m *MockStore) GetPersonalAccessTokensByUsername(ctx context.Context, username string) ([]db.PersonalAccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPersonalAccessTokensByUsername", ctx, username)
+	ret0, _ := ret[0].([]db.PersonalAccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPersonalAccessTokensByUsername indicates an expected call of GetPersonalAccessTokensByUsername.
+func (mr *MockStoreMockRecorder) GetPersonalAccessTokensByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPersonalAccessTokensByUsername", reflect.TypeOf((*MockStore)(nil).GetPersonalAccessTokensByUsername), ctx, username)
+}
+
+// GetPostById mocks base method.
+func (m *MockStore) GetPostById(ctx context.Context, id uuid.UUID) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostById", ctx, id)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostById indicates an expected call of GetPostById.
+func (mr *MockStoreMockRecorder) GetPostById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostById", reflect.TypeOf((*MockStore)(nil).GetPostById), ctx, id)
+}
+
+// GetPostRevision mocks base method.
+func (m *MockStore) GetPostRevision(ctx context.Context, arg db.GetPostRevisionParams) (db.PostRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostRevision", ctx, arg)
+	ret0, _ := ret[0].(db.PostRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostRevision indicates an expected call of GetPostRevision.
+func (mr *MockStoreMockRecorder) GetPostRevision(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostRevision", reflect.TypeOf((*MockStore)(nil).GetPostRevision), ctx, arg)
+}
+
+// GetPostsByUserName mocks base method.
+func (m *MockStore) GetPostsByUserName(ctx context.Context, username string) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostsByUserName", ctx, username)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostsByUserName indicates an expected call of GetPostsByUserName.
+func (mr *MockStoreMockRecorder) GetPostsByUserName(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByUserName", reflect.TypeOf((*MockStore)(nil).GetPostsByUserName), ctx, username)
+}
+
+// GetPublishedPostByPreviousSlug mocks base method.
+func (m *MockStore) GetPublishedPostByPreviousSlug(ctx context.Context, arg db.GetPublishedPostByPreviousSlugParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublishedPostByPreviousSlug", ctx, arg)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublishedPostByPreviousSlug indicates an expected call of GetPublishedPostByPreviousSlug.
+func (mr *MockStoreMockRecorder) GetPublishedPostByPreviousSlug(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublishedPostByPreviousSlug", reflect.TypeOf((*MockStore)(nil).GetPublishedPostByPreviousSlug), ctx, arg)
+}
+
+// GetPublishedPostByUsernameAndSlug mocks base method.
+func (m *MockStore) GetPublishedPostByUsernameAndSlug(ctx context.Context, arg db.GetPublishedPostByUsernameAndSlugParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPublishedPostByUsernameAndSlug", ctx, arg)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPublishedPostByUsernameAndSlug indicates an expected call of GetPublishedPostByUsernameAndSlug.
+func (mr *MockStoreMockRecorder) GetPublishedPostByUsernameAndSlug(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublishedPostByUsernameAndSlug", reflect.TypeOf((*MockStore)(nil).GetPublishedPostByUsernameAndSlug), ctx, arg)
+}
+
+// GetRemainingInviteUsesByInviter mocks base method.
+func (m *MockStore) GetRemainingInviteUsesByInviter(ctx context.Context, inviterUsername string) (int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRemainingInviteUsesByInviter", ctx, inviterUsername)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRemainingInviteUsesByInviter indicates an expected call of GetRemainingInviteUsesByInviter.
+func (mr *MockStoreMockRecorder) GetRemainingInviteUsesByInviter(ctx, inviterUsername interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemainingInviteUsesByInviter", reflect.TypeOf((*MockStore)(nil).GetRemainingInviteUsesByInviter), ctx, inviterUsername)
+}
+
+// GetSessionById mocks base method.
+func (m *MockStore) GetSessionById(ctx context.Context, id uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionById", ctx, id)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessionById indicates an expected call of GetSessionById.
+func (mr *MockStoreMockRecorder) GetSessionById(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionById", reflect.TypeOf((*MockStore)(nil).GetSessionById), ctx, id)
+}
+
+// GetTagsByPostIDs mocks base method.
+func (m *MockStore) GetTagsByPostIDs(ctx context.Context, postIds []uuid.UUID) ([]db.GetTagsByPostIDsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagsByPostIDs", ctx, postIds)
+	ret0, _ := ret[0].([]db.GetTagsByPostIDsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagsByPostIDs indicates an expected call of GetTagsByPostIDs.
+func (mr *MockStoreMockRecorder) GetTagsByPostIDs(ctx, postIds interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagsByPostIDs", reflect.TypeOf((*MockStore)(nil).GetTagsByPostIDs), ctx, postIds)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStore) GetUserByEmail(ctx context.Context, email string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStoreMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStore)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStore) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStoreMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStore)(nil).GetUserByID), ctx, id)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockStore) GetUserByUsername(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockStoreMockRecorder) GetUserByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), ctx, username)
+}
+
+// GetUserIdentity mocks base method.
+func (m *MockStore) GetUserIdentity(ctx context.Context, arg db.GetUserIdentityParams) (db.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdentity", ctx, arg)
+	ret0, _ := ret[0].(db.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdentity indicates an expected call of GetUserIdentity.
+func (mr *MockStoreMockRecorder) GetUserIdentity(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdentity", reflect.TypeOf((*MockStore)(nil).GetUserIdentity), ctx, arg)
+}
+
+// GetUserSessionsByUsername mocks base method.
+func (m *MockStore) GetUserSessionsByUsername(ctx context.Context, username string) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserSessionsByUsername", ctx, username)
+	ret0, _ := ret[0].([]db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserSessionsByUsername indicates an expected call of GetUserSessionsByUsername.
+func (mr *MockStoreMockRecorder) GetUserSessionsByUsername(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessionsByUsername", reflect.TypeOf((*MockStore)(nil).GetUserSessionsByUsername), ctx, username)
+}
+
+// GetUserTOTP mocks base method.
+func (m *MockStore) GetUserTOTP(ctx context.Context, username string) (db.UserTotp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTOTP", ctx, username)
+	ret0, _ := ret[0].(db.UserTotp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTOTP indicates an expected call of GetUserTOTP.
+func (mr *MockStoreMockRecorder) GetUserTOTP(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTOTP", reflect.TypeOf((*MockStore)(nil).GetUserTOTP), ctx, username)
+}
+
+// IncrementMFAChallengeFailedAttempts mocks base method.
+func (m *MockStore) IncrementMFAChallengeFailedAttempts(ctx context.Context, id uuid.UUID) (db.MfaChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementMFAChallengeFailedAttempts", ctx, id)
+	ret0, _ := ret[0].(db.MfaChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementMFAChallengeFailedAttempts indicates an expected call of IncrementMFAChallengeFailedAttempts.
+func (mr *MockStoreMockRecorder) IncrementMFAChallengeFailedAttempts(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementMFAChallengeFailedAttempts", reflect.TypeOf((*MockStore)(nil).IncrementMFAChallengeFailedAttempts), ctx, id)
+}
+
+// ListCategories mocks base method.
+func (m *MockStore) ListCategories(ctx context.Context) ([]db.Category, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCategories", ctx)
+	ret0, _ := ret[0].([]db.Category)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCategories indicates an expected call of ListCategories.
+func (mr *MockStoreMockRecorder) ListCategories(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCategories", reflect.TypeOf((*MockStore)(nil).ListCategories), ctx)
+}
+
+// ListCommentsByPostID mocks base method.
+func (m *MockStore) ListCommentsByPostID(ctx context.Context, arg db.ListCommentsByPostIDParams) ([]db.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCommentsByPostID", ctx, arg)
+	ret0, _ := ret[0].([]db.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCommentsByPostID indicates an expected call of ListCommentsByPostID.
+func (mr *MockStoreMockRecorder) ListCommentsByPostID(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCommentsByPostID", reflect.TypeOf((*MockStore)(nil).ListCommentsByPostID), ctx, arg)
+}
+
+// ListPostRevisions mocks base method.
+func (m *MockStore) ListPostRevisions(ctx context.Context, arg db.ListPostRevisionsParams) ([]db.ListPostRevisionsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPostRevisions", ctx, arg)
+	ret0, _ := ret[0].([]db.ListPostRevisionsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPostRevisions indicates an expected call of ListPostRevisions.
+func (mr *MockStoreMockRecorder) ListPostRevisions(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostRevisions", reflect.TypeOf((*MockStore)(nil).ListPostRevisions), ctx, arg)
+}
+
+// ListDraftPostsByUsername mocks base method.
+func (m *MockStore) ListDraftPostsByUsername(ctx context.Context, arg db.ListDraftPostsByUsernameParams) ([]db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDraftPostsByUsername", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDraftPostsByUsername indicates an expected call of ListDraftPostsByUsername.
+func (mr *MockStoreMockRecorder) ListDraftPostsByUsername(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewComment", reflect.TypeOf((*MockStore)(nil).CreateNewComment), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDraftPostsByUsername", reflect.TypeOf((*MockStore)(nil).ListDraftPostsByUsername), ctx, arg)
 }
 
-// CreateNewPost mocks base method.
-func (m *MockStore) CreateNewPost(ctx context.Context, arg db.CreateNewPostParams) (db.Post, error) {
+// ListPostsByUsername mocks base method.
+func (m *MockStore) ListPostsByUsername(ctx context.Context, arg db.ListPostsByUsernameParams) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewPost", ctx, arg)
-	ret0, _ := ret[0].(db.Post)
+	ret := m.ctrl.Call(m, "ListPostsByUsername", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateNewPost indicates an expected call of CreateNewPost.
-func (mr *MockStoreMockRecorder) CreateNewPost(ctx, arg interface{}) *gomock.Call {
+// ListPostsByUsername indicates an expected call of ListPostsByUsername.
+func (mr *MockStoreMockRecorder) ListPostsByUsername(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewPost", reflect.TypeOf((*MockStore)(nil).CreateNewPost), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPostsByUsername", reflect.TypeOf((*MockStore)(nil).ListPostsByUsername), ctx, arg)
 }
 
-// CreateNewUser mocks base method.
-func (m *MockStore) CreateNewUser(ctx context.Context, arg db.CreateNewUserParams) (db.User, error) {
+// ListPublishedPosts mocks base method.
+func (m *MockStore) ListPublishedPosts(ctx context.Context, arg db.ListPublishedPostsParams) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewUser", ctx, arg)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListPublishedPosts", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateNewUser indicates an expected call of CreateNewUser.
-func (mr *MockStoreMockRecorder) CreateNewUser(ctx, arg interface{}) *gomock.Call {
+// ListPublishedPosts indicates an expected call of ListPublishedPosts.
+func (mr *MockStoreMockRecorder) ListPublishedPosts(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewUser", reflect.TypeOf((*MockStore)(nil).CreateNewUser), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishedPosts", reflect.TypeOf((*MockStore)(nil).ListPublishedPosts), ctx, arg)
 }
 
-// CreateNewUserSession mocks base method.
-func (m *MockStore) CreateNewUserSession(ctx context.Context, arg db.CreateNewUserSessionParams) (db.Session, error) {
+// ListPublishedPostsByCategory mocks base method.
+func (m *MockStore) ListPublishedPostsByCategory(ctx context.Context, arg db.ListPublishedPostsByCategoryParams) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewUserSession", ctx, arg)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "ListPublishedPostsByCategory", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateNewUserSession indicates an expected call of CreateNewUserSession.
-func (mr *MockStoreMockRecorder) CreateNewUserSession(ctx, arg interface{}) *gomock.Call {
+// ListPublishedPostsByCategory indicates an expected call of ListPublishedPostsByCategory.
+func (mr *MockStoreMockRecorder) ListPublishedPostsByCategory(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewUserSession", reflect.TypeOf((*MockStore)(nil).CreateNewUserSession), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishedPostsByCategory", reflect.TypeOf((*MockStore)(nil).ListPublishedPostsByCategory), ctx, arg)
 }
 
-// CreatePostTx mocks base method.
-func (m *MockStore) CreatePostTx(ctx context.Context, arg db.CreatePostTxParams) (db.CreatePostTxResult, error) {
+// ListPublishedPostsByTag mocks base method.
+func (m *MockStore) ListPublishedPostsByTag(ctx context.Context, arg db.ListPublishedPostsByTagParams) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreatePostTx", ctx, arg)
-	ret0, _ := ret[0].(db.CreatePostTxResult)
+	ret := m.ctrl.Call(m, "ListPublishedPostsByTag", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreatePostTx indicates an expected call of CreatePostTx.
-func (mr *MockStoreMockRecorder) CreatePostTx(ctx, arg interface{}) *gomock.Call {
+// ListPublishedPostsByTag indicates an expected call of ListPublishedPostsByTag.
+func (mr *MockStoreMockRecorder) ListPublishedPostsByTag(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePostTx", reflect.TypeOf((*MockStore)(nil).CreatePostTx), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishedPostsByTag", reflect.TypeOf((*MockStore)(nil).ListPublishedPostsByTag), ctx, arg)
 }
 
-// CreateUserTx mocks base method.
-func (m *MockStore) CreateUserTx(ctx context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+// ListPublishedPostsInCategoryTree mocks base method.
+func (m *MockStore) ListPublishedPostsInCategoryTree(ctx context.Context, arg db.ListPublishedPostsInCategoryTreeParams) ([]db.Post, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUserTx", ctx, arg)
-	ret0, _ := ret[0].(db.CreateUserTxResult)
+	ret := m.ctrl.Call(m, "ListPublishedPostsInCategoryTree", ctx, arg)
+	ret0, _ := ret[0].([]db.Post)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUserTx indicates an expected call of CreateUserTx.
-func (mr *MockStoreMockRecorder) CreateUserTx(ctx, arg interface{}) *gomock.Call {
+// ListPublishedPostsInCategoryTree indicates an expected call of ListPublishedPostsInCategoryTree.
+func (mr *MockStoreMockRecorder) ListPublishedPostsInCategoryTree(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUserTx", reflect.TypeOf((*MockStore)(nil).CreateUserTx), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPublishedPostsInCategoryTree", reflect.TypeOf((*MockStore)(nil).ListPublishedPostsInCategoryTree), ctx, arg)
 }
 
-// DeleteCommentByID mocks base method.
-func (m *MockStore) DeleteCommentByID(ctx context.Context, id uuid.UUID) error {
+// ListTagsWithPostCounts mocks base method.
+func (m *MockStore) ListTagsWithPostCounts(ctx context.Context) ([]db.ListTagsWithPostCountsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteCommentByID", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListTagsWithPostCounts", ctx)
+	ret0, _ := ret[0].([]db.ListTagsWithPostCountsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteCommentByID indicates an expected call of DeleteCommentByID.
-func (mr *MockStoreMockRecorder) DeleteCommentByID(ctx, id interface{}) *gomock.Call {
+// ListTagsWithPostCounts indicates an expected call of ListTagsWithPostCounts.
+func (mr *MockStoreMockRecorder) ListTagsWithPostCounts(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCommentByID", reflect.TypeOf((*MockStore)(nil).DeleteCommentByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTagsWithPostCounts", reflect.TypeOf((*MockStore)(nil).ListTagsWithPostCounts), ctx)
 }
 
-// DeletePostByID mocks base method.
-func (m *MockStore) DeletePostByID(ctx context.Context, id uuid.UUID) error {
+// ListTakenPostSlugs mocks base method.
+func (m *MockStore) ListTakenPostSlugs(ctx context.Context, arg db.ListTakenPostSlugsParams) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeletePostByID", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListTakenPostSlugs", ctx, arg)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeletePostByID indicates an expected call of DeletePostByID.
-func (mr *MockStoreMockRecorder) DeletePostByID(ctx, id interface{}) *gomock.Call {
+// ListTakenPostSlugs indicates an expected call of ListTakenPostSlugs.
+func (mr *MockStoreMockRecorder) ListTakenPostSlugs(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePostByID", reflect.TypeOf((*MockStore)(nil).DeletePostByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTakenPostSlugs", reflect.TypeOf((*MockStore)(nil).ListTakenPostSlugs), ctx, arg)
 }
 
-// DeleteSessionById mocks base method.
-func (m *MockStore) DeleteSessionById(ctx context.Context, id uuid.UUID) error {
+// LockAdminUsernames mocks base method.
+func (m *MockStore) LockAdminUsernames(ctx context.Context) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSessionById", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "LockAdminUsernames", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteSessionById indicates an expected call of DeleteSessionById.
-func (mr *MockStoreMockRecorder) DeleteSessionById(ctx, id interface{}) *gomock.Call {
+// LockAdminUsernames indicates an expected call of LockAdminUsernames.
+func (mr *MockStoreMockRecorder) LockAdminUsernames(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSessionById", reflect.TypeOf((*MockStore)(nil).DeleteSessionById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAdminUsernames", reflect.TypeOf((*MockStore)(nil).LockAdminUsernames), ctx)
 }
 
-// DeleteUserAccount mocks base method.
-func (m *MockStore) DeleteUserAccount(ctx context.Context, username string) error {
+// LockUserByUsername mocks base method.
+func (m *MockStore) LockUserByUsername(ctx context.Context, username string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUserAccount", ctx, username)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "LockUserByUsername", ctx, username)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteUserAccount indicates an expected call of DeleteUserAccount.
-func (mr *MockStoreMockRecorder) DeleteUserAccount(ctx, username interface{}) *gomock.Call {
+// LockUserByUsername indicates an expected call of LockUserByUsername.
+func (mr *MockStoreMockRecorder) LockUserByUsername(ctx, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserAccount", reflect.TypeOf((*MockStore)(nil).DeleteUserAccount), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockUserByUsername", reflect.TypeOf((*MockStore)(nil).LockUserByUsername), ctx, username)
 }
 
-// GetAllPosts mocks base method.
-func (m *MockStore) GetAllPosts(ctx context.Context) ([]db.GetAllPostsRow, error) {
+// MarkMFAChallengeAsUsed mocks base method.
+func (m *MockStore) MarkMFAChallengeAsUsed(ctx context.Context, id uuid.UUID) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPosts", ctx)
-	ret0, _ := ret[0].([]db.GetAllPostsRow)
+	ret := m.ctrl.Call(m, "MarkMFAChallengeAsUsed", ctx, id)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllPosts indicates an expected call of GetAllPosts.
-func (mr *MockStoreMockRecorder) GetAllPosts(ctx interface{}) *gomock.Call {
+// MarkMFAChallengeAsUsed indicates an expected call of MarkMFAChallengeAsUsed.
+func (mr *MockStoreMockRecorder) MarkMFAChallengeAsUsed(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPosts", reflect.TypeOf((*MockStore)(nil).GetAllPosts), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMFAChallengeAsUsed", reflect.TypeOf((*MockStore)(nil).MarkMFAChallengeAsUsed), ctx, id)
 }
 
-// GetCommentByID mocks base method.
-func (m *MockStore) GetCommentByID(ctx context.Context, id uuid.UUID) (db.Comment, error) {
+// MarkPasswordResetTokenAsUsed mocks base method.
+func (m *MockStore) MarkPasswordResetTokenAsUsed(ctx context.Context, id uuid.UUID) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentByID", ctx, id)
-	ret0, _ := ret[0].(db.Comment)
+	ret := m.ctrl.Call(m, "MarkPasswordResetTokenAsUsed", ctx, id)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetCommentByID indicates an expected call of GetCommentByID.
-func (mr *MockStoreMockRecorder) GetCommentByID(ctx, id interface{}) *gomock.Call {
+// MarkPasswordResetTokenAsUsed indicates an expected call of MarkPasswordResetTokenAsUsed.
+func (mr *MockStoreMockRecorder) MarkPasswordResetTokenAsUsed(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentByID", reflect.TypeOf((*MockStore)(nil).GetCommentByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPasswordResetTokenAsUsed", reflect.TypeOf((*MockStore)(nil).MarkPasswordResetTokenAsUsed), ctx, id)
 }
 
-// GetCommentsByPostID mocks base method.
-func (m *MockStore) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]db.Comment, error) {
+// MarkSessionAsUsed mocks base method.
+func (m *MockStore) MarkSessionAsUsed(ctx context.Context, id uuid.UUID) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentsByPostID", ctx, postID)
-	ret0, _ := ret[0].([]db.Comment)
+	ret := m.ctrl.Call(m, "MarkSessionAsUsed", ctx, id)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetCommentsByPostID indicates an expected call of GetCommentsByPostID.
-func (mr *MockStoreMockRecorder) GetCommentsByPostID(ctx, postID interface{}) *gomock.Call {
+// MarkSessionAsUsed indicates an expected call of MarkSessionAsUsed.
+func (mr *MockStoreMockRecorder) MarkSessionAsUsed(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByPostID", reflect.TypeOf((*MockStore)(nil).GetCommentsByPostID), ctx, postID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSessionAsUsed", reflect.TypeOf((*MockStore)(nil).MarkSessionAsUsed), ctx, id)
 }
 
-// GetCommentsByUserName mocks base method.
-func (m *MockStore) GetCommentsByUserName(ctx context.Context, username string) ([]db.Comment, error) {
+// MarkUserEmailAsVerified mocks base method.
+func (m *MockStore) MarkUserEmailAsVerified(ctx context.Context, username string) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentsByUserName", ctx, username)
-	ret0, _ := ret[0].([]db.Comment)
+	ret := m.ctrl.Call(m, "MarkUserEmailAsVerified", ctx, username)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetCommentsByUserName indicates an expected call of GetCommentsByUserName.
-func (mr *MockStoreMockRecorder) GetCommentsByUserName(ctx, username interface{}) *gomock.Call {
+// MarkUserEmailAsVerified indicates an expected call of MarkUserEmailAsVerified.
+func (mr *MockStoreMockRecorder) MarkUserEmailAsVerified(ctx, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByUserName", reflect.TypeOf((*MockStore)(nil).GetCommentsByUserName), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUserEmailAsVerified", reflect.TypeOf((*MockStore)(nil).MarkUserEmailAsVerified), ctx, username)
 }
 
-// GetPostById mocks base method.
-func (m *MockStore) GetPostById(ctx context.Context, id uuid.UUID) (db.Post, error) {
+// RecordLoginFailure mocks base method.
+func (m *MockStore) RecordLoginFailure(ctx context.Context, arg db.RecordLoginFailureParams) (db.LoginAttempt, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostById", ctx, id)
-	ret0, _ := ret[0].(db.Post)
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, arg)
+	ret0, _ := ret[0].(db.LoginAttempt)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostById indicates an expected call of GetPostById.
-func (mr *MockStoreMockRecorder) GetPostById(ctx, id interface{}) *gomock.Call {
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockStoreMockRecorder) RecordLoginFailure(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostById", reflect.TypeOf((*MockStore)(nil).GetPostById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockStore)(nil).RecordLoginFailure), ctx, arg)
 }
 
-// GetPostsByCategory mocks base method.
-func (m *MockStore) GetPostsByCategory(ctx context.Context, category string) ([]db.Post, error) {
+// RehashUserPassword mocks base method.
+func (m *MockStore) RehashUserPassword(ctx context.Context, arg db.RehashUserPasswordParams) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByCategory", ctx, category)
-	ret0, _ := ret[0].([]db.Post)
+	ret := m.ctrl.Call(m, "RehashUserPassword", ctx, arg)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetPostsByCategory indicates an expected call of GetPostsByCategory.
-func (mr *MockStoreMockRecorder) GetPostsByCategory(ctx, category interface{}) *gomock.Call {
+// RehashUserPassword indicates an expected call of RehashUserPassword.
+func (mr *MockStoreMockRecorder) RehashUserPassword(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByCategory", reflect.TypeOf((*MockStore)(nil).GetPostsByCategory), ctx, category)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashUserPassword", reflect.TypeOf((*MockStore)(nil).RehashUserPassword), ctx, arg)
 }
 
-// GetPostsByUserName mocks base method.
-func (m *MockStore) GetPostsByUserName(ctx context.Context, username string) ([]db.Post, error) {
+// ResetPasswordTx mocks base method.
+func (m *MockStore) ResetPasswordTx(ctx context.Context, arg db.ResetPasswordTxParams) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostsByUserName", ctx, username)
-	ret0, _ := ret[0].([]db.Post)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "ResetPasswordTx", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetPostsByUserName indicates an expected call of GetPostsByUserName.
-func (mr *MockStoreMockRecorder) GetPostsByUserName(ctx, username interface{}) *gomock.Call {
+// ResetPasswordTx indicates an expected call of ResetPasswordTx.
+func (mr *MockStoreMockRecorder) ResetPasswordTx(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostsByUserName", reflect.TypeOf((*MockStore)(nil).GetPostsByUserName), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPasswordTx", reflect.TypeOf((*MockStore)(nil).ResetPasswordTx), ctx, arg)
 }
 
-// GetSessionById mocks base method.
-func (m *MockStore) GetSessionById(ctx context.Context, id uuid.UUID) (db.Session, error) {
+// RestorePostRevisionTx mocks base method.
+func (m *MockStore) RestorePostRevisionTx(ctx context.Context, arg db.RestorePostRevisionTxParams) (db.RestorePostRevisionTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSessionById", ctx, id)
-	ret0, _ := ret[0].(db.Session)
+	ret := m.ctrl.Call(m, "RestorePostRevisionTx", ctx, arg)
+	ret0, _ := ret[0].(db.RestorePostRevisionTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSessionById indicates an expected call of GetSessionById.
-func (mr *MockStoreMockRecorder) GetSessionById(ctx, id interface{}) *gomock.Call {
+// RestorePostRevisionTx indicates an expected call of RestorePostRevisionTx.
+func (mr *MockStoreMockRecorder) RestorePostRevisionTx(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionById", reflect.TypeOf((*MockStore)(nil).GetSessionById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestorePostRevisionTx", reflect.TypeOf((*MockStore)(nil).RestorePostRevisionTx), ctx, arg)
 }
 
-// GetUserByID mocks base method.
-func (m *MockStore) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+// RotateSessionTx mocks base method.
+func (m *MockStore) RotateSessionTx(ctx context.Context, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "RotateSessionTx", ctx, arg)
+	ret0, _ := ret[0].(db.RotateSessionTxResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByID indicates an expected call of GetUserByID.
-func (mr *MockStoreMockRecorder) GetUserByID(ctx, id interface{}) *gomock.Call {
+// RotateSessionTx indicates an expected call of RotateSessionTx.
+func (mr *MockStoreMockRecorder) RotateSessionTx(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStore)(nil).GetUserByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateSessionTx", reflect.TypeOf((*MockStore)(nil).RotateSessionTx), ctx, arg)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockStore) GetUserByUsername(ctx context.Context, username string) (db.User, error) {
+// SearchPublishedPosts mocks base method.
+func (m *MockStore) SearchPublishedPosts(ctx context.Context, arg db.SearchPublishedPostsParams) ([]db.SearchPublishedPostsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", ctx, username)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "SearchPublishedPosts", ctx, arg)
+	ret0, _ := ret[0].([]db.SearchPublishedPostsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockStoreMockRecorder) GetUserByUsername(ctx, username interface{}) *gomock.Call {
+// SearchPublishedPosts indicates an expected call of SearchPublishedPosts.
+func (mr *MockStoreMockRecorder) SearchPublishedPosts(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockStore)(nil).GetUserByUsername), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPublishedPosts", reflect.TypeOf((*MockStore)(nil).SearchPublishedPosts), ctx, arg)
 }
 
-// GetUserSessionsByUsername mocks base method.
-func (m *MockStore) GetUserSessionsByUsername(ctx context.Context, username string) ([]db.Session, error) {
+// UpdateCategory mocks base method.
+func (m *MockStore) UpdateCategory(ctx context.Context, arg db.UpdateCategoryParams) (db.Category, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserSessionsByUsername", ctx, username)
-	ret0, _ := ret[0].([]db.Session)
+	ret := m.ctrl.Call(m, "UpdateCategory", ctx, arg)
+	ret0, _ := ret[0].(db.Category)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserSessionsByUsername indicates an expected call of GetUserSessionsByUsername.
-func (mr *MockStoreMockRecorder) GetUserSessionsByUsername(ctx, username interface{}) *gomock.Call {
+// UpdateCategory indicates an expected call of UpdateCategory.
+func (mr *MockStoreMockRecorder) UpdateCategory(ctx, arg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserSessionsByUsername", reflect.TypeOf((*MockStore)(nil).GetUserSessionsByUsername), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCategory", reflect.TypeOf((*MockStore)(nil).UpdateCategory), ctx, arg)
+}
+
+// UpdatePersonalAccessTokenLastUsedAt mocks base method.
+func (m *MockStore) UpdatePersonalAccessTokenLastUsedAt(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePersonalAccessTokenLastUsedAt", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePersonalAccessTokenLastUsedAt indicates an expected call of UpdatePersonalAccessTokenLastUsedAt.
+func (mr *MockStoreMockRecorder) UpdatePersonalAccessTokenLastUsedAt(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePersonalAccessTokenLastUsedAt", reflect.TypeOf((*MockStore)(nil).UpdatePersonalAccessTokenLastUsedAt), ctx, id)
 }
 
 // UpdatePostBody mocks base method.
@@ -377,6 +1515,36 @@ func (mr *MockStoreMockRecorder) UpdatePostStatus(ctx, arg interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostStatus", reflect.TypeOf((*MockStore)(nil).UpdatePostStatus), ctx, arg)
 }
 
+// UpdatePostTitle mocks base method.
+func (m *MockStore) UpdatePostTitle(ctx context.Context, arg db.UpdatePostTitleParams) (db.Post, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostTitle", ctx, arg)
+	ret0, _ := ret[0].(db.Post)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostTitle indicates an expected call of UpdatePostTitle.
+func (mr *MockStoreMockRecorder) UpdatePostTitle(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostTitle", reflect.TypeOf((*MockStore)(nil).UpdatePostTitle), ctx, arg)
+}
+
+// UpdatePostTx mocks base method.
+func (m *MockStore) UpdatePostTx(ctx context.Context, arg db.UpdatePostTxParams) (db.UpdatePostTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePostTx", ctx, arg)
+	ret0, _ := ret[0].(db.UpdatePostTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePostTx indicates an expected call of UpdatePostTx.
+func (mr *MockStoreMockRecorder) UpdatePostTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePostTx", reflect.TypeOf((*MockStore)(nil).UpdatePostTx), ctx, arg)
+}
+
 // UpdateUserInterestsByUsername mocks base method.
 func (m *MockStore) UpdateUserInterestsByUsername(ctx context.Context, arg db.UpdateUserInterestsByUsernameParams) error {
 	m.ctrl.T.Helper()
@@ -390,3 +1558,151 @@ func (mr *MockStoreMockRecorder) UpdateUserInterestsByUsername(ctx, arg interfac
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserInterestsByUsername", reflect.TypeOf((*MockStore)(nil).UpdateUserInterestsByUsername), ctx, arg)
 }
+
+// UpdateUserPassword mocks base method.
+func (m *MockStore) UpdateUserPassword(ctx context.Context, arg db.UpdateUserPasswordParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStoreMockRecorder) UpdateUserPassword(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserPassword), ctx, arg)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), ctx, arg)
+}
+
+// UpdateUserRoleTx mocks base method.
+func (m *MockStore) UpdateUserRoleTx(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRoleTx", ctx, arg)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRoleTx indicates an expected call of UpdateUserRoleTx.
+func (mr *MockStoreMockRecorder) UpdateUserRoleTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRoleTx", reflect.TypeOf((*MockStore)(nil).UpdateUserRoleTx), ctx, arg)
+}
+
+// UpdateUserTOTPLastUsedStep mocks base method.
+func (m *MockStore) UpdateUserTOTPLastUsedStep(ctx context.Context, arg db.UpdateUserTOTPLastUsedStepParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserTOTPLastUsedStep", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserTOTPLastUsedStep indicates an expected call of UpdateUserTOTPLastUsedStep.
+func (mr *MockStoreMockRecorder) UpdateUserTOTPLastUsedStep(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserTOTPLastUsedStep", reflect.TypeOf((*MockStore)(nil).UpdateUserTOTPLastUsedStep), ctx, arg)
+}
+
+// UpsertTag mocks base method.
+func (m *MockStore) UpsertTag(ctx context.Context, slug string) (db.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTag", ctx, slug)
+	ret0, _ := ret[0].(db.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTag indicates an expected call of UpsertTag.
+func (mr *MockStoreMockRecorder) UpsertTag(ctx, slug interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTag", reflect.TypeOf((*MockStore)(nil).UpsertTag), ctx, slug)
+}
+
+// UpsertUnconfirmedUserTOTP mocks base method.
+func (m *MockStore) UpsertUnconfirmedUserTOTP(ctx context.Context, arg db.UpsertUnconfirmedUserTOTPParams) (db.UserTotp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUnconfirmedUserTOTP", ctx, arg)
+	ret0, _ := ret[0].(db.UserTotp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUnconfirmedUserTOTP indicates an expected call of UpsertUnconfirmedUserTOTP.
+func (mr *MockStoreMockRecorder) UpsertUnconfirmedUserTOTP(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUnconfirmedUserTOTP", reflect.TypeOf((*MockStore)(nil).UpsertUnconfirmedUserTOTP), ctx, arg)
+}
+
+// UseInvite mocks base method.
+func (m *MockStore) UseInvite(ctx context.Context, codeHash string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseInvite", ctx, codeHash)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseInvite indicates an expected call of UseInvite.
+func (mr *MockStoreMockRecorder) UseInvite(ctx, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseInvite", reflect.TypeOf((*MockStore)(nil).UseInvite), ctx, codeHash)
+}
+
+// UseMFAChallengeTx mocks base method.
+func (m *MockStore) UseMFAChallengeTx(ctx context.Context, arg db.UseMFAChallengeTxParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFAChallengeTx", ctx, arg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseMFAChallengeTx indicates an expected call of UseMFAChallengeTx.
+func (mr *MockStoreMockRecorder) UseMFAChallengeTx(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFAChallengeTx", reflect.TypeOf((*MockStore)(nil).UseMFAChallengeTx), ctx, arg)
+}
+
+// UseTOTPRecoveryCode mocks base method.
+func (m *MockStore) UseTOTPRecoveryCode(ctx context.Context, arg db.UseTOTPRecoveryCodeParams) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPRecoveryCode", ctx, arg)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPRecoveryCode indicates an expected call of UseTOTPRecoveryCode.
+func (mr *MockStoreMockRecorder) UseTOTPRecoveryCode(ctx, arg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPRecoveryCode", reflect.TypeOf((*MockStore)(nil).UseTOTPRecoveryCode), ctx, arg)
+}
+
+// VerifyEmailTx mocks base method.
+func (m *MockStore) VerifyEmailTx(ctx context.Context, username string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmailTx", ctx, username)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmailTx indicates an expected call of VerifyEmailTx.
+func (mr *MockStoreMockRecorder) VerifyEmailTx(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmailTx", reflect.TypeOf((*MockStore)(nil).VerifyEmailTx), ctx, username)
+}
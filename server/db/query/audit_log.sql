@@ -0,0 +1,5 @@
+-- name: CreateAuditLog :one
+INSERT INTO audit_logs (action, username, client_ip, details) VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetAuditLogsByUsername :many
+SELECT * FROM audit_logs WHERE username = $1 ORDER BY created_at DESC;
@@ -0,0 +1,22 @@
+-- name: CreateCategory :one
+INSERT INTO categories (slug, name, description, parent_id) VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetCategoryBySlug :one
+SELECT * FROM categories WHERE slug = $1;
+
+-- name: ListCategories :many
+SELECT * FROM categories ORDER BY slug;
+
+-- name: GetCategoryAncestorIDs :many
+WITH RECURSIVE ancestors AS (
+  SELECT categories.id, categories.parent_id FROM categories WHERE categories.id = $1
+  UNION ALL
+  SELECT categories.id, categories.parent_id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id
+)
+SELECT ancestors.id FROM ancestors;
+
+-- name: UpdateCategory :one
+UPDATE categories SET slug = $2, name = $3, description = $4, parent_id = $5 WHERE id = $1 RETURNING *;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories WHERE id = $1;
@@ -0,0 +1,11 @@
+-- name: CreateEmailVerificationToken :one
+INSERT INTO email_verification_tokens (username, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetEmailVerificationTokenByHash :one
+SELECT * FROM email_verification_tokens WHERE token_hash = $1 LIMIT 1;
+
+-- name: GetLatestEmailVerificationToken :one
+SELECT * FROM email_verification_tokens WHERE username = $1 ORDER BY created_at DESC LIMIT 1;
+
+-- name: DeleteEmailVerificationTokensByUsername :exec
+DELETE FROM email_verification_tokens WHERE username = $1;
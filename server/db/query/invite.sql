@@ -0,0 +1,15 @@
+-- name: CreateInvite :one
+INSERT INTO invites (code_hash, inviter_username, max_uses, expires_at) VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetInvitesByInviter :many
+SELECT * FROM invites WHERE inviter_username = $1 ORDER BY created_at DESC;
+
+-- name: GetRemainingInviteUsesByInviter :one
+SELECT COALESCE(SUM(max_uses - uses), 0)::integer AS remaining_uses FROM invites
+WHERE inviter_username = $1 AND uses < max_uses AND expires_at > now();
+
+-- name: UseInvite :execrows
+UPDATE invites SET uses = uses + 1 WHERE code_hash = $1 AND uses < max_uses AND expires_at > now();
+
+-- name: DeleteInvite :execrows
+DELETE FROM invites WHERE id = $1 AND inviter_username = $2;
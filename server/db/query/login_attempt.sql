@@ -0,0 +1,15 @@
+-- name: BlockLoginAttempt :exec
+UPDATE login_attempts SET blocked_until = GREATEST(blocked_until, sqlc.arg(blocked_until)::timestamptz) WHERE key = sqlc.arg(key);
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts WHERE key = $1;
+
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts WHERE key = $1 LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (key, failures, last_failure_at) VALUES (sqlc.arg(key), 1, sqlc.arg(failed_at))
+ON CONFLICT (key) DO UPDATE SET
+  failures = CASE WHEN login_attempts.last_failure_at < sqlc.arg(reset_before) THEN 1 ELSE login_attempts.failures + 1 END,
+  last_failure_at = EXCLUDED.last_failure_at
+RETURNING *;
@@ -0,0 +1,5 @@
+-- name: ConsumeOIDCLoginState :one
+DELETE FROM oidc_login_states WHERE state_hash = $1 RETURNING *;
+
+-- name: CreateOIDCLoginState :one
+INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, link_username, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
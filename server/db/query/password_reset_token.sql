@@ -0,0 +1,11 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (username, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING *;
+
+-- name: GetPasswordResetTokenByHash :one
+SELECT * FROM password_reset_tokens WHERE token_hash = $1 LIMIT 1;
+
+-- name: GetLatestPasswordResetToken :one
+SELECT * FROM password_reset_tokens WHERE username = $1 ORDER BY created_at DESC LIMIT 1;
+
+-- name: MarkPasswordResetTokenAsUsed :execrows
+UPDATE password_reset_tokens SET is_used = true WHERE id = $1 AND is_used = false;
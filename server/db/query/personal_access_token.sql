@@ -0,0 +1,19 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (username, name, token_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING *;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens WHERE id = $1 AND username = $2;
+
+-- name: DeletePersonalAccessTokensByUsername :exec
+DELETE FROM personal_access_tokens WHERE username = $1;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT personal_access_tokens.*, users.role FROM personal_access_tokens
+JOIN users ON users.username = personal_access_tokens.username
+WHERE personal_access_tokens.token_hash = $1 LIMIT 1;
+
+-- name: GetPersonalAccessTokensByUsername :many
+SELECT * FROM personal_access_tokens WHERE username = $1 ORDER BY created_at DESC;
+
+-- name: UpdatePersonalAccessTokenLastUsedAt :exec
+UPDATE personal_access_tokens SET last_used_at = now() WHERE id = $1;
@@ -1,26 +1,17 @@
 -- name: CreateNewPost :one
-INSERT INTO posts (title, body, username, status, category, published_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
-
--- name: GetPostsByCategory :many
-SELECT * FROM posts WHERE category = $1;
+INSERT INTO posts (title, body, username, status, category, published_at, slug) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
 -- name: GetPostById :one
 SELECT * FROM posts WHERE id = $1;
 
--- name: GetAllPosts :many
-SELECT id, title, username, body, status, category, created_at, published_at, last_modified FROM posts;
-
 -- name: UpdatePostStatus :one
-UPDATE posts SET status = $1, published_at = $2 WHERE id = $3 AND username = $4 RETURNING *;
+UPDATE posts SET status = sqlc.arg(status), published_at = sqlc.arg(published_at), version = version + 1 WHERE id = sqlc.arg(id) AND lower(username) = lower(sqlc.arg(username)) AND version = sqlc.arg(version) RETURNING *;
 
 -- name: CreateNewComment :one
 INSERT INTO comments (username, post_id, body) VALUES ($1, $2, $3) RETURNING *;
 
--- name: GetCommentsByPostID :many
-SELECT * FROM comments WHERE post_id = $1;
-
 -- name: GetCommentsByUserName :many
-SELECT * FROM comments WHERE username = $1;
+SELECT * FROM comments WHERE lower(username) = lower(sqlc.arg(username));
 
 -- name: GetCommentByID :one
 SELECT * FROM comments WHERE id = $1;
@@ -28,5 +19,106 @@ SELECT * FROM comments WHERE id = $1;
 -- name: DeleteCommentByID :exec
 DELETE FROM comments WHERE id = $1;
 
+-- name: DeleteCommentsByPostID :exec
+DELETE FROM comments WHERE post_id = $1;
+
 -- name: DeletePostByID :exec
-DELETE FROM posts WHERE id = $1;
\ No newline at end of file
+DELETE FROM posts WHERE id = $1;
+
+-- name: ListPublishedPosts :many
+SELECT * FROM posts
+WHERE status = 'published'
+AND (sqlc.narg(cursor_published_at)::text IS NULL OR (published_at, id) < (sqlc.narg(cursor_published_at)::text, sqlc.arg(cursor_id)::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListPublishedPostsByCategory :many
+SELECT * FROM posts
+WHERE category = sqlc.arg(category) AND status = 'published'
+AND (sqlc.narg(cursor_published_at)::text IS NULL OR (published_at, id) < (sqlc.narg(cursor_published_at)::text, sqlc.arg(cursor_id)::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListPostsByUsername :many
+SELECT * FROM posts
+WHERE lower(username) = lower(sqlc.arg(username)) AND status = sqlc.arg(status)
+AND (sqlc.narg(cursor_published_at)::text IS NULL OR (published_at, id) < (sqlc.narg(cursor_published_at)::text, sqlc.arg(cursor_id)::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListDraftPostsByUsername :many
+SELECT * FROM posts
+WHERE lower(username) = lower(sqlc.arg(username)) AND status = 'draft'
+AND (sqlc.narg(cursor_ordered_at)::timestamptz IS NULL OR (ordered_at, id) < (sqlc.narg(cursor_ordered_at)::timestamptz, sqlc.arg(cursor_id)::uuid))
+ORDER BY ordered_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListCommentsByPostID :many
+SELECT * FROM comments
+WHERE post_id = sqlc.arg(post_id)
+AND (sqlc.narg(cursor_ordered_at)::timestamptz IS NULL OR (ordered_at, id) > (sqlc.narg(cursor_ordered_at)::timestamptz, sqlc.arg(cursor_id)::uuid))
+ORDER BY ordered_at, id
+LIMIT sqlc.arg(page_limit);
+
+-- name: SearchPublishedPosts :many
+SELECT id, title, username, slug, category, published_at,
+  ts_rank(post_search_vector(title, body), to_tsquery('english', sqlc.arg(query))) AS rank,
+  ts_headline('english', replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), to_tsquery('english', sqlc.arg(query)), 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet
+FROM posts
+WHERE status = 'published' AND post_search_vector(title, body) @@ to_tsquery('english', sqlc.arg(query))
+AND (sqlc.narg(category)::text IS NULL OR category = sqlc.narg(category)::text)
+AND (sqlc.narg(username)::text IS NULL OR lower(username) = lower(sqlc.narg(username)::text))
+AND (sqlc.narg(published_from)::text IS NULL OR published_at >= sqlc.narg(published_from)::text)
+AND (sqlc.narg(published_to)::text IS NULL OR published_at < sqlc.narg(published_to)::text)
+AND (sqlc.narg(cursor_rank)::real IS NULL OR (ts_rank(post_search_vector(title, body), to_tsquery('english', sqlc.arg(query))), id) < (sqlc.narg(cursor_rank)::real, sqlc.arg(cursor_id)::uuid))
+ORDER BY rank DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListPublishedPostsByTag :many
+SELECT posts.* FROM posts
+JOIN post_tags ON post_tags.post_id = posts.id
+JOIN tags ON tags.id = post_tags.tag_id
+WHERE tags.slug = sqlc.arg(slug) AND posts.status = 'published'
+AND (sqlc.narg(cursor_published_at)::text IS NULL OR (posts.published_at, posts.id) < (sqlc.narg(cursor_published_at)::text, sqlc.arg(cursor_id)::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListPublishedPostsInCategoryTree :many
+WITH RECURSIVE category_tree AS (
+  SELECT categories.id, categories.slug FROM categories WHERE categories.slug = sqlc.arg(category)
+  UNION
+  SELECT categories.id, categories.slug FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+)
+SELECT posts.* FROM posts
+WHERE posts.category IN (SELECT category_tree.slug FROM category_tree) AND posts.status = 'published'
+AND (sqlc.narg(cursor_published_at)::text IS NULL OR (posts.published_at, posts.id) < (sqlc.narg(cursor_published_at)::text, sqlc.arg(cursor_id)::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: GetPublishedPostByUsernameAndSlug :one
+SELECT * FROM posts WHERE lower(username) = lower(sqlc.arg(username)) AND slug = sqlc.arg(slug) AND status = 'published';
+
+-- name: GetPublishedPostByPreviousSlug :one
+SELECT posts.* FROM post_slug_history
+JOIN posts ON posts.id = post_slug_history.post_id
+WHERE lower(posts.username) = lower(sqlc.arg(username)) AND post_slug_history.slug = sqlc.arg(slug) AND posts.status = 'published'
+LIMIT 1;
+
+-- name: ListTakenPostSlugs :many
+SELECT posts.slug FROM posts
+WHERE lower(posts.username) = lower(sqlc.arg(username)) AND posts.id <> sqlc.arg(post_id)
+AND (posts.slug = sqlc.arg(slug) OR posts.slug LIKE sqlc.arg(slug) || '-%')
+UNION
+SELECT post_slug_history.slug FROM post_slug_history
+JOIN posts ON posts.id = post_slug_history.post_id
+WHERE lower(posts.username) = lower(sqlc.arg(username)) AND post_slug_history.post_id <> sqlc.arg(post_id)
+AND (post_slug_history.slug = sqlc.arg(slug) OR post_slug_history.slug LIKE sqlc.arg(slug) || '-%');
+
+-- name: UpdatePostTitle :one
+UPDATE posts SET title = sqlc.arg(title), slug = sqlc.arg(slug) WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: AddPostSlugHistory :exec
+INSERT INTO post_slug_history (post_id, slug) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: DeletePostSlugHistory :exec
+DELETE FROM post_slug_history WHERE post_id = $1 AND slug = $2;
@@ -0,0 +1,18 @@
+-- name: CreatePostRevision :one
+INSERT INTO post_revisions (post_id, number, title, body, restored_from)
+SELECT sqlc.arg(post_id)::uuid, coalesce(max(number), 0) + 1, sqlc.arg(title)::varchar, sqlc.arg(body)::text, sqlc.narg(restored_from)::integer
+FROM post_revisions WHERE post_id = sqlc.arg(post_id)::uuid
+RETURNING *;
+
+-- name: GetLatestPostRevision :one
+SELECT * FROM post_revisions WHERE post_id = $1 ORDER BY number DESC LIMIT 1;
+
+-- name: GetPostRevision :one
+SELECT * FROM post_revisions WHERE post_id = $1 AND number = $2;
+
+-- name: ListPostRevisions :many
+SELECT id, post_id, number, title, restored_from, created_at FROM post_revisions
+WHERE post_id = sqlc.arg(post_id)
+AND (sqlc.narg(cursor_number)::integer IS NULL OR number < sqlc.narg(cursor_number)::integer)
+ORDER BY number DESC
+LIMIT sqlc.arg(page_limit);
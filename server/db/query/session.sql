@@ -1,5 +1,5 @@
 -- name: CreateNewUserSession :one
-INSERT INTO sessions (username, refresh_token, user_agent, client_ip, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING *;
+INSERT INTO sessions (id, family_id, username, refresh_token, user_agent, client_ip, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
 -- name: GetSessionById :one
 SELECT * FROM sessions WHERE id = $1 LIMIT 1;
@@ -7,5 +7,17 @@ SELECT * FROM sessions WHERE id = $1 LIMIT 1;
 -- name: GetUserSessionsByUsername :many
 SELECT * FROM sessions WHERE username = $1 ORDER BY created_at DESC;
 
+-- name: MarkSessionAsUsed :execrows
+UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false;
+
+-- name: BlockSessionFamily :exec
+UPDATE sessions SET is_blocked = true WHERE family_id = $1;
+
+-- name: BlockUserSessions :exec
+UPDATE sessions SET is_blocked = true WHERE username = $1;
+
+-- name: BlockUserSessionsExceptFamily :exec
+UPDATE sessions SET is_blocked = true WHERE username = $1 AND family_id <> $2;
+
 -- name: DeleteSessionById :exec
-DELETE FROM sessions WHERE id = $1;
\ No newline at end of file
+DELETE FROM sessions WHERE id = $1;
@@ -0,0 +1,21 @@
+-- name: UpsertTag :one
+INSERT INTO tags (slug) VALUES ($1) ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug RETURNING *;
+
+-- name: AddPostTag :exec
+INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;
+
+-- name: DeletePostTags :exec
+DELETE FROM post_tags WHERE post_id = $1;
+
+-- name: GetTagsByPostIDs :many
+SELECT post_tags.post_id, tags.slug FROM post_tags
+JOIN tags ON tags.id = post_tags.tag_id
+WHERE post_tags.post_id = ANY(sqlc.arg(post_ids)::uuid[])
+ORDER BY tags.slug;
+
+-- name: ListTagsWithPostCounts :many
+SELECT tags.slug, COUNT(*) AS post_count FROM tags
+JOIN post_tags ON post_tags.tag_id = tags.id
+JOIN posts ON posts.id = post_tags.post_id AND posts.status = 'published'
+GROUP BY tags.slug
+ORDER BY post_count DESC, tags.slug;
@@ -0,0 +1,23 @@
+-- name: CreateTOTPRecoveryCode :exec
+INSERT INTO totp_recovery_codes (username, code_hash) VALUES ($1, $2);
+
+-- name: ConfirmUserTOTP :one
+UPDATE user_totp SET confirmed_at = now(), last_used_step = $2 WHERE username = $1 AND confirmed_at IS NULL RETURNING *;
+
+-- name: DeleteTOTPRecoveryCodesByUsername :exec
+DELETE FROM totp_recovery_codes WHERE username = $1;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserTOTPLastUsedStep :execrows
+UPDATE user_totp SET last_used_step = $2 WHERE username = $1 AND last_used_step < $2;
+
+-- name: UpsertUnconfirmedUserTOTP :one
+INSERT INTO user_totp (username, secret_ciphertext) VALUES ($1, $2)
+ON CONFLICT (username) DO UPDATE SET secret_ciphertext = EXCLUDED.secret_ciphertext, last_used_step = 0, created_at = now()
+WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
+
+-- name: UseTOTPRecoveryCode :execrows
+UPDATE totp_recovery_codes SET is_used = true WHERE username = $1 AND code_hash = $2 AND is_used = false;
@@ -2,19 +2,40 @@
 INSERT INTO users (username, password, email, first_name, last_name) VALUES ($1, $2, $3, $4, $5) RETURNING *;
 
 -- name: GetUserByUsername :one
-SELECT * FROM users WHERE username = $1;
+SELECT * FROM users WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE lower(email) = lower(sqlc.arg(email));
 
 -- name: GetUserByID :one
 SELECT * FROM users WHERE id = $1;
 
 -- name: GetPostsByUserName :many
-SELECT * FROM posts WHERE username = $1;
+SELECT * FROM posts WHERE lower(username) = lower(sqlc.arg(username));
 
 -- name: UpdateUserInterestsByUsername :exec
-UPDATE users SET interests = $1 WHERE username = $2;
+UPDATE users SET interests = sqlc.arg(interests) WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: MarkUserEmailAsVerified :one
+UPDATE users SET email_verified_at = now() WHERE lower(username) = lower(sqlc.arg(username)) RETURNING *;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET password = sqlc.arg(password) WHERE lower(username) = lower(sqlc.arg(username));
+
+-- name: RehashUserPassword :execrows
+UPDATE users SET password = sqlc.arg(new_password) WHERE lower(username) = lower(sqlc.arg(username)) AND password = sqlc.arg(old_password);
+
+-- name: UpdateUserRole :one
+UPDATE users SET role = sqlc.arg(role) WHERE lower(username) = lower(sqlc.arg(username)) RETURNING *;
+
+-- name: LockAdminUsernames :many
+SELECT username FROM users WHERE role = 'admin' FOR UPDATE;
+
+-- name: LockUserByUsername :one
+SELECT username FROM users WHERE lower(username) = lower(sqlc.arg(username)) FOR UPDATE;
 
 -- name: UpdatePostBody :one
-UPDATE posts SET body = $1, last_modified = $2 WHERE id = $3 AND username = $4 RETURNING *;
+UPDATE posts SET body = sqlc.arg(body), last_modified = sqlc.arg(last_modified), version = version + 1 WHERE id = sqlc.arg(id) AND lower(username) = lower(sqlc.arg(username)) AND version = sqlc.arg(version) RETURNING *;
 
 -- name: DeleteUserAccount :exec
-DELETE FROM users WHERE username = $1;
\ No newline at end of file
+DELETE FROM users WHERE lower(username) = lower(sqlc.arg(username));
\ No newline at end of file
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryHierarchy(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testCategoryUser", "testCategoryUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreateCategory nests a category under its parent */
+	parent, err := testStore.CreateCategory(ctx, CreateCategoryParams{Slug: "test-programming", Name: "Programming"})
+	require.NoError(t, err)
+	require.False(t, parent.ParentID.Valid)
+
+	child, err := testStore.CreateCategory(ctx, CreateCategoryParams{
+		Slug:     "test-golang",
+		Name:     "Golang",
+		ParentID: pgtype.UUID{Bytes: parent.ID, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, parent.ID, uuid.UUID(child.ParentID.Bytes))
+
+	_, err = testStore.CreateCategory(ctx, CreateCategoryParams{Slug: "test-golang", Name: "Go"})
+	require.Equal(t, util.UniqueViolation, util.ErrorCode(err))
+
+	/* Test GetCategoryAncestorIDs walks up to the top level category */
+	ancestors, err := testStore.GetCategoryAncestorIDs(ctx, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{child.ID, parent.ID}, ancestors)
+
+	/* Test ListPublishedPostsInCategoryTree includes the posts of subcategories */
+	arg := createDummyPost(t, user.ID, user.Username)
+	arg.Category = child.Slug
+	arg.Status = StatusPublished
+	arg.PublishedAt = "2024-01-01 10:00:00"
+	post, err := testStore.CreateNewPost(ctx, arg)
+	require.NoError(t, err)
+
+	posts, err := testStore.ListPublishedPostsByCategory(ctx, ListPublishedPostsByCategoryParams{Category: parent.Slug, PageLimit: 10})
+	require.NoError(t, err)
+	require.Empty(t, posts)
+
+	posts, err = testStore.ListPublishedPostsInCategoryTree(ctx, ListPublishedPostsInCategoryTreeParams{Category: parent.Slug, PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	require.Equal(t, post.ID, posts[0].ID)
+
+	/* Test posts only reference existing categories */
+	arg.Category = "test-missing-category"
+	arg.Slug = "test-post-" + uuid.NewString()
+	_, err = testStore.CreateNewPost(ctx, arg)
+	require.Equal(t, util.ForeignKeyViolation, util.ErrorCode(err))
+
+	/* Test renaming a category renames it on its posts */
+	child, err = testStore.UpdateCategory(ctx, UpdateCategoryParams{
+		ID:       child.ID,
+		Slug:     "test-go",
+		Name:     "Go",
+		ParentID: child.ParentID,
+	})
+	require.NoError(t, err)
+	post, err = testStore.GetPostById(ctx, post.ID)
+	require.NoError(t, err)
+	require.Equal(t, "test-go", post.Category)
+
+	/* Test categories with posts or subcategories cannot be deleted */
+	_, err = testStore.DeleteCategory(ctx, parent.ID)
+	require.Equal(t, util.ForeignKeyViolation, util.ErrorCode(err))
+	_, err = testStore.DeleteCategory(ctx, child.ID)
+	require.Equal(t, util.ForeignKeyViolation, util.ErrorCode(err))
+
+	/* Teardown */
+	err = testStore.DeletePostByID(ctx, post.ID)
+	require.NoError(t, err)
+	deleted, err := testStore.DeleteCategory(ctx, child.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	_, err = testStore.DeleteCategory(ctx, parent.ID)
+	require.NoError(t, err)
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
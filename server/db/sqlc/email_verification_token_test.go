@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyEmailTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testVerifyUser", "testVerifyUser@email.com"))
+	require.NoError(t, err)
+	require.False(t, user.EmailVerifiedAt.Valid)
+
+	/* Test CreateEmailVerificationToken */
+	verificationToken, err := testStore.CreateEmailVerificationToken(ctx, CreateEmailVerificationTokenParams{
+		Username:  user.Username,
+		TokenHash: "testVerificationTokenHash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, verificationToken.Username)
+
+	/* Test GetEmailVerificationTokenByHash */
+	getVerificationToken, err := testStore.GetEmailVerificationTokenByHash(ctx, "testVerificationTokenHash")
+	require.NoError(t, err)
+	require.Equal(t, verificationToken.ID, getVerificationToken.ID)
+
+	/* Test GetLatestEmailVerificationToken */
+	latestToken, err := testStore.GetLatestEmailVerificationToken(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, verificationToken.ID, latestToken.ID)
+
+	/* Test VerifyEmailTx */
+	verifiedUser, err := testStore.VerifyEmailTx(ctx, user.Username)
+	require.NoError(t, err)
+	require.True(t, verifiedUser.EmailVerifiedAt.Valid)
+
+	_, err = testStore.GetEmailVerificationTokenByHash(ctx, "testVerificationTokenHash")
+	require.Error(t, err)
+
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
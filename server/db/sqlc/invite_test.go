@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteCRUDOperations(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testInviteUser", "testInviteUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreateInvite */
+	invite, err := testStore.CreateInvite(ctx, CreateInviteParams{
+		CodeHash:        "testInviteCodeHash",
+		InviterUsername: user.Username,
+		MaxUses:         2,
+		ExpiresAt:       time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, invite.InviterUsername)
+	require.Equal(t, int32(2), invite.MaxUses)
+	require.Zero(t, invite.Uses)
+
+	expiredInvite, err := testStore.CreateInvite(ctx, CreateInviteParams{
+		CodeHash:        "testExpiredInviteCodeHash",
+		InviterUsername: user.Username,
+		MaxUses:         3,
+		ExpiresAt:       time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	/* Test GetRemainingInviteUsesByInviter ignores expired invites */
+	remainingUses, err := testStore.GetRemainingInviteUsesByInviter(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), remainingUses)
+
+	/* Test UseInvite until the invite is used up */
+	rows, err := testStore.UseInvite(ctx, "testInviteCodeHash")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testStore.UseInvite(ctx, "testInviteCodeHash")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = testStore.UseInvite(ctx, "testInviteCodeHash")
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testStore.UseInvite(ctx, "testExpiredInviteCodeHash")
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	remainingUses, err = testStore.GetRemainingInviteUsesByInviter(ctx, user.Username)
+	require.NoError(t, err)
+	require.Zero(t, remainingUses)
+
+	/* Test GetInvitesByInviter */
+	invites, err := testStore.GetInvitesByInviter(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	require.Equal(t, expiredInvite.ID, invites[0].ID)
+	require.Equal(t, int32(2), invites[1].Uses)
+
+	/* Test DeleteInvite only deletes invites of the inviter */
+	rows, err = testStore.DeleteInvite(ctx, DeleteInviteParams{
+		ID:              invite.ID,
+		InviterUsername: "someoneElse",
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testStore.DeleteInvite(ctx, DeleteInviteParams{
+		ID:              invite.ID,
+		InviterUsername: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	/* Teardown */
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestCreateInviteTxQuota(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testInviteQuotaUser", "testInviteQuotaUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test concurrent invites of the same inviter cannot exceed the quota between them */
+	const quota = 3
+	errs := make(chan error, quota*2)
+	for i := 0; i < quota*2; i++ {
+		go func(i int) {
+			_, err := testStore.CreateInviteTx(ctx, CreateInviteTxParams{
+				CreateInviteParams: CreateInviteParams{
+					CodeHash:        fmt.Sprintf("testInviteQuotaCodeHash%d", i),
+					InviterUsername: user.Username,
+					MaxUses:         1,
+					ExpiresAt:       time.Now().Add(time.Hour),
+				},
+				Quota: quota,
+			})
+			errs <- err
+		}(i)
+	}
+
+	created := 0
+	for i := 0; i < quota*2; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInviteQuotaExceeded)
+	}
+	require.Equal(t, quota, created)
+
+	remainingUses, err := testStore.GetRemainingInviteUsesByInviter(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int32(quota), remainingUses)
+
+	/* Test a zero quota is no quota */
+	_, err = testStore.CreateInviteTx(ctx, CreateInviteTxParams{
+		CreateInviteParams: CreateInviteParams{
+			CodeHash:        "testInviteNoQuotaCodeHash",
+			InviterUsername: user.Username,
+			MaxUses:         100,
+			ExpiresAt:       time.Now().Add(time.Hour),
+		},
+	})
+	require.NoError(t, err)
+
+	/* Teardown */
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
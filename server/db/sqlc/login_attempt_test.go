@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	ctx := context.Background()
+	key := "username:testLoginAttemptUser"
+	now := time.Now()
+
+	/* Test RecordLoginFailure */
+	for i := 1; i <= 3; i++ {
+		loginAttempt, err := testStore.RecordLoginFailure(ctx, RecordLoginFailureParams{
+			Key:         key,
+			FailedAt:    now,
+			ResetBefore: now.Add(-time.Minute),
+		})
+		require.NoError(t, err)
+		require.Equal(t, int32(i), loginAttempt.Failures)
+	}
+
+	/* Failures before ResetBefore are forgotten */
+	later := now.Add(time.Hour)
+	loginAttempt, err := testStore.RecordLoginFailure(ctx, RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    later,
+		ResetBefore: later.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), loginAttempt.Failures)
+
+	/* Test BlockLoginAttempt never shortens a block */
+	err = testStore.BlockLoginAttempt(ctx, BlockLoginAttemptParams{BlockedUntil: later.Add(time.Hour), Key: key})
+	require.NoError(t, err)
+	err = testStore.BlockLoginAttempt(ctx, BlockLoginAttemptParams{BlockedUntil: later, Key: key})
+	require.NoError(t, err)
+
+	loginAttempt, err = testStore.GetLoginAttempt(ctx, key)
+	require.NoError(t, err)
+	require.WithinDuration(t, later.Add(time.Hour), loginAttempt.BlockedUntil, time.Second)
+
+	/* Test DeleteLoginAttempt */
+	err = testStore.DeleteLoginAttempt(ctx, key)
+	require.NoError(t, err)
+	_, err = testStore.GetLoginAttempt(ctx, key)
+	require.Error(t, err)
+}
+
+func TestCreateAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	auditLog, err := testStore.CreateAuditLog(ctx, CreateAuditLogParams{
+		Action:   "login_lockout",
+		Username: "testAuditUser",
+		ClientIp: "192.0.2.1",
+		Details:  "testDetails",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "login_lockout", auditLog.Action)
+
+	auditLogs, err := testStore.GetAuditLogsByUsername(ctx, "testAuditUser")
+	require.NoError(t, err)
+	require.NotEmpty(t, auditLogs)
+	require.Equal(t, auditLog.ID, auditLogs[0].ID)
+}
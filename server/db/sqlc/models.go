@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Status string
@@ -54,26 +55,197 @@ func (ns NullStatus) Value() (driver.Value, error) {
 	return string(ns.Status), nil
 }
 
+type UserRole string
+
+const (
+	UserRoleReader    UserRole = "reader"
+	UserRoleAuthor    UserRole = "author"
+	UserRoleModerator UserRole = "moderator"
+	UserRoleAdmin     UserRole = "admin"
+)
+
+func (e *UserRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = UserRole(s)
+	case string:
+		*e = UserRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for UserRole: %T", src)
+	}
+	return nil
+}
+
+type NullUserRole struct {
+	UserRole UserRole `json:"user_role"`
+	Valid    bool     `json:"valid"` // Valid is true if UserRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullUserRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.UserRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.UserRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullUserRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.UserRole), nil
+}
+
+type AuditLog struct {
+	ID     uuid.UUID `json:"id"`
+	Action string    `json:"action"`
+	// Not a foreign key so that entries outlive the account they refer to
+	Username  string    `json:"username"`
+	ClientIp  string    `json:"client_ip"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Category struct {
+	ID uuid.UUID `json:"id"`
+	// Lowercase letters and digits with words joined by hyphens, posts reference categories by slug
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Null for top level categories
+	ParentID  pgtype.UUID `json:"parent_id"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
 type Comment struct {
 	ID       uuid.UUID `json:"id"`
 	Username string    `json:"username"`
 	PostID   uuid.UUID `json:"post_id"`
 	// Content of the comment
-	Body      string `json:"body"`
-	CreatedAt string `json:"created_at"`
+	Body      string    `json:"body"`
+	CreatedAt string    `json:"created_at"`
+	OrderedAt time.Time `json:"ordered_at"`
+}
+
+type EmailVerificationToken struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// SHA-256 hash of the token emailed to the user
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Invite struct {
+	ID uuid.UUID `json:"id"`
+	// SHA-256 hash of the invite code shown to the inviter once at creation
+	CodeHash        string `json:"code_hash"`
+	InviterUsername string `json:"inviter_username"`
+	// Number of accounts that can be registered with the code
+	MaxUses   int32     `json:"max_uses"`
+	Uses      int32     `json:"uses"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	// Username or client IP the failures are counted for
+	Key           string    `json:"key"`
+	Failures      int32     `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+	BlockedUntil  time.Time `json:"blocked_until"`
+}
+
+type MfaChallenge struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// SHA-256 hash of the challenge token returned by LoginUser
+	TokenHash      string    `json:"token_hash"`
+	IsUsed         bool      `json:"is_used"`
+	FailedAttempts int32     `json:"failed_attempts"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type OidcLoginState struct {
+	ID uuid.UUID `json:"id"`
+	// SHA-256 hash of the state parameter sent to the provider
+	StateHash    string `json:"state_hash"`
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	// Set when a signed in user links the identity to their account
+	LinkUsername pgtype.Text `json:"link_username"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// SHA-256 hash of the token emailed to the user
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PersonalAccessToken struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	// SHA-256 hash of the token shown to the user once at creation
+	TokenHash string   `json:"token_hash"`
+	Scopes    []string `json:"scopes"`
+	// NULL for tokens that do not expire
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `json:"last_used_at"`
+	CreatedAt  time.Time          `json:"created_at"`
 }
 
 type Post struct {
 	ID    uuid.UUID `json:"id"`
 	Title string    `json:"title"`
 	// Content of the blog post
-	Body         string `json:"body"`
-	Username     string `json:"username"`
-	Status       Status `json:"status"`
-	Category     string `json:"category"`
-	CreatedAt    string `json:"created_at"`
-	PublishedAt  string `json:"published_at"`
-	LastModified string `json:"last_modified"`
+	Body         string    `json:"body"`
+	Username     string    `json:"username"`
+	Status       Status    `json:"status"`
+	Category     string    `json:"category"`
+	CreatedAt    string    `json:"created_at"`
+	PublishedAt  string    `json:"published_at"`
+	LastModified string    `json:"last_modified"`
+	OrderedAt    time.Time `json:"ordered_at"`
+	// Unique per author, made from the title and used in the permalink of the post
+	Slug string `json:"slug"`
+	// Goes up by one on every update, an update must name the version it changes
+	Version int32 `json:"version"`
+}
+
+type PostRevision struct {
+	ID     uuid.UUID `json:"id"`
+	PostID uuid.UUID `json:"post_id"`
+	// Counts the revisions of a post from 1
+	Number int32  `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	// Number of the revision this revision restored
+	RestoredFrom pgtype.Int4 `json:"restored_from"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+type PostSlugHistory struct {
+	PostID uuid.UUID `json:"post_id"`
+	// A previous slug of the post, its permalink redirects to the current one
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PostTag struct {
+	PostID uuid.UUID `json:"post_id"`
+	TagID  uuid.UUID `json:"tag_id"`
 }
 
 type Session struct {
@@ -85,6 +257,26 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// ID of the login session every rotated refresh token descends from
+	FamilyID uuid.UUID `json:"family_id"`
+	// Set once the refresh token has been exchanged for a new one
+	IsUsed bool `json:"is_used"`
+}
+
+type Tag struct {
+	ID uuid.UUID `json:"id"`
+	// Lowercase letters and digits with words joined by hyphens
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TotpRecoveryCode struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// SHA-256 hash of the normalized recovery code
+	CodeHash  string    `json:"code_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type User struct {
@@ -96,4 +288,29 @@ type User struct {
 	LastName  string    `json:"last_name"`
 	Interests []string  `json:"interests"`
 	CreatedAt string    `json:"created_at"`
+	Role      UserRole  `json:"role"`
+	// NULL until the user follows the verification link
+	EmailVerifiedAt pgtype.Timestamptz `json:"email_verified_at"`
+}
+
+type UserIdentity struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// Name of the OpenID Connect provider in the config
+	Provider string `json:"provider"`
+	// Subject identifier the provider issued for the account
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTotp struct {
+	Username string `json:"username"`
+	// AES-256-GCM encrypted TOTP secret
+	SecretCiphertext []byte `json:"secret_ciphertext"`
+	// NULL until the user confirms enrollment with a first code
+	ConfirmedAt pgtype.Timestamptz `json:"confirmed_at"`
+	// Time step of the last accepted code, older or equal steps are rejected
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
 }
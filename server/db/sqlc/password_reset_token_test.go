@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetPasswordTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testResetUser", "testResetUser@email.com"))
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+	_, err = testStore.CreateNewUserSession(ctx, createDummySession(sessionID, sessionID, user.Username))
+	require.NoError(t, err)
+	_, err = testStore.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenParams{
+		Username:  user.Username,
+		Name:      "ci",
+		TokenHash: "testResetPersonalAccessTokenHash",
+		Scopes:    []string{"read"},
+	})
+	require.NoError(t, err)
+
+	/* Test CreatePasswordResetToken */
+	resetToken, err := testStore.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: "testTokenHash",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, resetToken.Username)
+	require.False(t, resetToken.IsUsed)
+
+	/* Test GetPasswordResetTokenByHash */
+	getResetToken, err := testStore.GetPasswordResetTokenByHash(ctx, "testTokenHash")
+	require.NoError(t, err)
+	require.Equal(t, resetToken.ID, getResetToken.ID)
+
+	/* Test GetLatestPasswordResetToken */
+	latestToken, err := testStore.GetLatestPasswordResetToken(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, resetToken.ID, latestToken.ID)
+
+	/* Test ResetPasswordTx */
+	err = testStore.ResetPasswordTx(ctx, ResetPasswordTxParams{
+		TokenID:        resetToken.ID,
+		Username:       user.Username,
+		HashedPassword: "newHashedPassword",
+	})
+	require.NoError(t, err)
+
+	updatedUser, err := testStore.GetUserByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, "newHashedPassword", updatedUser.Password)
+
+	session, err := testStore.GetSessionById(ctx, sessionID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	/* A new password revokes the personal access tokens too */
+	personalAccessTokens, err := testStore.GetPersonalAccessTokensByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Empty(t, personalAccessTokens)
+
+	/* Test ResetPasswordTx with a used token */
+	err = testStore.ResetPasswordTx(ctx, ResetPasswordTxParams{
+		TokenID:        resetToken.ID,
+		Username:       user.Username,
+		HashedPassword: "anotherHashedPassword",
+	})
+	require.ErrorIs(t, err, ErrPasswordResetTokenUsed)
+
+	/* Tokens are deleted together with the user */
+	err = testStore.DeleteSessionById(ctx, sessionID)
+	require.NoError(t, err)
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+	_, err = testStore.GetPasswordResetTokenByHash(ctx, "testTokenHash")
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalAccessTokenCRUDOperations(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testTokenUser", "testTokenUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreatePersonalAccessToken */
+	personalAccessToken, err := testStore.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenParams{
+		Username:  user.Username,
+		Name:      "ci",
+		TokenHash: "testPersonalAccessTokenHash",
+		Scopes:    []string{"read", "posts:write"},
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, personalAccessToken.Username)
+	require.Equal(t, []string{"read", "posts:write"}, personalAccessToken.Scopes)
+	require.False(t, personalAccessToken.LastUsedAt.Valid)
+
+	/* Test GetPersonalAccessTokenByHash includes the role of the owner */
+	getPersonalAccessToken, err := testStore.GetPersonalAccessTokenByHash(ctx, "testPersonalAccessTokenHash")
+	require.NoError(t, err)
+	require.Equal(t, personalAccessToken.ID, getPersonalAccessToken.ID)
+	require.Equal(t, user.Role, getPersonalAccessToken.Role)
+
+	/* Test UpdatePersonalAccessTokenLastUsedAt */
+	err = testStore.UpdatePersonalAccessTokenLastUsedAt(ctx, personalAccessToken.ID)
+	require.NoError(t, err)
+
+	/* Test GetPersonalAccessTokensByUsername */
+	personalAccessTokens, err := testStore.GetPersonalAccessTokensByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, personalAccessTokens, 1)
+	require.True(t, personalAccessTokens[0].LastUsedAt.Valid)
+
+	/* Test DeletePersonalAccessToken only deletes tokens of the user */
+	rows, err := testStore.DeletePersonalAccessToken(ctx, DeletePersonalAccessTokenParams{
+		ID:       personalAccessToken.ID,
+		Username: "someoneElse",
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testStore.DeletePersonalAccessToken(ctx, DeletePersonalAccessTokenParams{
+		ID:       personalAccessToken.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	_, err = testStore.GetPersonalAccessTokenByHash(ctx, "testPersonalAccessTokenHash")
+	require.Error(t, err)
+
+	rows, err = testStore.DeletePersonalAccessToken(ctx, DeletePersonalAccessTokenParams{
+		ID:       uuid.New(),
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	/* Teardown */
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
@@ -9,10 +9,25 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addPostSlugHistory = `-- name: AddPostSlugHistory :exec
+INSERT INTO post_slug_history (post_id, slug) VALUES ($1, $2) ON CONFLICT DO NOTHING
+`
+
+type AddPostSlugHistoryParams struct {
+	PostID uuid.UUID `json:"post_id"`
+	Slug   string    `json:"slug"`
+}
+
+func (q *Queries) AddPostSlugHistory(ctx context.Context, arg AddPostSlugHistoryParams) error {
+	_, err := q.db.Exec(ctx, addPostSlugHistory, arg.PostID, arg.Slug)
+	return err
+}
+
 const createNewComment = `-- name: CreateNewComment :one
-INSERT INTO comments (username, post_id, body) VALUES ($1, $2, $3) RETURNING id, username, post_id, body, created_at
+INSERT INTO comments (username, post_id, body) VALUES ($1, $2, $3) RETURNING id, username, post_id, body, created_at, ordered_at
 `
 
 type CreateNewCommentParams struct {
@@ -30,12 +45,13 @@ func (q *Queries) CreateNewComment(ctx context.Context, arg CreateNewCommentPara
 		&i.PostID,
 		&i.Body,
 		&i.CreatedAt,
+		&i.OrderedAt,
 	)
 	return i, err
 }
 
 const createNewPost = `-- name: CreateNewPost :one
-INSERT INTO posts (title, body, username, status, category, published_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, title, body, username, status, category, created_at, published_at, last_modified
+INSERT INTO posts (title, body, username, status, category, published_at, slug) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version
 `
 
 type CreateNewPostParams struct {
@@ -45,6 +61,7 @@ type CreateNewPostParams struct {
 	Status      Status `json:"status"`
 	Category    string `json:"category"`
 	PublishedAt string `json:"published_at"`
+	Slug        string `json:"slug"`
 }
 
 func (q *Queries) CreateNewPost(ctx context.Context, arg CreateNewPostParams) (Post, error) {
@@ -55,6 +72,7 @@ func (q *Queries) CreateNewPost(ctx context.Context, arg CreateNewPostParams) (P
 		arg.Status,
 		arg.Category,
 		arg.PublishedAt,
+		arg.Slug,
 	)
 	var i Post
 	err := row.Scan(
@@ -67,6 +85,9 @@ func (q *Queries) CreateNewPost(ctx context.Context, arg CreateNewPostParams) (P
 		&i.CreatedAt,
 		&i.PublishedAt,
 		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
 	)
 	return i, err
 }
@@ -80,6 +101,15 @@ func (q *Queries) DeleteCommentByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const deleteCommentsByPostID = `-- name: DeleteCommentsByPostID :exec
+DELETE FROM comments WHERE post_id = $1
+`
+
+func (q *Queries) DeleteCommentsByPostID(ctx context.Context, postID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCommentsByPostID, postID)
+	return err
+}
+
 const deletePostByID = `-- name: DeletePostByID :exec
 DELETE FROM posts WHERE id = $1
 `
@@ -89,41 +119,58 @@ func (q *Queries) DeletePostByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllPosts = `-- name: GetAllPosts :many
-SELECT id, title, username, body, status, category, created_at, published_at, last_modified FROM posts
+const deletePostSlugHistory = `-- name: DeletePostSlugHistory :exec
+DELETE FROM post_slug_history WHERE post_id = $1 AND slug = $2
 `
 
-type GetAllPostsRow struct {
-	ID           uuid.UUID `json:"id"`
-	Title        string    `json:"title"`
-	Username     string    `json:"username"`
-	Body         string    `json:"body"`
-	Status       Status    `json:"status"`
-	Category     string    `json:"category"`
-	CreatedAt    string    `json:"created_at"`
-	PublishedAt  string    `json:"published_at"`
-	LastModified string    `json:"last_modified"`
+type DeletePostSlugHistoryParams struct {
+	PostID uuid.UUID `json:"post_id"`
+	Slug   string    `json:"slug"`
+}
+
+func (q *Queries) DeletePostSlugHistory(ctx context.Context, arg DeletePostSlugHistoryParams) error {
+	_, err := q.db.Exec(ctx, deletePostSlugHistory, arg.PostID, arg.Slug)
+	return err
 }
 
-func (q *Queries) GetAllPosts(ctx context.Context) ([]GetAllPostsRow, error) {
-	rows, err := q.db.Query(ctx, getAllPosts)
+const getCommentByID = `-- name: GetCommentByID :one
+SELECT id, username, post_id, body, created_at, ordered_at FROM comments WHERE id = $1
+`
+
+func (q *Queries) GetCommentByID(ctx context.Context, id uuid.UUID) (Comment, error) {
+	row := q.db.QueryRow(ctx, getCommentByID, id)
+	var i Comment
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.PostID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.OrderedAt,
+	)
+	return i, err
+}
+
+const getCommentsByUserName = `-- name: GetCommentsByUserName :many
+SELECT id, username, post_id, body, created_at, ordered_at FROM comments WHERE lower(username) = lower($1)
+`
+
+func (q *Queries) GetCommentsByUserName(ctx context.Context, username string) ([]Comment, error) {
+	rows, err := q.db.Query(ctx, getCommentsByUserName, username)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []GetAllPostsRow{}
+	items := []Comment{}
 	for rows.Next() {
-		var i GetAllPostsRow
+		var i Comment
 		if err := rows.Scan(
 			&i.ID,
-			&i.Title,
 			&i.Username,
+			&i.PostID,
 			&i.Body,
-			&i.Status,
-			&i.Category,
 			&i.CreatedAt,
-			&i.PublishedAt,
-			&i.LastModified,
+			&i.OrderedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -135,29 +182,113 @@ func (q *Queries) GetAllPosts(ctx context.Context) ([]GetAllPostsRow, error) {
 	return items, nil
 }
 
-const getCommentByID = `-- name: GetCommentByID :one
-SELECT id, username, post_id, body, created_at FROM comments WHERE id = $1
+const getPostById = `-- name: GetPostById :one
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts WHERE id = $1
 `
 
-func (q *Queries) GetCommentByID(ctx context.Context, id uuid.UUID) (Comment, error) {
-	row := q.db.QueryRow(ctx, getCommentByID, id)
-	var i Comment
+func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRow(ctx, getPostById, id)
+	var i Post
 	err := row.Scan(
 		&i.ID,
+		&i.Title,
+		&i.Body,
 		&i.Username,
-		&i.PostID,
+		&i.Status,
+		&i.Category,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
+	)
+	return i, err
+}
+
+const getPublishedPostByPreviousSlug = `-- name: GetPublishedPostByPreviousSlug :one
+SELECT posts.id, posts.title, posts.body, posts.username, posts.status, posts.category, posts.created_at, posts.published_at, posts.last_modified, posts.ordered_at, posts.slug, posts.version FROM post_slug_history
+JOIN posts ON posts.id = post_slug_history.post_id
+WHERE lower(posts.username) = lower($1) AND post_slug_history.slug = $2 AND posts.status = 'published'
+LIMIT 1
+`
+
+type GetPublishedPostByPreviousSlugParams struct {
+	Username string `json:"username"`
+	Slug     string `json:"slug"`
+}
+
+func (q *Queries) GetPublishedPostByPreviousSlug(ctx context.Context, arg GetPublishedPostByPreviousSlugParams) (Post, error) {
+	row := q.db.QueryRow(ctx, getPublishedPostByPreviousSlug, arg.Username, arg.Slug)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
 		&i.Body,
+		&i.Username,
+		&i.Status,
+		&i.Category,
 		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
 	)
 	return i, err
 }
 
-const getCommentsByPostID = `-- name: GetCommentsByPostID :many
-SELECT id, username, post_id, body, created_at FROM comments WHERE post_id = $1
+const getPublishedPostByUsernameAndSlug = `-- name: GetPublishedPostByUsernameAndSlug :one
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts WHERE lower(username) = lower($1) AND slug = $2 AND status = 'published'
 `
 
-func (q *Queries) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]Comment, error) {
-	rows, err := q.db.Query(ctx, getCommentsByPostID, postID)
+type GetPublishedPostByUsernameAndSlugParams struct {
+	Username string `json:"username"`
+	Slug     string `json:"slug"`
+}
+
+func (q *Queries) GetPublishedPostByUsernameAndSlug(ctx context.Context, arg GetPublishedPostByUsernameAndSlugParams) (Post, error) {
+	row := q.db.QueryRow(ctx, getPublishedPostByUsernameAndSlug, arg.Username, arg.Slug)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Body,
+		&i.Username,
+		&i.Status,
+		&i.Category,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
+	)
+	return i, err
+}
+
+const listCommentsByPostID = `-- name: ListCommentsByPostID :many
+SELECT id, username, post_id, body, created_at, ordered_at FROM comments
+WHERE post_id = $1
+AND ($2::timestamptz IS NULL OR (ordered_at, id) > ($2::timestamptz, $3::uuid))
+ORDER BY ordered_at, id
+LIMIT $4
+`
+
+type ListCommentsByPostIDParams struct {
+	PostID          uuid.UUID          `json:"post_id"`
+	CursorOrderedAt pgtype.Timestamptz `json:"cursor_ordered_at"`
+	CursorID        uuid.UUID          `json:"cursor_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+func (q *Queries) ListCommentsByPostID(ctx context.Context, arg ListCommentsByPostIDParams) ([]Comment, error) {
+	rows, err := q.db.Query(ctx, listCommentsByPostID,
+		arg.PostID,
+		arg.CursorOrderedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +302,7 @@ func (q *Queries) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]
 			&i.PostID,
 			&i.Body,
 			&i.CreatedAt,
+			&i.OrderedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -182,25 +314,103 @@ func (q *Queries) GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]
 	return items, nil
 }
 
-const getCommentsByUserName = `-- name: GetCommentsByUserName :many
-SELECT id, username, post_id, body, created_at FROM comments WHERE username = $1
+const listDraftPostsByUsername = `-- name: ListDraftPostsByUsername :many
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts
+WHERE lower(username) = lower($1) AND status = 'draft'
+AND ($2::timestamptz IS NULL OR (ordered_at, id) < ($2::timestamptz, $3::uuid))
+ORDER BY ordered_at DESC, id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetCommentsByUserName(ctx context.Context, username string) ([]Comment, error) {
-	rows, err := q.db.Query(ctx, getCommentsByUserName, username)
+type ListDraftPostsByUsernameParams struct {
+	Username        string             `json:"username"`
+	CursorOrderedAt pgtype.Timestamptz `json:"cursor_ordered_at"`
+	CursorID        uuid.UUID          `json:"cursor_id"`
+	PageLimit       int32              `json:"page_limit"`
+}
+
+func (q *Queries) ListDraftPostsByUsername(ctx context.Context, arg ListDraftPostsByUsernameParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listDraftPostsByUsername,
+		arg.Username,
+		arg.CursorOrderedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Comment{}
+	items := []Post{}
 	for rows.Next() {
-		var i Comment
+		var i Post
 		if err := rows.Scan(
 			&i.ID,
+			&i.Title,
+			&i.Body,
 			&i.Username,
-			&i.PostID,
+			&i.Status,
+			&i.Category,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostsByUsername = `-- name: ListPostsByUsername :many
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts
+WHERE lower(username) = lower($1) AND status = $2
+AND ($3::text IS NULL OR (published_at, id) < ($3::text, $4::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT $5
+`
+
+type ListPostsByUsernameParams struct {
+	Username          string      `json:"username"`
+	Status            Status      `json:"status"`
+	CursorPublishedAt pgtype.Text `json:"cursor_published_at"`
+	CursorID          uuid.UUID   `json:"cursor_id"`
+	PageLimit         int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListPostsByUsername(ctx context.Context, arg ListPostsByUsernameParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPostsByUsername,
+		arg.Username,
+		arg.Status,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
 			&i.Body,
+			&i.Username,
+			&i.Status,
+			&i.Category,
 			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -212,33 +422,130 @@ func (q *Queries) GetCommentsByUserName(ctx context.Context, username string) ([
 	return items, nil
 }
 
-const getPostById = `-- name: GetPostById :one
-SELECT id, title, body, username, status, category, created_at, published_at, last_modified FROM posts WHERE id = $1
+const listPublishedPosts = `-- name: ListPublishedPosts :many
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts
+WHERE status = 'published'
+AND ($1::text IS NULL OR (published_at, id) < ($1::text, $2::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT $3
 `
 
-func (q *Queries) GetPostById(ctx context.Context, id uuid.UUID) (Post, error) {
-	row := q.db.QueryRow(ctx, getPostById, id)
-	var i Post
-	err := row.Scan(
-		&i.ID,
-		&i.Title,
-		&i.Body,
-		&i.Username,
-		&i.Status,
-		&i.Category,
-		&i.CreatedAt,
-		&i.PublishedAt,
-		&i.LastModified,
+type ListPublishedPostsParams struct {
+	CursorPublishedAt pgtype.Text `json:"cursor_published_at"`
+	CursorID          uuid.UUID   `json:"cursor_id"`
+	PageLimit         int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListPublishedPosts(ctx context.Context, arg ListPublishedPostsParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPublishedPosts, arg.CursorPublishedAt, arg.CursorID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Body,
+			&i.Username,
+			&i.Status,
+			&i.Category,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublishedPostsByCategory = `-- name: ListPublishedPostsByCategory :many
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts
+WHERE category = $1 AND status = 'published'
+AND ($2::text IS NULL OR (published_at, id) < ($2::text, $3::uuid))
+ORDER BY published_at DESC, id DESC
+LIMIT $4
+`
+
+type ListPublishedPostsByCategoryParams struct {
+	Category          string      `json:"category"`
+	CursorPublishedAt pgtype.Text `json:"cursor_published_at"`
+	CursorID          uuid.UUID   `json:"cursor_id"`
+	PageLimit         int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListPublishedPostsByCategory(ctx context.Context, arg ListPublishedPostsByCategoryParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPublishedPostsByCategory,
+		arg.Category,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageLimit,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Body,
+			&i.Username,
+			&i.Status,
+			&i.Category,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getPostsByCategory = `-- name: GetPostsByCategory :many
-SELECT id, title, body, username, status, category, created_at, published_at, last_modified FROM posts WHERE category = $1
+const listPublishedPostsByTag = `-- name: ListPublishedPostsByTag :many
+SELECT posts.id, posts.title, posts.body, posts.username, posts.status, posts.category, posts.created_at, posts.published_at, posts.last_modified, posts.ordered_at, posts.slug, posts.version FROM posts
+JOIN post_tags ON post_tags.post_id = posts.id
+JOIN tags ON tags.id = post_tags.tag_id
+WHERE tags.slug = $1 AND posts.status = 'published'
+AND ($2::text IS NULL OR (posts.published_at, posts.id) < ($2::text, $3::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetPostsByCategory(ctx context.Context, category string) ([]Post, error) {
-	rows, err := q.db.Query(ctx, getPostsByCategory, category)
+type ListPublishedPostsByTagParams struct {
+	Slug              string      `json:"slug"`
+	CursorPublishedAt pgtype.Text `json:"cursor_published_at"`
+	CursorID          uuid.UUID   `json:"cursor_id"`
+	PageLimit         int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListPublishedPostsByTag(ctx context.Context, arg ListPublishedPostsByTagParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPublishedPostsByTag,
+		arg.Slug,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -256,6 +563,179 @@ func (q *Queries) GetPostsByCategory(ctx context.Context, category string) ([]Po
 			&i.CreatedAt,
 			&i.PublishedAt,
 			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPublishedPostsInCategoryTree = `-- name: ListPublishedPostsInCategoryTree :many
+WITH RECURSIVE category_tree AS (
+  SELECT categories.id, categories.slug FROM categories WHERE categories.slug = $1
+  UNION
+  SELECT categories.id, categories.slug FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+)
+SELECT posts.id, posts.title, posts.body, posts.username, posts.status, posts.category, posts.created_at, posts.published_at, posts.last_modified, posts.ordered_at, posts.slug, posts.version FROM posts
+WHERE posts.category IN (SELECT category_tree.slug FROM category_tree) AND posts.status = 'published'
+AND ($2::text IS NULL OR (posts.published_at, posts.id) < ($2::text, $3::uuid))
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $4
+`
+
+type ListPublishedPostsInCategoryTreeParams struct {
+	Category          string      `json:"category"`
+	CursorPublishedAt pgtype.Text `json:"cursor_published_at"`
+	CursorID          uuid.UUID   `json:"cursor_id"`
+	PageLimit         int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListPublishedPostsInCategoryTree(ctx context.Context, arg ListPublishedPostsInCategoryTreeParams) ([]Post, error) {
+	rows, err := q.db.Query(ctx, listPublishedPostsInCategoryTree,
+		arg.Category,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Body,
+			&i.Username,
+			&i.Status,
+			&i.Category,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTakenPostSlugs = `-- name: ListTakenPostSlugs :many
+SELECT posts.slug FROM posts
+WHERE lower(posts.username) = lower($1) AND posts.id <> $2
+AND (posts.slug = $3 OR posts.slug LIKE $3 || '-%')
+UNION
+SELECT post_slug_history.slug FROM post_slug_history
+JOIN posts ON posts.id = post_slug_history.post_id
+WHERE lower(posts.username) = lower($1) AND post_slug_history.post_id <> $2
+AND (post_slug_history.slug = $3 OR post_slug_history.slug LIKE $3 || '-%')
+`
+
+type ListTakenPostSlugsParams struct {
+	Username string    `json:"username"`
+	PostID   uuid.UUID `json:"post_id"`
+	Slug     string    `json:"slug"`
+}
+
+func (q *Queries) ListTakenPostSlugs(ctx context.Context, arg ListTakenPostSlugsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, listTakenPostSlugs, arg.Username, arg.PostID, arg.Slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		items = append(items, slug)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPublishedPosts = `-- name: SearchPublishedPosts :many
+SELECT id, title, username, slug, category, published_at,
+  ts_rank(post_search_vector(title, body), to_tsquery('english', $1)) AS rank,
+  ts_headline('english', replace(replace(replace(body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), to_tsquery('english', $1), 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10') AS snippet
+FROM posts
+WHERE status = 'published' AND post_search_vector(title, body) @@ to_tsquery('english', $1)
+AND ($2::text IS NULL OR category = $2::text)
+AND ($3::text IS NULL OR lower(username) = lower($3::text))
+AND ($4::text IS NULL OR published_at >= $4::text)
+AND ($5::text IS NULL OR published_at < $5::text)
+AND ($6::real IS NULL OR (ts_rank(post_search_vector(title, body), to_tsquery('english', $1)), id) < ($6::real, $7::uuid))
+ORDER BY rank DESC, id DESC
+LIMIT $8
+`
+
+type SearchPublishedPostsParams struct {
+	Query         string        `json:"query"`
+	Category      pgtype.Text   `json:"category"`
+	Username      pgtype.Text   `json:"username"`
+	PublishedFrom pgtype.Text   `json:"published_from"`
+	PublishedTo   pgtype.Text   `json:"published_to"`
+	CursorRank    pgtype.Float4 `json:"cursor_rank"`
+	CursorID      uuid.UUID     `json:"cursor_id"`
+	PageLimit     int32         `json:"page_limit"`
+}
+
+type SearchPublishedPostsRow struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Username    string    `json:"username"`
+	Slug        string    `json:"slug"`
+	Category    string    `json:"category"`
+	PublishedAt string    `json:"published_at"`
+	Rank        float32   `json:"rank"`
+	Snippet     string    `json:"snippet"`
+}
+
+func (q *Queries) SearchPublishedPosts(ctx context.Context, arg SearchPublishedPostsParams) ([]SearchPublishedPostsRow, error) {
+	rows, err := q.db.Query(ctx, searchPublishedPosts,
+		arg.Query,
+		arg.Category,
+		arg.Username,
+		arg.PublishedFrom,
+		arg.PublishedTo,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchPublishedPostsRow{}
+	for rows.Next() {
+		var i SearchPublishedPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Username,
+			&i.Slug,
+			&i.Category,
+			&i.PublishedAt,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -268,7 +748,7 @@ func (q *Queries) GetPostsByCategory(ctx context.Context, category string) ([]Po
 }
 
 const updatePostStatus = `-- name: UpdatePostStatus :one
-UPDATE posts SET status = $1, published_at = $2 WHERE id = $3 AND username = $4 RETURNING id, title, body, username, status, category, created_at, published_at, last_modified
+UPDATE posts SET status = $1, published_at = $2, version = version + 1 WHERE id = $3 AND lower(username) = lower($4) AND version = $5 RETURNING id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version
 `
 
 type UpdatePostStatusParams struct {
@@ -276,6 +756,7 @@ type UpdatePostStatusParams struct {
 	PublishedAt string    `json:"published_at"`
 	ID          uuid.UUID `json:"id"`
 	Username    string    `json:"username"`
+	Version     int32     `json:"version"`
 }
 
 func (q *Queries) UpdatePostStatus(ctx context.Context, arg UpdatePostStatusParams) (Post, error) {
@@ -284,6 +765,7 @@ func (q *Queries) UpdatePostStatus(ctx context.Context, arg UpdatePostStatusPara
 		arg.PublishedAt,
 		arg.ID,
 		arg.Username,
+		arg.Version,
 	)
 	var i Post
 	err := row.Scan(
@@ -296,6 +778,39 @@ func (q *Queries) UpdatePostStatus(ctx context.Context, arg UpdatePostStatusPara
 		&i.CreatedAt,
 		&i.PublishedAt,
 		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
+	)
+	return i, err
+}
+
+const updatePostTitle = `-- name: UpdatePostTitle :one
+UPDATE posts SET title = $1, slug = $2 WHERE id = $3 RETURNING id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version
+`
+
+type UpdatePostTitleParams struct {
+	Title string    `json:"title"`
+	Slug  string    `json:"slug"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdatePostTitle(ctx context.Context, arg UpdatePostTitleParams) (Post, error) {
+	row := q.db.QueryRow(ctx, updatePostTitle, arg.Title, arg.Slug, arg.ID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Body,
+		&i.Username,
+		&i.Status,
+		&i.Category,
+		&i.CreatedAt,
+		&i.PublishedAt,
+		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
 	)
 	return i, err
 }
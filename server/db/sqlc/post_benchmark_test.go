@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	benchmarkPostCount     = 5000
+	benchmarkCategoryCount = 10
+	benchmarkPageLimit     = 20
+)
+
+/* queryCounter is a pgx tracer that counts the queries sent to the database */
+type queryCounter struct {
+	queries atomic.Int64
+}
+
+func (counter *queryCounter) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	counter.queries.Add(1)
+	return ctx
+}
+
+func (counter *queryCounter) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+/* newBenchmarkStore connects a store whose queries are counted */
+func newBenchmarkStore(b *testing.B) (Store, *pgxpool.Pool, *queryCounter) {
+	config, err := util.LoadConfig("../../..")
+	require.NoError(b, err)
+
+	poolConfig, err := pgxpool.ParseConfig(config.DB_URL)
+	require.NoError(b, err)
+	counter := &queryCounter{}
+	poolConfig.ConnConfig.Tracer = counter
+
+	connPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	require.NoError(b, err)
+	b.Cleanup(connPool.Close)
+	return NewStore(connPool), connPool, counter
+}
+
+/*
+seedBenchmarkPosts creates an author with benchmarkPostCount posts spread over benchmarkCategoryCount categories,
+every other post is a draft. The posts and the author are deleted when the benchmark ends.
+*/
+func seedBenchmarkPosts(b *testing.B, store Store, connPool *pgxpool.Pool) User {
+	ctx := context.Background()
+	user, err := store.CreateNewUser(ctx, createDummyUser("benchPostUser", "benchPostUser@email.com"))
+	require.NoError(b, err)
+
+	categories := []Category{}
+	for i := 0; i < benchmarkCategoryCount; i++ {
+		slug := fmt.Sprintf("bench-category-%d", i)
+		category, err := store.CreateCategory(ctx, CreateCategoryParams{Slug: slug, Name: slug})
+		require.NoError(b, err)
+		categories = append(categories, category)
+	}
+
+	for i := 0; i < benchmarkPostCount; i++ {
+		arg := CreateNewPostParams{
+			Title:    fmt.Sprintf("Benchmark Post %d", i),
+			Body:     "This is a benchmark post",
+			Username: user.Username,
+			Status:   StatusDraft,
+			Category: categories[i%benchmarkCategoryCount].Slug,
+			Slug:     fmt.Sprintf("benchmark-post-%d", i),
+		}
+		if i%2 == 0 {
+			arg.Status = StatusPublished
+			arg.PublishedAt = fmt.Sprintf("2024-01-01 %02d:%02d:%02d", i/3600%24, i/60%60, i%60)
+		}
+		_, err := store.CreateNewPost(ctx, arg)
+		require.NoError(b, err)
+	}
+
+	// let the planner see the seeded rows before the queries are measured
+	_, err = connPool.Exec(ctx, "ANALYZE posts")
+	require.NoError(b, err)
+
+	b.Cleanup(func() {
+		posts, err := store.GetPostsByUserName(ctx, user.Username)
+		require.NoError(b, err)
+		for _, post := range posts {
+			require.NoError(b, store.DeletePostByID(ctx, post.ID))
+		}
+		for _, category := range categories {
+			_, err := store.DeleteCategory(ctx, category.ID)
+			require.NoError(b, err)
+		}
+		require.NoError(b, store.DeleteUserAccount(ctx, user.Username))
+	})
+	return user
+}
+
+/* runCountedBenchmark runs fetch b.N times and reports the queries and rows of every run */
+func runCountedBenchmark(b *testing.B, counter *queryCounter, fetch func() (int, error)) {
+	rows := 0
+	counter.queries.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n, err := fetch()
+		if err != nil {
+			b.Fatal(err)
+		}
+		rows += n
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(counter.queries.Load())/float64(b.N), "queries/op")
+	b.ReportMetric(float64(rows)/float64(b.N), "rows/op")
+}
+
+/*
+Benchmark the first page of published posts of a category, fetching every post of the category and
+dropping the drafts in Go against filtering and limiting in SQL
+*/
+func BenchmarkPublishedPostsByCategory(b *testing.B) {
+	ctx := context.Background()
+	store, connPool, counter := newBenchmarkStore(b)
+	seedBenchmarkPosts(b, store, connPool)
+
+	b.Run("FilterInGo", func(b *testing.B) {
+		runCountedBenchmark(b, counter, func() (int, error) {
+			// the unbounded query is gone from the store, it is kept here as the baseline
+			rows, err := connPool.Query(ctx, "SELECT * FROM posts WHERE category = $1", "bench-category-0")
+			if err != nil {
+				return 0, err
+			}
+			posts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[Post])
+			if err != nil {
+				return 0, err
+			}
+			published := []Post{}
+			for _, post := range posts {
+				if post.Status == StatusPublished && len(published) < benchmarkPageLimit {
+					published = append(published, post)
+				}
+			}
+			return len(posts), nil
+		})
+	})
+
+	b.Run("FilterInSQL", func(b *testing.B) {
+		runCountedBenchmark(b, counter, func() (int, error) {
+			posts, err := store.ListPublishedPostsByCategory(ctx, ListPublishedPostsByCategoryParams{
+				Category:  "bench-category-0",
+				PageLimit: benchmarkPageLimit + 1,
+			})
+			return len(posts), err
+		})
+	})
+}
+
+/* Benchmark the first page of published posts of an author, filtering in Go against filtering in SQL */
+func BenchmarkPublishedPostsByUsername(b *testing.B) {
+	ctx := context.Background()
+	store, connPool, counter := newBenchmarkStore(b)
+	user := seedBenchmarkPosts(b, store, connPool)
+
+	b.Run("FilterInGo", func(b *testing.B) {
+		runCountedBenchmark(b, counter, func() (int, error) {
+			posts, err := store.GetPostsByUserName(ctx, user.Username)
+			if err != nil {
+				return 0, err
+			}
+			published := []Post{}
+			for _, post := range posts {
+				if post.Status == StatusPublished && len(published) < benchmarkPageLimit {
+					published = append(published, post)
+				}
+			}
+			return len(posts), nil
+		})
+	})
+
+	b.Run("FilterInSQL", func(b *testing.B) {
+		runCountedBenchmark(b, counter, func() (int, error) {
+			posts, err := store.ListPostsByUsername(ctx, ListPostsByUsernameParams{
+				Username:  user.Username,
+				Status:    StatusPublished,
+				PageLimit: benchmarkPageLimit + 1,
+			})
+			return len(posts), err
+		})
+	})
+}
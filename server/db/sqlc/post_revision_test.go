@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostRevisions(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testRevisionUser", "testRevisionUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreatePostTx stores the first revision */
+	arg := createDummyPost(t, user.ID, user.Username)
+	arg.Status = StatusPublished
+	created, err := testStore.CreatePostTx(ctx, CreatePostTxParams{CreateNewPostParams: arg})
+	require.NoError(t, err)
+	post := created.Post
+
+	latest, err := testStore.GetLatestPostRevision(ctx, post.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), latest.Number)
+	require.Equal(t, post.Body, latest.Body)
+	require.False(t, latest.RestoredFrom.Valid)
+
+	update := func(title, body string) {
+		result, err := testStore.UpdatePostTx(ctx, UpdatePostTxParams{
+			UpdatePostBodyParams: UpdatePostBodyParams{
+				ID:       post.ID,
+				Body:     body,
+				Username: post.Username,
+				Version:  post.Version,
+			},
+			Title: title,
+		})
+		require.NoError(t, err)
+		post = result.Post
+	}
+
+	/* Test saving the same title and body does not add a revision */
+	update("", post.Body)
+	latest, err = testStore.GetLatestPostRevision(ctx, post.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), latest.Number)
+
+	/* Test every change of the body or title is a new revision */
+	update("", "A second body")
+	update("A New Title", "A second body")
+	require.Equal(t, "a-new-title", post.Slug)
+
+	revision, err := testStore.GetPostRevision(ctx, GetPostRevisionParams{PostID: post.ID, Number: 3})
+	require.NoError(t, err)
+	require.Equal(t, "A New Title", revision.Title)
+	require.Equal(t, "A second body", revision.Body)
+
+	/* Test restoring a revision adds it back as a new revision */
+	restored, err := testStore.RestorePostRevisionTx(ctx, RestorePostRevisionTxParams{
+		PostID:   post.ID,
+		Number:   1,
+		Username: post.Username,
+		Version:  post.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, created.Post.Title, restored.Post.Title)
+	require.Equal(t, created.Post.Body, restored.Post.Body)
+	require.Equal(t, int32(4), restored.Revision.Number)
+	require.Equal(t, int32(1), restored.Revision.RestoredFrom.Int32)
+
+	/* Test the restored title takes back its slug and the renamed one redirects */
+	require.Equal(t, created.Post.Slug, restored.Post.Slug)
+	redirect, err := testStore.GetPublishedPostByPreviousSlug(ctx, GetPublishedPostByPreviousSlugParams{Username: user.Username, Slug: "a-new-title"})
+	require.NoError(t, err)
+	require.Equal(t, post.ID, redirect.ID)
+
+	/* Test a restore based on an old version of the post changes nothing */
+	_, err = testStore.RestorePostRevisionTx(ctx, RestorePostRevisionTxParams{
+		PostID:   post.ID,
+		Number:   2,
+		Username: post.Username,
+		Version:  post.Version,
+	})
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+
+	_, err = testStore.RestorePostRevisionTx(ctx, RestorePostRevisionTxParams{
+		PostID:   post.ID,
+		Number:   9,
+		Username: post.Username,
+		Version:  restored.Post.Version,
+	})
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+
+	/* Test ListPostRevisions pages through the revisions, newest first */
+	revisions, err := testStore.ListPostRevisions(ctx, ListPostRevisionsParams{PostID: post.ID, PageLimit: 3})
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	require.Equal(t, int32(4), revisions[0].Number)
+
+	revisions, err = testStore.ListPostRevisions(ctx, ListPostRevisionsParams{
+		PostID:       post.ID,
+		CursorNumber: pgtype.Int4{Int32: revisions[2].Number, Valid: true},
+		PageLimit:    3,
+	})
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	require.Equal(t, int32(1), revisions[0].Number)
+
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
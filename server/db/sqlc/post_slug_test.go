@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostSlugBase(t *testing.T) {
+	require.Equal(t, "hello-world", postSlugBase("Hello, World!"))
+	require.Equal(t, "post", postSlugBase("!!!"))
+
+	/* Long titles are cut without leaving a trailing hyphen */
+	slug := postSlugBase(strings.Repeat("a", maxPostSlugLength-1) + " long title")
+	require.Equal(t, strings.Repeat("a", maxPostSlugLength-1), slug)
+}
+
+func TestPostSlugs(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testSlugUser", "testSlugUser@email.com"))
+	require.NoError(t, err)
+
+	createPost := func(title string) Post {
+		arg := createDummyPost(t, user.ID, user.Username)
+		arg.Title = title
+		arg.Status = StatusPublished
+		result, err := testStore.CreatePostTx(ctx, CreatePostTxParams{CreateNewPostParams: arg})
+		require.NoError(t, err)
+		return result.Post
+	}
+
+	/* Test CreatePostTx slugs the title and numbers the slugs of the same title */
+	first := createPost("Hello, World!")
+	require.Equal(t, "hello-world", first.Slug)
+	second := createPost("Hello World")
+	require.Equal(t, "hello-world-2", second.Slug)
+
+	post, err := testStore.GetPublishedPostByUsernameAndSlug(ctx, GetPublishedPostByUsernameAndSlugParams{
+		Username: strings.ToUpper(user.Username),
+		Slug:     second.Slug,
+	})
+	require.NoError(t, err)
+	require.Equal(t, second.ID, post.ID)
+
+	/* Test a new title changes the slug and keeps the previous one */
+	rename := func(post Post, title string) Post {
+		result, err := testStore.UpdatePostTx(ctx, UpdatePostTxParams{
+			UpdatePostBodyParams: UpdatePostBodyParams{
+				ID:       post.ID,
+				Body:     post.Body,
+				Username: post.Username,
+				Version:  post.Version,
+			},
+			Title: title,
+		})
+		require.NoError(t, err)
+		return result.Post
+	}
+
+	second = rename(second, "Goodbye")
+	require.Equal(t, "Goodbye", second.Title)
+	require.Equal(t, "goodbye", second.Slug)
+
+	post, err = testStore.GetPublishedPostByPreviousSlug(ctx, GetPublishedPostByPreviousSlugParams{Username: user.Username, Slug: "hello-world-2"})
+	require.NoError(t, err)
+	require.Equal(t, second.ID, post.ID)
+
+	/* Test the previous slugs of other posts are not reused */
+	third := createPost("Hello World")
+	require.Equal(t, "hello-world-3", third.Slug)
+
+	/* Test a post can take back its own previous slug, which then stops redirecting */
+	second = rename(second, "Hello World")
+	require.Equal(t, "hello-world-2", second.Slug)
+
+	_, err = testStore.GetPublishedPostByPreviousSlug(ctx, GetPublishedPostByPreviousSlugParams{Username: user.Username, Slug: "hello-world-2"})
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+
+	post, err = testStore.GetPublishedPostByPreviousSlug(ctx, GetPublishedPostByPreviousSlugParams{Username: user.Username, Slug: "goodbye"})
+	require.NoError(t, err)
+	require.Equal(t, second.ID, post.ID)
+
+	/* Test the permalinks of drafts are not found */
+	draft := createDummyPost(t, user.ID, user.Username)
+	draft.Slug = "draft-post"
+	_, err = testStore.CreateNewPost(ctx, draft)
+	require.NoError(t, err)
+	_, err = testStore.GetPublishedPostByUsernameAndSlug(ctx, GetPublishedPostByUsernameAndSlugParams{
+		Username: user.Username,
+		Slug:     draft.Slug,
+	})
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+
+	/* Test slugs are unique per author */
+	arg := createDummyPost(t, user.ID, user.Username)
+	arg.Slug = first.Slug
+	_, err = testStore.CreateNewPost(ctx, arg)
+	require.Equal(t, util.UniqueViolation, util.ErrorCode(err))
+
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
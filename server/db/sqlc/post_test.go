@@ -2,9 +2,13 @@ package db
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	"github.com/Oabraham1/open-blogger/server/util"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -14,7 +18,8 @@ func createDummyPost(t *testing.T, userId uuid.UUID, username string) CreateNewP
 		Body:     "This is a test post",
 		Username: username,
 		Status:   StatusDraft,
-		Category: "Test",
+		Category: "uncategorized",
+		Slug:     "test-post-" + uuid.NewString(),
 	}
 }
 
@@ -51,27 +56,15 @@ func TestPostCRUDOperations(t *testing.T) {
 	require.Equal(t, post.Category, getPost.Category)
 	require.Equal(t, post.CreatedAt, getPost.CreatedAt)
 
-	/*
-		Test Get Post By Category
-	*/
-	getPostsByCategory, err := testStore.GetPostsByCategory(ctx, post.Category)
-	require.NoError(t, err)
-	require.NotEmpty(t, getPostsByCategory)
-	require.Greater(t, len(getPostsByCategory), 0)
-
-	/*
-		Test Get All Posts
-		Create 4 posts
-	*/
+	/* Create 4 more posts */
 	for i := 0; i < 4; i++ {
 		arg := createDummyPost(t, user.ID, user.Username)
 		_, err := testStore.CreateNewPost(ctx, arg)
 		require.NoError(t, err)
 	}
-	getAllPosts, err := testStore.GetAllPosts(ctx)
+	userPosts, err := testStore.GetPostsByUserName(ctx, user.Username)
 	require.NoError(t, err)
-	require.NotEmpty(t, getAllPosts)
-	require.Equal(t, len(getAllPosts), 5)
+	require.Len(t, userPosts, 5)
 
 	/*
 		Test Update Post Body
@@ -80,12 +73,20 @@ func TestPostCRUDOperations(t *testing.T) {
 		Body:     "This is an updated post",
 		Username: arg.Username,
 		ID:       post.ID,
+		Version:  post.Version,
 	}
 	updatedPost, err := testStore.UpdatePostBody(ctx, updatePost)
 	require.NoError(t, err)
 	require.NotEmpty(t, updatedPost)
 	require.Equal(t, updatePost.Body, updatedPost.Body)
 	require.NotEqual(t, post.Body, updatedPost.Body)
+	require.Equal(t, post.Version+1, updatedPost.Version)
+
+	/*
+		Test an update based on an old version changes nothing
+	*/
+	_, err = testStore.UpdatePostBody(ctx, updatePost)
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
 
 	/*
 		Test Update Post Status
@@ -94,12 +95,14 @@ func TestPostCRUDOperations(t *testing.T) {
 		Status:   StatusPublished,
 		Username: arg.Username,
 		ID:       post.ID,
+		Version:  updatedPost.Version,
 	}
 	updatedPost, err = testStore.UpdatePostStatus(ctx, updatePostStatus)
 	require.NoError(t, err)
 	require.NotEmpty(t, updatedPost)
 	require.Equal(t, updatePostStatus.Status, updatedPost.Status)
 	require.NotEqual(t, post.Status, updatedPost.Status)
+	require.Equal(t, updatePostStatus.Version+1, updatedPost.Version)
 
 	/*
 		Test Delete Post
@@ -112,7 +115,7 @@ func TestPostCRUDOperations(t *testing.T) {
 
 	// Tear Down
 	// Delete all posts
-	posts, err := testStore.GetAllPosts(ctx)
+	posts, err := testStore.GetPostsByUserName(ctx, user.Username)
 	require.NoError(t, err)
 	require.NotEmpty(t, posts)
 	for _, post := range posts {
@@ -123,3 +126,193 @@ func TestPostCRUDOperations(t *testing.T) {
 	err = testStore.DeleteUserAccount(ctx, arg.Username)
 	require.NoError(t, err)
 }
+
+func TestListPostsPagination(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testPageUser", "testPageUser@email.com"))
+	require.NoError(t, err)
+	category, err := testStore.CreateCategory(ctx, CreateCategoryParams{Slug: "test-page-category", Name: "Test Page Category"})
+	require.NoError(t, err)
+
+	/* Create 5 published posts and 1 draft, two of the published posts share their publish time */
+	publishedAt := []string{"2024-01-01 10:00:00", "2024-01-02 10:00:00", "2024-01-02 10:00:00", "2024-01-03 10:00:00", "2024-01-04 10:00:00"}
+	for _, published := range publishedAt {
+		arg := createDummyPost(t, user.ID, user.Username)
+		arg.Category = category.Slug
+		arg.Status = StatusPublished
+		arg.PublishedAt = published
+		_, err := testStore.CreateNewPost(ctx, arg)
+		require.NoError(t, err)
+	}
+	draft := createDummyPost(t, user.ID, user.Username)
+	draft.Category = category.Slug
+	_, err = testStore.CreateNewPost(ctx, draft)
+	require.NoError(t, err)
+
+	/* Test ListPublishedPostsByCategory walks every published post once, newest first */
+	seen := make(map[uuid.UUID]bool)
+	var previous []Post
+	arg := ListPublishedPostsByCategoryParams{Category: category.Slug, PageLimit: 2}
+	for {
+		posts, err := testStore.ListPublishedPostsByCategory(ctx, arg)
+		require.NoError(t, err)
+		if len(posts) == 0 {
+			break
+		}
+		require.LessOrEqual(t, len(posts), 2)
+		for _, post := range posts {
+			require.Equal(t, StatusPublished, post.Status)
+			require.False(t, seen[post.ID])
+			seen[post.ID] = true
+			if len(previous) > 0 {
+				require.GreaterOrEqual(t, previous[len(previous)-1].PublishedAt, post.PublishedAt)
+			}
+			previous = append(previous, post)
+		}
+
+		last := posts[len(posts)-1]
+		arg.CursorPublishedAt = pgtype.Text{String: last.PublishedAt, Valid: true}
+		arg.CursorID = last.ID
+	}
+	require.Len(t, seen, len(publishedAt))
+
+	/* Test ListPostsByUsername filters by status */
+	published, err := testStore.ListPostsByUsername(ctx, ListPostsByUsernameParams{
+		Username:  user.Username,
+		Status:    StatusPublished,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, published, len(publishedAt))
+
+	/* Test ListDraftPostsByUsername lists only drafts and pages them by ordered_at */
+	drafts, err := testStore.ListDraftPostsByUsername(ctx, ListDraftPostsByUsernameParams{
+		Username:  user.Username,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, drafts, 1)
+	require.Equal(t, StatusDraft, drafts[0].Status)
+
+	nextDrafts, err := testStore.ListDraftPostsByUsername(ctx, ListDraftPostsByUsernameParams{
+		Username:        user.Username,
+		CursorOrderedAt: pgtype.Timestamptz{Time: drafts[0].OrderedAt, Valid: true},
+		CursorID:        drafts[0].ID,
+		PageLimit:       10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, nextDrafts)
+
+	/* Teardown */
+	for _, post := range previous {
+		err = testStore.DeletePostByID(ctx, post.ID)
+		require.NoError(t, err)
+	}
+	err = testStore.DeletePostByID(ctx, drafts[0].ID)
+	require.NoError(t, err)
+	_, err = testStore.DeleteCategory(ctx, category.ID)
+	require.NoError(t, err)
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestSearchPublishedPosts(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testSearchUser", "testSearchUser@email.com"))
+	require.NoError(t, err)
+
+	/* Create a post matching in its title, one matching in its body and a draft matching in both */
+	titleMatch := createDummyPost(t, user.ID, user.Username)
+	titleMatch.Title = "Searching with tsvector"
+	titleMatch.Status = StatusPublished
+	titleMatch.PublishedAt = "2024-01-01 10:00:00"
+	bodyMatch := createDummyPost(t, user.ID, user.Username)
+	bodyMatch.Body = "This body mentions a <b>tsvector</b> once"
+	bodyMatch.Status = StatusPublished
+	bodyMatch.PublishedAt = "2024-02-01 10:00:00"
+	draft := createDummyPost(t, user.ID, user.Username)
+	draft.Title = "Draft about tsvector"
+	draft.Body = "tsvector"
+
+	created := []Post{}
+	for _, arg := range []CreateNewPostParams{titleMatch, bodyMatch, draft} {
+		post, err := testStore.CreateNewPost(ctx, arg)
+		require.NoError(t, err)
+		created = append(created, post)
+	}
+
+	/* Test a title match ranks above a body match and drafts are left out */
+	arg := SearchPublishedPostsParams{
+		Query:     "tsvector",
+		Username:  pgtype.Text{String: user.Username, Valid: true},
+		PageLimit: 10,
+	}
+	posts, err := testStore.SearchPublishedPosts(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, posts, 2)
+	require.Equal(t, created[0].ID, posts[0].ID)
+	require.Equal(t, created[1].ID, posts[1].ID)
+	require.Greater(t, posts[0].Rank, posts[1].Rank)
+
+	/* Test the snippet highlights the match and escapes the body */
+	require.Contains(t, posts[1].Snippet, "<mark>tsvector</mark>")
+	require.Contains(t, posts[1].Snippet, "&lt;b&gt;")
+
+	/* Test prefix queries and the date range */
+	arg.Query = "search:*"
+	posts, err = testStore.SearchPublishedPosts(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+
+	arg.Query = "tsvector"
+	arg.PublishedFrom = pgtype.Text{String: "2024-01-15", Valid: true}
+	posts, err = testStore.SearchPublishedPosts(ctx, arg)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	require.Equal(t, created[1].ID, posts[0].ID)
+
+	/* Test the cursor continues after the rank of the last post */
+	arg.PublishedFrom = pgtype.Text{}
+	arg.CursorRank = pgtype.Float4{Float32: posts[0].Rank, Valid: true}
+	arg.CursorID = posts[0].ID
+	posts, err = testStore.SearchPublishedPosts(ctx, arg)
+	require.NoError(t, err)
+	require.Empty(t, posts)
+
+	/* Teardown */
+	for _, post := range created {
+		err = testStore.DeletePostByID(ctx, post.ID)
+		require.NoError(t, err)
+	}
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestSearchPublishedPostsUsesIndex(t *testing.T) {
+	ctx := context.Background()
+	tx, err := testStore.(*SQLStore).ConnectionPool.Begin(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	// a test database holds a handful of posts, which a sequential scan reads faster than any index
+	_, err = tx.Exec(ctx, "SET LOCAL enable_seqscan = off")
+	require.NoError(t, err)
+
+	arg := SearchPublishedPostsParams{Query: "tsvector", PageLimit: 10}
+	rows, err := tx.Query(ctx, "EXPLAIN "+searchPublishedPosts,
+		arg.Query,
+		arg.Category,
+		arg.Username,
+		arg.PublishedFrom,
+		arg.PublishedTo,
+		arg.CursorRank,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	require.NoError(t, err)
+	plan, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+
+	/* Test the search expression is matched to the GIN expression index */
+	require.Contains(t, strings.Join(plan, "\n"), "posts_search_vector_idx")
+}
@@ -11,28 +11,106 @@ import (
 )
 
 type Querier interface {
+	AddPostSlugHistory(ctx context.Context, arg AddPostSlugHistoryParams) error
+	AddPostTag(ctx context.Context, arg AddPostTagParams) error
+	BlockLoginAttempt(ctx context.Context, arg BlockLoginAttemptParams) error
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
+	BlockUserSessionsExceptFamily(ctx context.Context, arg BlockUserSessionsExceptFamilyParams) error
+	ConfirmUserTOTP(ctx context.Context, arg ConfirmUserTOTPParams) (UserTotp, error)
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (OidcLoginState, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) (AuditLog, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateEmailVerificationToken(ctx context.Context, arg CreateEmailVerificationTokenParams) (EmailVerificationToken, error)
+	CreateInvite(ctx context.Context, arg CreateInviteParams) (Invite, error)
+	CreateMFAChallenge(ctx context.Context, arg CreateMFAChallengeParams) (MfaChallenge, error)
 	CreateNewComment(ctx context.Context, arg CreateNewCommentParams) (Comment, error)
 	CreateNewPost(ctx context.Context, arg CreateNewPostParams) (Post, error)
 	CreateNewUser(ctx context.Context, arg CreateNewUserParams) (User, error)
 	CreateNewUserSession(ctx context.Context, arg CreateNewUserSessionParams) (Session, error)
+	CreateOIDCLoginState(ctx context.Context, arg CreateOIDCLoginStateParams) (OidcLoginState, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error)
+	CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) (PostRevision, error)
+	CreateTOTPRecoveryCode(ctx context.Context, arg CreateTOTPRecoveryCodeParams) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) (int64, error)
 	DeleteCommentByID(ctx context.Context, id uuid.UUID) error
+	DeleteEmailVerificationTokensByUsername(ctx context.Context, username string) error
+	DeleteInvite(ctx context.Context, arg DeleteInviteParams) (int64, error)
+	DeleteLoginAttempt(ctx context.Context, key string) error
+	DeletePersonalAccessToken(ctx context.Context, arg DeletePersonalAccessTokenParams) (int64, error)
+	DeletePersonalAccessTokensByUsername(ctx context.Context, username string) error
+	DeleteCommentsByPostID(ctx context.Context, postID uuid.UUID) error
 	DeletePostByID(ctx context.Context, id uuid.UUID) error
+	DeletePostSlugHistory(ctx context.Context, arg DeletePostSlugHistoryParams) error
+	DeletePostTags(ctx context.Context, postID uuid.UUID) error
 	DeleteSessionById(ctx context.Context, id uuid.UUID) error
+	DeleteTOTPRecoveryCodesByUsername(ctx context.Context, username string) error
 	DeleteUserAccount(ctx context.Context, username string) error
-	GetAllPosts(ctx context.Context) ([]GetAllPostsRow, error)
+	GetAuditLogsByUsername(ctx context.Context, username string) ([]AuditLog, error)
+	GetCategoryAncestorIDs(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (Category, error)
 	GetCommentByID(ctx context.Context, id uuid.UUID) (Comment, error)
-	GetCommentsByPostID(ctx context.Context, postID uuid.UUID) ([]Comment, error)
 	GetCommentsByUserName(ctx context.Context, username string) ([]Comment, error)
+	GetEmailVerificationTokenByHash(ctx context.Context, tokenHash string) (EmailVerificationToken, error)
+	GetInvitesByInviter(ctx context.Context, inviterUsername string) ([]Invite, error)
+	GetLatestEmailVerificationToken(ctx context.Context, username string) (EmailVerificationToken, error)
+	GetLatestPasswordResetToken(ctx context.Context, username string) (PasswordResetToken, error)
+	GetLatestPostRevision(ctx context.Context, postID uuid.UUID) (PostRevision, error)
+	GetLoginAttempt(ctx context.Context, key string) (LoginAttempt, error)
+	GetMFAChallengeByHash(ctx context.Context, tokenHash string) (MfaChallenge, error)
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	GetPersonalAccessTokenByHash(ctx context.Context, tokenHash string) (GetPersonalAccessTokenByHashRow, error)
+	GetPersonalAccessTokensByUsername(ctx context.Context, username string) ([]PersonalAccessToken, error)
 	GetPostById(ctx context.Context, id uuid.UUID) (Post, error)
-	GetPostsByCategory(ctx context.Context, category string) ([]Post, error)
+	GetPostRevision(ctx context.Context, arg GetPostRevisionParams) (PostRevision, error)
 	GetPostsByUserName(ctx context.Context, username string) ([]Post, error)
+	GetPublishedPostByPreviousSlug(ctx context.Context, arg GetPublishedPostByPreviousSlugParams) (Post, error)
+	GetPublishedPostByUsernameAndSlug(ctx context.Context, arg GetPublishedPostByUsernameAndSlugParams) (Post, error)
+	GetRemainingInviteUsesByInviter(ctx context.Context, inviterUsername string) (int32, error)
 	GetSessionById(ctx context.Context, id uuid.UUID) (Session, error)
+	GetTagsByPostIDs(ctx context.Context, postIds []uuid.UUID) ([]GetTagsByPostIDsRow, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByUsername(ctx context.Context, username string) (User, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
 	GetUserSessionsByUsername(ctx context.Context, username string) ([]Session, error)
+	GetUserTOTP(ctx context.Context, username string) (UserTotp, error)
+	IncrementMFAChallengeFailedAttempts(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	ListCommentsByPostID(ctx context.Context, arg ListCommentsByPostIDParams) ([]Comment, error)
+	ListPostRevisions(ctx context.Context, arg ListPostRevisionsParams) ([]ListPostRevisionsRow, error)
+	ListDraftPostsByUsername(ctx context.Context, arg ListDraftPostsByUsernameParams) ([]Post, error)
+	ListPostsByUsername(ctx context.Context, arg ListPostsByUsernameParams) ([]Post, error)
+	ListPublishedPosts(ctx context.Context, arg ListPublishedPostsParams) ([]Post, error)
+	ListPublishedPostsByCategory(ctx context.Context, arg ListPublishedPostsByCategoryParams) ([]Post, error)
+	ListPublishedPostsByTag(ctx context.Context, arg ListPublishedPostsByTagParams) ([]Post, error)
+	ListPublishedPostsInCategoryTree(ctx context.Context, arg ListPublishedPostsInCategoryTreeParams) ([]Post, error)
+	ListTagsWithPostCounts(ctx context.Context) ([]ListTagsWithPostCountsRow, error)
+	ListTakenPostSlugs(ctx context.Context, arg ListTakenPostSlugsParams) ([]string, error)
+	LockAdminUsernames(ctx context.Context) ([]string, error)
+	LockUserByUsername(ctx context.Context, username string) (string, error)
+	MarkMFAChallengeAsUsed(ctx context.Context, id uuid.UUID) (int64, error)
+	MarkPasswordResetTokenAsUsed(ctx context.Context, id uuid.UUID) (int64, error)
+	MarkSessionAsUsed(ctx context.Context, id uuid.UUID) (int64, error)
+	MarkUserEmailAsVerified(ctx context.Context, username string) (User, error)
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginAttempt, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error)
+	SearchPublishedPosts(ctx context.Context, arg SearchPublishedPostsParams) ([]SearchPublishedPostsRow, error)
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
+	UpdatePersonalAccessTokenLastUsedAt(ctx context.Context, id uuid.UUID) error
 	UpdatePostBody(ctx context.Context, arg UpdatePostBodyParams) (Post, error)
 	UpdatePostStatus(ctx context.Context, arg UpdatePostStatusParams) (Post, error)
+	UpdatePostTitle(ctx context.Context, arg UpdatePostTitleParams) (Post, error)
 	UpdateUserInterestsByUsername(ctx context.Context, arg UpdateUserInterestsByUsernameParams) error
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	UpdateUserTOTPLastUsedStep(ctx context.Context, arg UpdateUserTOTPLastUsedStepParams) (int64, error)
+	UpsertTag(ctx context.Context, slug string) (Tag, error)
+	UpsertUnconfirmedUserTOTP(ctx context.Context, arg UpsertUnconfirmedUserTOTPParams) (UserTotp, error)
+	UseInvite(ctx context.Context, codeHash string) (int64, error)
+	UseTOTPRecoveryCode(ctx context.Context, arg UseTOTPRecoveryCodeParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
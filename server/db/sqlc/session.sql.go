@@ -12,11 +12,45 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockSessionFamily = `-- name: BlockSessionFamily :exec
+UPDATE sessions SET is_blocked = true WHERE family_id = $1
+`
+
+func (q *Queries) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.Exec(ctx, blockSessionFamily, familyID)
+	return err
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :exec
+UPDATE sessions SET is_blocked = true WHERE username = $1
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, blockUserSessions, username)
+	return err
+}
+
+const blockUserSessionsExceptFamily = `-- name: BlockUserSessionsExceptFamily :exec
+UPDATE sessions SET is_blocked = true WHERE username = $1 AND family_id <> $2
+`
+
+type BlockUserSessionsExceptFamilyParams struct {
+	Username string    `json:"username"`
+	FamilyID uuid.UUID `json:"family_id"`
+}
+
+func (q *Queries) BlockUserSessionsExceptFamily(ctx context.Context, arg BlockUserSessionsExceptFamilyParams) error {
+	_, err := q.db.Exec(ctx, blockUserSessionsExceptFamily, arg.Username, arg.FamilyID)
+	return err
+}
+
 const createNewUserSession = `-- name: CreateNewUserSession :one
-INSERT INTO sessions (username, refresh_token, user_agent, client_ip, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+INSERT INTO sessions (id, family_id, username, refresh_token, user_agent, client_ip, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, is_used
 `
 
 type CreateNewUserSessionParams struct {
+	ID           uuid.UUID `json:"id"`
+	FamilyID     uuid.UUID `json:"family_id"`
 	Username     string    `json:"username"`
 	RefreshToken string    `json:"refresh_token"`
 	UserAgent    string    `json:"user_agent"`
@@ -26,6 +60,8 @@ type CreateNewUserSessionParams struct {
 
 func (q *Queries) CreateNewUserSession(ctx context.Context, arg CreateNewUserSessionParams) (Session, error) {
 	row := q.db.QueryRow(ctx, createNewUserSession,
+		arg.ID,
+		arg.FamilyID,
 		arg.Username,
 		arg.RefreshToken,
 		arg.UserAgent,
@@ -42,6 +78,8 @@ func (q *Queries) CreateNewUserSession(ctx context.Context, arg CreateNewUserSes
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.IsUsed,
 	)
 	return i, err
 }
@@ -56,7 +94,7 @@ func (q *Queries) DeleteSessionById(ctx context.Context, id uuid.UUID) error {
 }
 
 const getSessionById = `-- name: GetSessionById :one
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions WHERE id = $1 LIMIT 1
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, is_used FROM sessions WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetSessionById(ctx context.Context, id uuid.UUID) (Session, error) {
@@ -71,12 +109,14 @@ func (q *Queries) GetSessionById(ctx context.Context, id uuid.UUID) (Session, er
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.IsUsed,
 	)
 	return i, err
 }
 
 const getUserSessionsByUsername = `-- name: GetUserSessionsByUsername :many
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions WHERE username = $1 ORDER BY created_at DESC
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, is_used FROM sessions WHERE username = $1 ORDER BY created_at DESC
 `
 
 func (q *Queries) GetUserSessionsByUsername(ctx context.Context, username string) ([]Session, error) {
@@ -97,6 +137,8 @@ func (q *Queries) GetUserSessionsByUsername(ctx context.Context, username string
 			&i.IsBlocked,
 			&i.ExpiresAt,
 			&i.CreatedAt,
+			&i.FamilyID,
+			&i.IsUsed,
 		); err != nil {
 			return nil, err
 		}
@@ -107,3 +149,15 @@ func (q *Queries) GetUserSessionsByUsername(ctx context.Context, username string
 	}
 	return items, nil
 }
+
+const markSessionAsUsed = `-- name: MarkSessionAsUsed :execrows
+UPDATE sessions SET is_used = true WHERE id = $1 AND is_used = false
+`
+
+func (q *Queries) MarkSessionAsUsed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, markSessionAsUsed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
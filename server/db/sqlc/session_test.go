@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createDummySession(id, familyID uuid.UUID, username string) CreateNewUserSessionParams {
+	return CreateNewUserSessionParams{
+		ID:           id,
+		FamilyID:     familyID,
+		Username:     username,
+		RefreshToken: "testRefreshToken" + id.String(),
+		UserAgent:    "testUserAgent",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testSessionUser", "testSessionUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreateNewUserSession */
+	loginID := uuid.New()
+	login, err := testStore.CreateNewUserSession(ctx, createDummySession(loginID, loginID, user.Username))
+	require.NoError(t, err)
+	require.Equal(t, loginID, login.ID)
+	require.Equal(t, loginID, login.FamilyID)
+	require.False(t, login.IsUsed)
+	require.False(t, login.IsBlocked)
+
+	/* Test RotateSessionTx */
+	rotated, err := testStore.RotateSessionTx(ctx, RotateSessionTxParams{
+		PreviousSessionID:          login.ID,
+		CreateNewUserSessionParams: createDummySession(uuid.New(), login.FamilyID, user.Username),
+	})
+	require.NoError(t, err)
+	require.Equal(t, login.FamilyID, rotated.Session.FamilyID)
+	require.NotEqual(t, login.ID, rotated.Session.ID)
+
+	usedLogin, err := testStore.GetSessionById(ctx, login.ID)
+	require.NoError(t, err)
+	require.True(t, usedLogin.IsUsed)
+
+	/* Test RotateSessionTx with a reused refresh token */
+	_, err = testStore.RotateSessionTx(ctx, RotateSessionTxParams{
+		PreviousSessionID:          login.ID,
+		CreateNewUserSessionParams: createDummySession(uuid.New(), login.FamilyID, user.Username),
+	})
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	sessions, err := testStore.GetUserSessionsByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, session := range sessions {
+		require.True(t, session.IsBlocked)
+	}
+
+	/* Teardown */
+	for _, session := range sessions {
+		err = testStore.DeleteSessionById(ctx, session.ID)
+		require.NoError(t, err)
+	}
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
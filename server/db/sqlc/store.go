@@ -11,6 +11,17 @@ type Store interface {
 	Querier
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	CreatePostTx(ctx context.Context, arg CreatePostTxParams) (CreatePostTxResult, error)
+	UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (UpdatePostTxResult, error)
+	RestorePostRevisionTx(ctx context.Context, arg RestorePostRevisionTxParams) (RestorePostRevisionTxResult, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) error
+	VerifyEmailTx(ctx context.Context, username string) (User, error)
+	ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (UserTotp, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleParams) (User, error)
+	CreateInviteTx(ctx context.Context, arg CreateInviteTxParams) (Invite, error)
+	DeleteUserAccountTx(ctx context.Context, username string) error
+	UseMFAChallengeTx(ctx context.Context, arg UseMFAChallengeTxParams) error
 }
 
 /* SQLStore provides all functions to execute SQL queries and transactions */
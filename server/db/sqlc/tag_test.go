@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostTags(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testTagUser", "testTagUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreatePostTx creates the missing tags and links them to the post */
+	arg := createDummyPost(t, user.ID, user.Username)
+	arg.Status = StatusPublished
+	arg.PublishedAt = "2024-01-01 10:00:00"
+	result, err := testStore.CreatePostTx(ctx, CreatePostTxParams{
+		CreateNewPostParams: arg,
+		Tags:                []string{"test-tag-go", "test-tag-web"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"test-tag-go", "test-tag-web"}, result.Tags)
+
+	posts, err := testStore.ListPublishedPostsByTag(ctx, ListPublishedPostsByTagParams{Slug: "test-tag-web", PageLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	require.Equal(t, result.Post.ID, posts[0].ID)
+
+	tags, err := testStore.ListTagsWithPostCounts(ctx)
+	require.NoError(t, err)
+	counts := make(map[string]int64)
+	for _, tag := range tags {
+		counts[tag.Slug] = tag.PostCount
+	}
+	require.Equal(t, int64(1), counts["test-tag-go"])
+
+	/* Test UpdatePostTx keeps the tags when none are given and replaces them otherwise */
+	update := UpdatePostBodyParams{
+		ID:           result.Post.ID,
+		Body:         "Updated body",
+		Username:     user.Username,
+		LastModified: "2024-01-02 10:00:00",
+		Version:      result.Post.Version,
+	}
+	updated, err := testStore.UpdatePostTx(ctx, UpdatePostTxParams{UpdatePostBodyParams: update})
+	require.NoError(t, err)
+	require.Equal(t, result.Tags, updated.Tags)
+
+	update.Version = updated.Post.Version
+
+	updated, err = testStore.UpdatePostTx(ctx, UpdatePostTxParams{UpdatePostBodyParams: update, Tags: []string{"test-tag-go"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"test-tag-go"}, updated.Tags)
+
+	posts, err = testStore.ListPublishedPostsByTag(ctx, ListPublishedPostsByTagParams{Slug: "test-tag-web", PageLimit: 10})
+	require.NoError(t, err)
+	require.Empty(t, posts)
+
+	/* Teardown, deleting the post removes its tag links */
+	err = testStore.DeletePostByID(ctx, result.Post.ID)
+	require.NoError(t, err)
+	rows, err := testStore.GetTagsByPostIDs(ctx, []uuid.UUID{result.Post.ID})
+	require.NoError(t, err)
+	require.Empty(t, rows)
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirmTOTPTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testTOTPUser", "testTOTPUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test UpsertUnconfirmedUserTOTP */
+	userTOTP, err := testStore.UpsertUnconfirmedUserTOTP(ctx, UpsertUnconfirmedUserTOTPParams{
+		Username:         user.Username,
+		SecretCiphertext: []byte("firstCiphertext"),
+	})
+	require.NoError(t, err)
+	require.False(t, userTOTP.ConfirmedAt.Valid)
+
+	userTOTP, err = testStore.UpsertUnconfirmedUserTOTP(ctx, UpsertUnconfirmedUserTOTPParams{
+		Username:         user.Username,
+		SecretCiphertext: []byte("secondCiphertext"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte("secondCiphertext"), userTOTP.SecretCiphertext)
+
+	/* Test ConfirmTOTPTx */
+	userTOTP, err = testStore.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{
+		Username:           user.Username,
+		Step:               100,
+		RecoveryCodeHashes: []string{"firstCodeHash", "secondCodeHash"},
+	})
+	require.NoError(t, err)
+	require.True(t, userTOTP.ConfirmedAt.Valid)
+	require.Equal(t, int64(100), userTOTP.LastUsedStep)
+
+	/* A confirmed enrollment can neither be confirmed again nor replaced */
+	_, err = testStore.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{Username: user.Username, Step: 101})
+	require.Error(t, err)
+	_, err = testStore.UpsertUnconfirmedUserTOTP(ctx, UpsertUnconfirmedUserTOTPParams{
+		Username:         user.Username,
+		SecretCiphertext: []byte("thirdCiphertext"),
+	})
+	require.Error(t, err)
+
+	/* Test UpdateUserTOTPLastUsedStep rejects replayed steps */
+	rows, err := testStore.UpdateUserTOTPLastUsedStep(ctx, UpdateUserTOTPLastUsedStepParams{Username: user.Username, LastUsedStep: 100})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+	rows, err = testStore.UpdateUserTOTPLastUsedStep(ctx, UpdateUserTOTPLastUsedStepParams{Username: user.Username, LastUsedStep: 101})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	/* Test UseTOTPRecoveryCode */
+	rows, err = testStore.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: "firstCodeHash"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+	rows, err = testStore.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: "firstCodeHash"})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	/* Test MFA challenges */
+	challenge, err := testStore.CreateMFAChallenge(ctx, CreateMFAChallengeParams{
+		Username:  user.Username,
+		TokenHash: "testChallengeHash",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	challenge, err = testStore.IncrementMFAChallengeFailedAttempts(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), challenge.FailedAttempts)
+
+	rows, err = testStore.MarkMFAChallengeAsUsed(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+	rows, err = testStore.MarkMFAChallengeAsUsed(ctx, challenge.ID)
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestUseMFAChallengeTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testMFAChallengeUser", "testMFAChallengeUser@email.com"))
+	require.NoError(t, err)
+
+	_, err = testStore.UpsertUnconfirmedUserTOTP(ctx, UpsertUnconfirmedUserTOTPParams{
+		Username:         user.Username,
+		SecretCiphertext: []byte("testCiphertext"),
+	})
+	require.NoError(t, err)
+	_, err = testStore.ConfirmTOTPTx(ctx, ConfirmTOTPTxParams{
+		Username:           user.Username,
+		Step:               100,
+		RecoveryCodeHashes: []string{"firstCodeHash", "secondCodeHash"},
+	})
+	require.NoError(t, err)
+
+	challenge, err := testStore.CreateMFAChallenge(ctx, CreateMFAChallengeParams{
+		Username:  user.Username,
+		TokenHash: "testUseChallengeHash",
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	useRecoveryCode := func(codeHash string) func(querier Querier) (bool, error) {
+		return func(querier Querier) (bool, error) {
+			rows, err := querier.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: codeHash})
+			return rows > 0, err
+		}
+	}
+
+	/* A rejected second factor leaves the challenge unused */
+	err = testStore.UseMFAChallengeTx(ctx, UseMFAChallengeTxParams{
+		ChallengeID:        challenge.ID,
+		VerifySecondFactor: useRecoveryCode("unknownCodeHash"),
+	})
+	require.ErrorIs(t, err, ErrSecondFactorInvalid)
+	challenge, err = testStore.GetMFAChallengeByHash(ctx, challenge.TokenHash)
+	require.NoError(t, err)
+	require.False(t, challenge.IsUsed)
+
+	err = testStore.UseMFAChallengeTx(ctx, UseMFAChallengeTxParams{
+		ChallengeID:        challenge.ID,
+		VerifySecondFactor: useRecoveryCode("firstCodeHash"),
+	})
+	require.NoError(t, err)
+	challenge, err = testStore.GetMFAChallengeByHash(ctx, challenge.TokenHash)
+	require.NoError(t, err)
+	require.True(t, challenge.IsUsed)
+
+	/* A used challenge does not consume another recovery code */
+	err = testStore.UseMFAChallengeTx(ctx, UseMFAChallengeTxParams{
+		ChallengeID:        challenge.ID,
+		VerifySecondFactor: useRecoveryCode("secondCodeHash"),
+	})
+	require.ErrorIs(t, err, ErrMFAChallengeUsed)
+	rows, err := testStore.UseTOTPRecoveryCode(ctx, UseTOTPRecoveryCodeParams{Username: user.Username, CodeHash: "secondCodeHash"})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
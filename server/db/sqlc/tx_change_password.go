@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+)
+
+/* ChangePasswordTxParams contains the input parameters of the ChangePasswordTx function */
+type ChangePasswordTxParams struct {
+	HashedPassword string
+	CreateNewUserSessionParams
+}
+
+/* ChangePasswordTxResult is the result of the ChangePasswordTx function */
+type ChangePasswordTxResult struct {
+	Session Session
+}
+
+/*
+ChangePasswordTx stores the new password, blocks every session of the user, deletes their personal access
+tokens and creates a fresh session for the device that changed the password within a database transaction.
+*/
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (ChangePasswordTxResult, error) {
+	var result ChangePasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Password: arg.HashedPassword,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeletePersonalAccessTokensByUsername(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.Session, err = q.CreateNewUserSession(ctx, arg.CreateNewUserSessionParams)
+		return err
+	})
+	return result, err
+}
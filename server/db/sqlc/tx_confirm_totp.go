@@ -0,0 +1,48 @@
+package db
+
+import "context"
+
+/* ConfirmTOTPTxParams contains the input parameters of the ConfirmTOTPTx function */
+type ConfirmTOTPTxParams struct {
+	Username           string
+	Step               int64
+	RecoveryCodeHashes []string
+}
+
+/*
+ConfirmTOTPTx confirms the pending TOTP enrollment of the user, records the time step of the
+confirming code and replaces the recovery codes of the user within a database transaction.
+*/
+func (store *SQLStore) ConfirmTOTPTx(ctx context.Context, arg ConfirmTOTPTxParams) (UserTotp, error) {
+	var userTOTP UserTotp
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		userTOTP, err = q.ConfirmUserTOTP(ctx, ConfirmUserTOTPParams{
+			Username:     arg.Username,
+			LastUsedStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteTOTPRecoveryCodesByUsername(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			err = q.CreateTOTPRecoveryCode(ctx, CreateTOTPRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return userTOTP, err
+}
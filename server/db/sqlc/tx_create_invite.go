@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+/* ErrInviteQuotaExceeded is returned by CreateInviteTx when the invite would exceed the quota of the inviter */
+var ErrInviteQuotaExceeded = errors.New("the invite quota is used up")
+
+/*
+CreateInviteTxParams contains the input parameters of the CreateInviteTx function.
+Quota is the number of unused invite uses the inviter can have, zero means no quota.
+*/
+type CreateInviteTxParams struct {
+	CreateInviteParams
+	Quota int32
+}
+
+/*
+CreateInviteTx checks the quota of the inviter and creates the invite within a database transaction.
+The inviter is locked first, so concurrent invites of the same inviter cannot exceed the quota between them.
+*/
+func (store *SQLStore) CreateInviteTx(ctx context.Context, arg CreateInviteTxParams) (Invite, error) {
+	var invite Invite
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.Quota > 0 {
+			if _, err := q.LockUserByUsername(ctx, arg.InviterUsername); err != nil {
+				return err
+			}
+
+			remainingUses, err := q.GetRemainingInviteUsesByInviter(ctx, arg.InviterUsername)
+			if err != nil {
+				return err
+			}
+			if int64(remainingUses)+int64(arg.MaxUses) > int64(arg.Quota) {
+				return ErrInviteQuotaExceeded
+			}
+		}
+
+		var err error
+
+		invite, err = q.CreateInvite(ctx, arg.CreateInviteParams)
+		return err
+	})
+
+	return invite, err
+}
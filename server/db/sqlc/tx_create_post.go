@@ -1,30 +1,61 @@
 package db
 
-import "context"
+import (
+	"context"
 
-/* CreatePostTxParams contains the input parameters of the CreatePostTx function */
+	"github.com/google/uuid"
+)
+
+/*
+CreatePostTxParams contains the input parameters of the CreatePostTx function.
+Tags are slugs, the tags that do not exist yet are created with the post.
+The slug of the post is made from its title, any Slug in CreateNewPostParams is replaced.
+*/
 type CreatePostTxParams struct {
 	CreateNewPostParams
+	Tags        []string
 	AfterCreate func(post Post) error
 }
 
 /* CreatePostTxResult is the result of the CreatePostTx function */
 type CreatePostTxResult struct {
 	Post Post
+	Tags []string
 }
 
-/* CreatePostTx creates a new post and executes the callback within a database transaction */
+/* CreatePostTx creates a new post with its tags and first revision and executes the callback within a database transaction */
 func (store *SQLStore) CreatePostTx(ctx context.Context, arg CreatePostTxParams) (CreatePostTxResult, error) {
 	var result CreatePostTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
+		arg.Slug, err = availablePostSlug(ctx, q, arg.Username, uuid.Nil, arg.Title)
+		if err != nil {
+			return err
+		}
+
 		result.Post, err = q.CreateNewPost(ctx, arg.CreateNewPostParams)
 		if err != nil {
 			return err
 		}
 
+		if err = setPostTags(ctx, q, result.Post.ID, arg.Tags); err != nil {
+			return err
+		}
+
+		result.Tags, err = getPostTags(ctx, q, result.Post.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = recordPostRevision(ctx, q, result.Post); err != nil {
+			return err
+		}
+
+		if arg.AfterCreate == nil {
+			return nil
+		}
 		return arg.AfterCreate(result.Post)
 	})
 
@@ -2,10 +2,13 @@ package db
 
 import "context"
 
-/* CreateUserTxParams contains the input parameters of the CreateUserTx function */
+/*
+CreateUserTxParams contains the input parameters of the CreateUserTx function.
+AfterCreate receives the querier of the transaction so that the rows it writes are rolled back with the user.
+*/
 type CreateUserTxParams struct {
 	CreateNewUserParams
-	AfterCreate func(user User) error
+	AfterCreate func(querier Querier, user User) error
 }
 
 /* CreateUserTxResult is the result of the CreateUserTx function */
@@ -25,7 +28,10 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		return arg.AfterCreate(result.User)
+		if arg.AfterCreate == nil {
+			return nil
+		}
+		return arg.AfterCreate(q, result.User)
 	})
 
 	return result, err
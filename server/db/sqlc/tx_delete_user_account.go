@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+)
+
+/*
+DeleteUserAccountTx deletes a user with all of their comments, posts and sessions within a database transaction.
+The comments of other users on the posts are deleted with the posts, since comments do not cascade.
+The admins are locked first, like in UpdateUserRoleTx, so the last admin cannot be deleted.
+*/
+func (store *SQLStore) DeleteUserAccountTx(ctx context.Context, username string) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		admins, err := q.LockAdminUsernames(ctx)
+		if err != nil {
+			return err
+		}
+
+		user, err := q.GetUserByUsername(ctx, username)
+		if err != nil {
+			return err
+		}
+		if user.Role == UserRoleAdmin && len(admins) <= 1 {
+			return ErrLastAdmin
+		}
+
+		comments, err := q.GetCommentsByUserName(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if err = q.DeleteCommentByID(ctx, comment.ID); err != nil {
+				return err
+			}
+		}
+
+		posts, err := q.GetPostsByUserName(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			if err = q.DeleteCommentsByPostID(ctx, post.ID); err != nil {
+				return err
+			}
+			if err = q.DeletePostByID(ctx, post.ID); err != nil {
+				return err
+			}
+		}
+
+		sessions, err := q.GetUserSessionsByUsername(ctx, user.Username)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if err = q.DeleteSessionById(ctx, session.ID); err != nil {
+				return err
+			}
+		}
+
+		return q.DeleteUserAccount(ctx, user.Username)
+	})
+}
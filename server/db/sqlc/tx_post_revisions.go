@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+)
+
+/* recordPostRevision stores the title and body of a post as its next revision, unless they are those of its latest revision */
+func recordPostRevision(ctx context.Context, q *Queries, post Post) error {
+	latest, err := q.GetLatestPostRevision(ctx, post.ID)
+	if err != nil && !errors.Is(err, util.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && latest.Title == post.Title && latest.Body == post.Body {
+		return nil
+	}
+
+	_, err = q.CreatePostRevision(ctx, CreatePostRevisionParams{
+		PostID: post.ID,
+		Title:  post.Title,
+		Body:   post.Body,
+	})
+	return err
+}
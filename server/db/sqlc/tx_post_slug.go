@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/google/uuid"
+)
+
+const maxPostSlugLength = 80
+
+/* postSlugBase is the slug of a title before any suffix, titles without letters or digits become "post" */
+func postSlugBase(title string) string {
+	slug := util.Slugify(title)
+	if len(slug) > maxPostSlugLength {
+		slug = strings.TrimRight(slug[:maxPostSlugLength], "-")
+	}
+	if slug == "" {
+		return "post"
+	}
+	return slug
+}
+
+/*
+availablePostSlug returns the slug of title for a post of username, numbered from -2 when another post of
+the author has or had the same slug. A post can take back its own previous slugs, and uuid.Nil stands for a
+post that does not exist yet. The unique index on the slugs of an author still rejects concurrent writers.
+*/
+func availablePostSlug(ctx context.Context, q *Queries, username string, postID uuid.UUID, title string) (string, error) {
+	base := postSlugBase(title)
+	taken, err := q.ListTakenPostSlugs(ctx, ListTakenPostSlugsParams{
+		Username: username,
+		PostID:   postID,
+		Slug:     base,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+/* renamePost changes the title and slug of a post, the previous slug is kept so its permalink redirects */
+func renamePost(ctx context.Context, q *Queries, post Post, title string) (Post, error) {
+	slug, err := availablePostSlug(ctx, q, post.Username, post.ID, title)
+	if err != nil {
+		return Post{}, err
+	}
+
+	if slug != post.Slug {
+		err = q.AddPostSlugHistory(ctx, AddPostSlugHistoryParams{PostID: post.ID, Slug: post.Slug})
+		if err != nil {
+			return Post{}, err
+		}
+
+		// a slug taken back is current again and no longer redirects
+		err = q.DeletePostSlugHistory(ctx, DeletePostSlugHistoryParams{PostID: post.ID, Slug: slug})
+		if err != nil {
+			return Post{}, err
+		}
+	}
+
+	return q.UpdatePostTitle(ctx, UpdatePostTitleParams{
+		Title: title,
+		Slug:  slug,
+		ID:    post.ID,
+	})
+}
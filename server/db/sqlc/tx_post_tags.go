@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+/* setPostTags replaces the tags of a post with slugs, creating the tags that do not exist yet */
+func setPostTags(ctx context.Context, q *Queries, postID uuid.UUID, slugs []string) error {
+	if err := q.DeletePostTags(ctx, postID); err != nil {
+		return err
+	}
+
+	for _, slug := range slugs {
+		tag, err := q.UpsertTag(ctx, slug)
+		if err != nil {
+			return err
+		}
+
+		err = q.AddPostTag(ctx, AddPostTagParams{PostID: postID, TagID: tag.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* getPostTags returns the slugs of the tags of a post in alphabetical order */
+func getPostTags(ctx context.Context, q *Queries, postID uuid.UUID) ([]string, error) {
+	rows, err := q.GetTagsByPostIDs(ctx, []uuid.UUID{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{}
+	for _, row := range rows {
+		tags = append(tags, row.Slug)
+	}
+	return tags, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+/* ErrPasswordResetTokenUsed is returned by ResetPasswordTx when the reset token has already been consumed */
+var ErrPasswordResetTokenUsed = errors.New("password reset token has already been used")
+
+/* ResetPasswordTxParams contains the input parameters of the ResetPasswordTx function */
+type ResetPasswordTxParams struct {
+	TokenID        uuid.UUID
+	Username       string
+	HashedPassword string
+}
+
+/*
+ResetPasswordTx consumes the reset token, stores the new password, blocks every session of the user
+and deletes their personal access tokens within a database transaction.
+*/
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.MarkPasswordResetTokenAsUsed(ctx, arg.TokenID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrPasswordResetTokenUsed
+		}
+
+		err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Password: arg.HashedPassword,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return q.DeletePersonalAccessTokensByUsername(ctx, arg.Username)
+	})
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+/* RestorePostRevisionTxParams contains the input parameters of the RestorePostRevisionTx function, Version is the current version of the post */
+type RestorePostRevisionTxParams struct {
+	PostID       uuid.UUID
+	Number       int32
+	Username     string
+	LastModified string
+	Version      int32
+}
+
+/* RestorePostRevisionTxResult is the result of the RestorePostRevisionTx function */
+type RestorePostRevisionTxResult struct {
+	Post     Post
+	Revision PostRevision
+}
+
+/*
+RestorePostRevisionTx puts back the title and body of a revision within a database transaction.
+The restore is a new revision, so the revisions made since the restored one stay in the history.
+*/
+func (store *SQLStore) RestorePostRevisionTx(ctx context.Context, arg RestorePostRevisionTxParams) (RestorePostRevisionTxResult, error) {
+	var result RestorePostRevisionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		restored, err := q.GetPostRevision(ctx, GetPostRevisionParams{PostID: arg.PostID, Number: arg.Number})
+		if err != nil {
+			return err
+		}
+
+		result.Post, err = q.UpdatePostBody(ctx, UpdatePostBodyParams{
+			Body:         restored.Body,
+			LastModified: arg.LastModified,
+			ID:           arg.PostID,
+			Username:     arg.Username,
+			Version:      arg.Version,
+		})
+		if err != nil {
+			return err
+		}
+
+		if restored.Title != result.Post.Title {
+			result.Post, err = renamePost(ctx, q, result.Post, restored.Title)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Revision, err = q.CreatePostRevision(ctx, CreatePostRevisionParams{
+			PostID:       result.Post.ID,
+			Title:        result.Post.Title,
+			Body:         result.Post.Body,
+			RestoredFrom: pgtype.Int4{Int32: restored.Number, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
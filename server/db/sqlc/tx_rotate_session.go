@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+/* ErrRefreshTokenReused is returned by RotateSessionTx when the refresh token has already been exchanged */
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+/* RotateSessionTxParams contains the input parameters of the RotateSessionTx function */
+type RotateSessionTxParams struct {
+	PreviousSessionID uuid.UUID
+	CreateNewUserSessionParams
+}
+
+/* RotateSessionTxResult is the result of the RotateSessionTx function */
+type RotateSessionTxResult struct {
+	Session Session
+}
+
+/*
+RotateSessionTx marks the previous session as used and creates its successor within a database transaction.
+If the previous session was already used, every session in its family is blocked and ErrRefreshTokenReused is returned.
+*/
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+	reused := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.MarkSessionAsUsed(ctx, arg.PreviousSessionID)
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			reused = true
+			return q.BlockSessionFamily(ctx, arg.FamilyID)
+		}
+
+		result.Session, err = q.CreateNewUserSession(ctx, arg.CreateNewUserSessionParams)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if reused {
+		return result, ErrRefreshTokenReused
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import "context"
+
+/*
+UpdatePostTxParams contains the input parameters of the UpdatePostTx function.
+An empty Title keeps the title of the post and nil Tags keeps its tags.
+*/
+type UpdatePostTxParams struct {
+	UpdatePostBodyParams
+	Title string
+	Tags  []string
+}
+
+/* UpdatePostTxResult is the result of the UpdatePostTx function */
+type UpdatePostTxResult struct {
+	Post Post
+	Tags []string
+}
+
+/*
+UpdatePostTx updates the body and title of a post and replaces its tags within a database transaction.
+A change of the title or body is stored as a new revision.
+*/
+func (store *SQLStore) UpdatePostTx(ctx context.Context, arg UpdatePostTxParams) (UpdatePostTxResult, error) {
+	var result UpdatePostTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Post, err = q.UpdatePostBody(ctx, arg.UpdatePostBodyParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.Title != "" && arg.Title != result.Post.Title {
+			result.Post, err = renamePost(ctx, q, result.Post, arg.Title)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = recordPostRevision(ctx, q, result.Post); err != nil {
+			return err
+		}
+
+		if arg.Tags != nil {
+			if err = setPostTags(ctx, q, result.Post.ID, arg.Tags); err != nil {
+				return err
+			}
+		}
+
+		result.Tags, err = getPostTags(ctx, q, result.Post.ID)
+		return err
+	})
+
+	return result, err
+}
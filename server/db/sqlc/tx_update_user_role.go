@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+/* ErrLastAdmin is returned by UpdateUserRoleTx when the change would leave no admin */
+var ErrLastAdmin = errors.New("the last admin cannot be demoted")
+
+/*
+UpdateUserRoleTx changes the role of the user and, when the role is a different one, blocks every
+session of the user within a database transaction. The role is copied into the access tokens of a
+session, so blocking the sessions is what takes the old permissions away.
+The admins are locked first, so concurrent changes cannot demote every admin between them.
+*/
+func (store *SQLStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		admins, err := q.LockAdminUsernames(ctx)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.GetUserByUsername(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if user.Role == arg.Role {
+			return nil
+		}
+		if user.Role == UserRoleAdmin && len(admins) <= 1 {
+			return ErrLastAdmin
+		}
+
+		user, err = q.UpdateUserRole(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, user.Username)
+	})
+
+	return user, err
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+/* ErrMFAChallengeUsed is returned by UseMFAChallengeTx when the challenge has already been used */
+var ErrMFAChallengeUsed = errors.New("mfa challenge has already been used")
+
+/* ErrSecondFactorInvalid is returned by UseMFAChallengeTx when the second factor is rejected */
+var ErrSecondFactorInvalid = errors.New("second factor is invalid")
+
+/*
+UseMFAChallengeTxParams contains the input parameters of the UseMFAChallengeTx function.
+VerifySecondFactor receives the querier of the transaction so that the code it consumes is rolled back with the challenge.
+*/
+type UseMFAChallengeTxParams struct {
+	ChallengeID        uuid.UUID
+	VerifySecondFactor func(querier Querier) (bool, error)
+}
+
+/*
+UseMFAChallengeTx claims the challenge and then verifies the second factor within a database transaction.
+The claim locks the challenge, so concurrent requests with the same challenge cannot consume a code each,
+and a rejected second factor rolls the claim back so that the challenge can be retried.
+*/
+func (store *SQLStore) UseMFAChallengeTx(ctx context.Context, arg UseMFAChallengeTxParams) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		rows, err := q.MarkMFAChallengeAsUsed(ctx, arg.ChallengeID)
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrMFAChallengeUsed
+		}
+
+		verified, err := arg.VerifySecondFactor(q)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return ErrSecondFactorInvalid
+		}
+		return nil
+	})
+}
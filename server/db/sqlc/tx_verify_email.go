@@ -0,0 +1,21 @@
+package db
+
+import "context"
+
+/* VerifyEmailTx marks the email of the user as verified and deletes every outstanding verification token within a database transaction */
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, username string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.MarkUserEmailAsVerified(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteEmailVerificationTokensByUsername(ctx, username)
+	})
+
+	return user, err
+}
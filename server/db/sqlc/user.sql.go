@@ -12,7 +12,7 @@ import (
 )
 
 const createNewUser = `-- name: CreateNewUser :one
-INSERT INTO users (username, password, email, first_name, last_name) VALUES ($1, $2, $3, $4, $5) RETURNING id, username, password, email, first_name, last_name, interests, created_at
+INSERT INTO users (username, password, email, first_name, last_name) VALUES ($1, $2, $3, $4, $5) RETURNING id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at
 `
 
 type CreateNewUserParams struct {
@@ -41,12 +41,14 @@ func (q *Queries) CreateNewUser(ctx context.Context, arg CreateNewUserParams) (U
 		&i.LastName,
 		&i.Interests,
 		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const deleteUserAccount = `-- name: DeleteUserAccount :exec
-DELETE FROM users WHERE username = $1
+DELETE FROM users WHERE lower(username) = lower($1)
 `
 
 func (q *Queries) DeleteUserAccount(ctx context.Context, username string) error {
@@ -55,7 +57,7 @@ func (q *Queries) DeleteUserAccount(ctx context.Context, username string) error
 }
 
 const getPostsByUserName = `-- name: GetPostsByUserName :many
-SELECT id, title, body, username, status, category, created_at, published_at, last_modified FROM posts WHERE username = $1
+SELECT id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version FROM posts WHERE lower(username) = lower($1)
 `
 
 func (q *Queries) GetPostsByUserName(ctx context.Context, username string) ([]Post, error) {
@@ -77,6 +79,9 @@ func (q *Queries) GetPostsByUserName(ctx context.Context, username string) ([]Po
 			&i.CreatedAt,
 			&i.PublishedAt,
 			&i.LastModified,
+			&i.OrderedAt,
+			&i.Slug,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -88,8 +93,30 @@ func (q *Queries) GetPostsByUserName(ctx context.Context, username string) ([]Po
 	return items, nil
 }
 
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at FROM users WHERE lower(email) = lower($1)
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.FirstName,
+		&i.LastName,
+		&i.Interests,
+		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, password, email, first_name, last_name, interests, created_at FROM users WHERE id = $1
+SELECT id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at FROM users WHERE id = $1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -104,12 +131,14 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.LastName,
 		&i.Interests,
 		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
 const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, email, first_name, last_name, interests, created_at FROM users WHERE username = $1
+SELECT id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at FROM users WHERE lower(username) = lower($1)
 `
 
 func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
@@ -124,12 +153,88 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.LastName,
 		&i.Interests,
 		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
 	)
 	return i, err
 }
 
+const lockAdminUsernames = `-- name: LockAdminUsernames :many
+SELECT username FROM users WHERE role = 'admin' FOR UPDATE
+`
+
+func (q *Queries) LockAdminUsernames(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, lockAdminUsernames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		items = append(items, username)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserByUsername = `-- name: LockUserByUsername :one
+SELECT username FROM users WHERE lower(username) = lower($1) FOR UPDATE
+`
+
+func (q *Queries) LockUserByUsername(ctx context.Context, username string) (string, error) {
+	row := q.db.QueryRow(ctx, lockUserByUsername, username)
+	err := row.Scan(&username)
+	return username, err
+}
+
+const markUserEmailAsVerified = `-- name: MarkUserEmailAsVerified :one
+UPDATE users SET email_verified_at = now() WHERE lower(username) = lower($1) RETURNING id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at
+`
+
+func (q *Queries) MarkUserEmailAsVerified(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, markUserEmailAsVerified, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.FirstName,
+		&i.LastName,
+		&i.Interests,
+		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :execrows
+UPDATE users SET password = $1 WHERE lower(username) = lower($2) AND password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewPassword string `json:"new_password"`
+	Username    string `json:"username"`
+	OldPassword string `json:"old_password"`
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rehashUserPassword, arg.NewPassword, arg.Username, arg.OldPassword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updatePostBody = `-- name: UpdatePostBody :one
-UPDATE posts SET body = $1, last_modified = $2 WHERE id = $3 AND username = $4 RETURNING id, title, body, username, status, category, created_at, published_at, last_modified
+UPDATE posts SET body = $1, last_modified = $2, version = version + 1 WHERE id = $3 AND lower(username) = lower($4) AND version = $5 RETURNING id, title, body, username, status, category, created_at, published_at, last_modified, ordered_at, slug, version
 `
 
 type UpdatePostBodyParams struct {
@@ -137,6 +242,7 @@ type UpdatePostBodyParams struct {
 	LastModified string    `json:"last_modified"`
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
+	Version      int32     `json:"version"`
 }
 
 func (q *Queries) UpdatePostBody(ctx context.Context, arg UpdatePostBodyParams) (Post, error) {
@@ -145,6 +251,7 @@ func (q *Queries) UpdatePostBody(ctx context.Context, arg UpdatePostBodyParams)
 		arg.LastModified,
 		arg.ID,
 		arg.Username,
+		arg.Version,
 	)
 	var i Post
 	err := row.Scan(
@@ -157,12 +264,15 @@ func (q *Queries) UpdatePostBody(ctx context.Context, arg UpdatePostBodyParams)
 		&i.CreatedAt,
 		&i.PublishedAt,
 		&i.LastModified,
+		&i.OrderedAt,
+		&i.Slug,
+		&i.Version,
 	)
 	return i, err
 }
 
 const updateUserInterestsByUsername = `-- name: UpdateUserInterestsByUsername :exec
-UPDATE users SET interests = $1 WHERE username = $2
+UPDATE users SET interests = $1 WHERE lower(username) = lower($2)
 `
 
 type UpdateUserInterestsByUsernameParams struct {
@@ -174,3 +284,44 @@ func (q *Queries) UpdateUserInterestsByUsername(ctx context.Context, arg UpdateU
 	_, err := q.db.Exec(ctx, updateUserInterestsByUsername, arg.Interests, arg.Username)
 	return err
 }
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users SET password = $1 WHERE lower(username) = lower($2)
+`
+
+type UpdateUserPasswordParams struct {
+	Password string `json:"password"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.Exec(ctx, updateUserPassword, arg.Password, arg.Username)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users SET role = $1 WHERE lower(username) = lower($2) RETURNING id, username, password, email, first_name, last_name, interests, created_at, role, email_verified_at
+`
+
+type UpdateUserRoleParams struct {
+	Role     UserRole `json:"role"`
+	Username string   `json:"username"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.Role, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Email,
+		&i.FirstName,
+		&i.LastName,
+		&i.Interests,
+		&i.CreatedAt,
+		&i.Role,
+		&i.EmailVerifiedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserIdentityCRUDOperations(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testIdentityUser", "testIdentityUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test CreateUserIdentity */
+	identity, err := testStore.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		Username: user.Username,
+		Provider: "test",
+		Subject:  "testIdentitySubject",
+		Email:    user.Email,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, identity.Username)
+
+	/* Test GetUserIdentity */
+	getIdentity, err := testStore.GetUserIdentity(ctx, GetUserIdentityParams{
+		Provider: "test",
+		Subject:  "testIdentitySubject",
+	})
+	require.NoError(t, err)
+	require.Equal(t, identity.ID, getIdentity.ID)
+
+	/* Test a subject of a provider can only be linked once */
+	_, err = testStore.CreateUserIdentity(ctx, CreateUserIdentityParams{
+		Username: user.Username,
+		Provider: "test",
+		Subject:  "testIdentitySubject",
+		Email:    user.Email,
+	})
+	require.Equal(t, util.UniqueViolation, util.ErrorCode(err))
+
+	/* Test identities are deleted with the user */
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+	_, err = testStore.GetUserIdentity(ctx, GetUserIdentityParams{
+		Provider: "test",
+		Subject:  "testIdentitySubject",
+	})
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+}
+
+func TestOIDCLoginStateCRUDOperations(t *testing.T) {
+	ctx := context.Background()
+
+	/* Test CreateOIDCLoginState */
+	loginState, err := testStore.CreateOIDCLoginState(ctx, CreateOIDCLoginStateParams{
+		StateHash:    "testOIDCStateHash",
+		Provider:     "test",
+		CodeVerifier: "testCodeVerifier",
+		Nonce:        "testNonce",
+		LinkUsername: pgtype.Text{},
+		ExpiresAt:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.False(t, loginState.LinkUsername.Valid)
+
+	/* Test ConsumeOIDCLoginState can only consume a state once */
+	consumedLoginState, err := testStore.ConsumeOIDCLoginState(ctx, "testOIDCStateHash")
+	require.NoError(t, err)
+	require.Equal(t, loginState.ID, consumedLoginState.ID)
+	require.Equal(t, "testCodeVerifier", consumedLoginState.CodeVerifier)
+
+	_, err = testStore.ConsumeOIDCLoginState(ctx, "testOIDCStateHash")
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+}
@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,6 +31,32 @@ func TestUserCRUDOperations(t *testing.T) {
 	require.Equal(t, arg.Email, user.Email)
 	require.Equal(t, arg.FirstName, user.FirstName)
 	require.Equal(t, arg.LastName, user.LastName)
+	require.Equal(t, UserRoleAuthor, user.Role)
+
+	/* Test UpdateUserRole */
+	updatedRoleUser, err := testStore.UpdateUserRole(context.Background(), UpdateUserRoleParams{
+		Role:     UserRoleModerator,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, UserRoleModerator, updatedRoleUser.Role)
+
+	/* Test RehashUserPassword only replaces the expected hash */
+	rows, err := testStore.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		NewPassword: "newHashedPassword",
+		Username:    user.Username,
+		OldPassword: "staleHashedPassword",
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	rows, err = testStore.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		NewPassword: "newHashedPassword",
+		Username:    user.Username,
+		OldPassword: user.Password,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
 
 	/* Test GetUserByID */
 	getUser, err := testStore.GetUserByID(context.Background(), user.ID)
@@ -59,9 +88,10 @@ func TestUserCRUDOperations(t *testing.T) {
 		_, err := testStore.CreateNewPost(context.Background(), CreateNewPostParams{
 			Username: user.Username,
 			Status:   StatusPublished,
-			Category: "News",
+			Category: "uncategorized",
 			Title:    "testTitle",
 			Body:     "testContent",
+			Slug:     fmt.Sprintf("testtitle-%d", i),
 		})
 		require.NoError(t, err)
 	}
@@ -96,6 +126,7 @@ func TestUserCRUDOperations(t *testing.T) {
 		Username: user.Username,
 		ID:       posts[0].ID,
 		Body:     newBody,
+		Version:  posts[0].Version,
 	})
 	require.NoError(t, err)
 	require.NotEmpty(t, updatedPost)
@@ -135,3 +166,210 @@ func TestUserCRUDOperations(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, getUserDeleted)
 }
+
+func TestChangePasswordTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testChangePasswordUser", "testChangePasswordUser@email.com"))
+	require.NoError(t, err)
+
+	otherSessionID := uuid.New()
+	_, err = testStore.CreateNewUserSession(ctx, createDummySession(otherSessionID, otherSessionID, user.Username))
+	require.NoError(t, err)
+	_, err = testStore.CreatePersonalAccessToken(ctx, CreatePersonalAccessTokenParams{
+		Username:  user.Username,
+		Name:      "ci",
+		TokenHash: "testChangePasswordPersonalAccessTokenHash",
+		Scopes:    []string{"read"},
+	})
+	require.NoError(t, err)
+
+	/* Test ChangePasswordTx */
+	newSessionID := uuid.New()
+	result, err := testStore.ChangePasswordTx(ctx, ChangePasswordTxParams{
+		HashedPassword:             "newHashedPassword",
+		CreateNewUserSessionParams: createDummySession(newSessionID, newSessionID, user.Username),
+	})
+	require.NoError(t, err)
+	require.Equal(t, newSessionID, result.Session.ID)
+	require.False(t, result.Session.IsBlocked)
+
+	updatedUser, err := testStore.GetUserByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, "newHashedPassword", updatedUser.Password)
+
+	otherSession, err := testStore.GetSessionById(ctx, otherSessionID)
+	require.NoError(t, err)
+	require.True(t, otherSession.IsBlocked)
+
+	/* A new password revokes the personal access tokens too */
+	personalAccessTokens, err := testStore.GetPersonalAccessTokensByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Empty(t, personalAccessTokens)
+
+	/* Teardown */
+	for _, sessionID := range []uuid.UUID{otherSessionID, newSessionID} {
+		err = testStore.DeleteSessionById(ctx, sessionID)
+		require.NoError(t, err)
+	}
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testUpdateRoleUser", "testUpdateRoleUser@email.com"))
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+	_, err = testStore.CreateNewUserSession(ctx, createDummySession(sessionID, sessionID, user.Username))
+	require.NoError(t, err)
+
+	/* Test UpdateUserRoleTx keeps the sessions when the role stays the same */
+	sameRoleUser, err := testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: user.Role, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, user.Role, sameRoleUser.Role)
+
+	session, err := testStore.GetSessionById(ctx, sessionID)
+	require.NoError(t, err)
+	require.False(t, session.IsBlocked)
+
+	/* Test UpdateUserRoleTx blocks the sessions when the role changes */
+	updatedUser, err := testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: UserRoleModerator, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, UserRoleModerator, updatedUser.Role)
+
+	session, err = testStore.GetSessionById(ctx, sessionID)
+	require.NoError(t, err)
+	require.True(t, session.IsBlocked)
+
+	/* Teardown */
+	err = testStore.DeleteSessionById(ctx, sessionID)
+	require.NoError(t, err)
+	err = testStore.DeleteUserAccount(ctx, user.Username)
+	require.NoError(t, err)
+}
+
+func TestUpdateUserRoleTxLastAdmin(t *testing.T) {
+	ctx := context.Background()
+	firstAdmin, err := testStore.CreateNewUser(ctx, createDummyUser("testFirstAdmin", "testFirstAdmin@email.com"))
+	require.NoError(t, err)
+	secondAdmin, err := testStore.CreateNewUser(ctx, createDummyUser("testSecondAdmin", "testSecondAdmin@email.com"))
+	require.NoError(t, err)
+
+	for _, user := range []User{firstAdmin, secondAdmin} {
+		_, err = testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: UserRoleAdmin, Username: user.Username})
+		require.NoError(t, err)
+	}
+
+	/* Test an admin can be demoted while another admin is left */
+	demoted, err := testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: UserRoleAuthor, Username: firstAdmin.Username})
+	require.NoError(t, err)
+	require.Equal(t, UserRoleAuthor, demoted.Role)
+
+	/* Test the last admin cannot be demoted */
+	admins, err := testStore.LockAdminUsernames(ctx)
+	require.NoError(t, err)
+	require.Contains(t, admins, secondAdmin.Username)
+	if len(admins) == 1 {
+		_, err = testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: UserRoleAuthor, Username: secondAdmin.Username})
+		require.ErrorIs(t, err, ErrLastAdmin)
+
+		user, err := testStore.GetUserByUsername(ctx, secondAdmin.Username)
+		require.NoError(t, err)
+		require.Equal(t, UserRoleAdmin, user.Role)
+	}
+
+	/* Teardown */
+	for _, user := range []User{firstAdmin, secondAdmin} {
+		err = testStore.DeleteUserAccount(ctx, user.Username)
+		require.NoError(t, err)
+	}
+}
+
+func TestCaseInsensitiveUsers(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testCaseUser", "testCaseUser@email.com"))
+	require.NoError(t, err)
+
+	/* Test GetUserByUsername and GetUserByEmail ignore case */
+	getUser, err := testStore.GetUserByUsername(ctx, "TESTCASEUSER")
+	require.NoError(t, err)
+	require.Equal(t, user.Username, getUser.Username)
+
+	getUser, err = testStore.GetUserByEmail(ctx, "TestCaseUser@Email.com")
+	require.NoError(t, err)
+	require.Equal(t, user.Username, getUser.Username)
+
+	/* Test usernames and emails that only differ in case are taken */
+	_, err = testStore.CreateNewUser(ctx, createDummyUser("testcaseuser", "otherCaseUser@email.com"))
+	require.Equal(t, util.UniqueViolation, util.ErrorCode(err))
+
+	_, err = testStore.CreateNewUser(ctx, createDummyUser("otherCaseUser", "TESTCASEUSER@email.com"))
+	require.Equal(t, util.UniqueViolation, util.ErrorCode(err))
+
+	err = testStore.DeleteUserAccount(ctx, "TestCaseUser")
+	require.NoError(t, err)
+	_, err = testStore.GetUserByUsername(ctx, user.Username)
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+}
+
+func TestDeleteUserAccountTx(t *testing.T) {
+	ctx := context.Background()
+	user, err := testStore.CreateNewUser(ctx, createDummyUser("testDeletedUser", "testDeletedUser@email.com"))
+	require.NoError(t, err)
+	commenter, err := testStore.CreateNewUser(ctx, createDummyUser("testCommenter", "testCommenter@email.com"))
+	require.NoError(t, err)
+
+	post, err := testStore.CreateNewPost(ctx, createDummyPost(t, user.ID, user.Username))
+	require.NoError(t, err)
+	commenterPost, err := testStore.CreateNewPost(ctx, createDummyPost(t, commenter.ID, commenter.Username))
+	require.NoError(t, err)
+	_, err = testStore.CreateNewUserSession(ctx, createDummySession(uuid.New(), uuid.New(), user.Username))
+	require.NoError(t, err)
+
+	/* Another user commented on the post of the deleted user, and the other way around */
+	_, err = testStore.CreateNewComment(ctx, CreateNewCommentParams{Username: commenter.Username, PostID: post.ID, Body: "test comment"})
+	require.NoError(t, err)
+	_, err = testStore.CreateNewComment(ctx, CreateNewCommentParams{Username: user.Username, PostID: commenterPost.ID, Body: "test comment"})
+	require.NoError(t, err)
+
+	err = testStore.DeleteUserAccountTx(ctx, user.Username)
+	require.NoError(t, err)
+
+	_, err = testStore.GetUserByUsername(ctx, user.Username)
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+	_, err = testStore.GetPostById(ctx, post.ID)
+	require.ErrorIs(t, err, util.ErrRecordNotFound)
+	sessions, err := testStore.GetUserSessionsByUsername(ctx, user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	/* The comments on the deleted post are gone, the post of the other user stays without the comment of the deleted user */
+	comments, err := testStore.GetCommentsByUserName(ctx, commenter.Username)
+	require.NoError(t, err)
+	require.Empty(t, comments)
+	_, err = testStore.GetPostById(ctx, commenterPost.ID)
+	require.NoError(t, err)
+	comments, err = testStore.ListCommentsByPostID(ctx, ListCommentsByPostIDParams{PostID: commenterPost.ID, PageLimit: 10})
+	require.NoError(t, err)
+	require.Empty(t, comments)
+
+	/* Test the last admin cannot be deleted */
+	_, err = testStore.UpdateUserRoleTx(ctx, UpdateUserRoleParams{Role: UserRoleAdmin, Username: commenter.Username})
+	require.NoError(t, err)
+	admins, err := testStore.LockAdminUsernames(ctx)
+	require.NoError(t, err)
+	if len(admins) == 1 {
+		err = testStore.DeleteUserAccountTx(ctx, commenter.Username)
+		require.ErrorIs(t, err, ErrLastAdmin)
+
+		_, err = testStore.GetUserByUsername(ctx, commenter.Username)
+		require.NoError(t, err)
+	}
+
+	/* Teardown */
+	err = testStore.DeletePostByID(ctx, commenterPost.ID)
+	require.NoError(t, err)
+	err = testStore.DeleteUserAccount(ctx, commenter.Username)
+	require.NoError(t, err)
+}
@@ -0,0 +1,94 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+/* Policy controls how quickly a key is slowed down and locked out */
+type Policy struct {
+	/* MaxFailures is the number of failures after which the key is locked out */
+	MaxFailures int
+	/* BaseBackoff is the delay after the first failure, it doubles with every further failure */
+	BaseBackoff time.Duration
+	/* LockoutDuration is how long the key is locked out, failures older than this are forgotten */
+	LockoutDuration time.Duration
+}
+
+/* Result describes the state of a key after a failure has been recorded */
+type Result struct {
+	Failures   int
+	RetryAfter time.Duration
+	LockedOut  bool
+}
+
+/* Limiter applies exponential backoff and temporary lockouts on top of a Store */
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+/* NewLimiter creates a new limiter */
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+/* RetryAfter returns how long the caller has to wait before any of the keys may be tried again, zero when none is blocked */
+func (limiter *Limiter) RetryAfter(ctx context.Context, keys ...string) (time.Duration, error) {
+	now := limiter.now()
+	var retryAfter time.Duration
+	for _, key := range keys {
+		attempt, err := limiter.store.GetAttempt(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if wait := attempt.BlockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	return retryAfter, nil
+}
+
+/* RecordFailure records a failure for the key and blocks it according to the policy */
+func (limiter *Limiter) RecordFailure(ctx context.Context, key string, policy Policy) (Result, error) {
+	now := limiter.now()
+	attempt, err := limiter.store.RecordFailure(ctx, key, now, now.Add(-policy.LockoutDuration))
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Failures:   attempt.Failures,
+		RetryAfter: policy.backoff(attempt.Failures),
+		LockedOut:  attempt.Failures >= policy.MaxFailures,
+	}
+	if result.LockedOut {
+		result.RetryAfter = policy.LockoutDuration
+	}
+
+	err = limiter.store.Block(ctx, key, now.Add(result.RetryAfter))
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+/* Reset forgets every failure of the key */
+func (limiter *Limiter) Reset(ctx context.Context, key string) error {
+	return limiter.store.Reset(ctx, key)
+}
+
+/* backoff returns BaseBackoff doubled for every failure after the first, capped at LockoutDuration */
+func (policy Policy) backoff(failures int) time.Duration {
+	backoff := policy.BaseBackoff
+	for i := 1; i < failures && backoff < policy.LockoutDuration; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.LockoutDuration {
+		backoff = policy.LockoutDuration
+	}
+	return backoff
+}
@@ -0,0 +1,105 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter(NewMemoryStore())
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := newTestLimiter(&now)
+	policy := Policy{MaxFailures: 4, BaseBackoff: time.Second, LockoutDuration: time.Minute}
+
+	retryAfter, err := limiter.RetryAfter(ctx, "username:testUser")
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+
+	/* The delay doubles with every failure */
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		result, err := limiter.RecordFailure(ctx, "username:testUser", policy)
+		require.NoError(t, err)
+		require.Equal(t, i+1, result.Failures)
+		require.Equal(t, expected, result.RetryAfter)
+		require.False(t, result.LockedOut)
+
+		retryAfter, err = limiter.RetryAfter(ctx, "username:testUser", "ip:127.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, expected, retryAfter)
+	}
+
+	/* Reaching MaxFailures locks the key out */
+	result, err := limiter.RecordFailure(ctx, "username:testUser", policy)
+	require.NoError(t, err)
+	require.True(t, result.LockedOut)
+	require.Equal(t, time.Minute, result.RetryAfter)
+
+	/* Other keys are not affected */
+	retryAfter, err = limiter.RetryAfter(ctx, "username:otherUser")
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+
+	now = now.Add(30 * time.Second)
+	retryAfter, err = limiter.RetryAfter(ctx, "username:testUser")
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, retryAfter)
+
+	now = now.Add(30 * time.Second)
+	retryAfter, err = limiter.RetryAfter(ctx, "username:testUser")
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+}
+
+func TestLimiterForgetsOldFailures(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := newTestLimiter(&now)
+	policy := Policy{MaxFailures: 3, BaseBackoff: time.Second, LockoutDuration: time.Minute}
+
+	for i := 0; i < 2; i++ {
+		_, err := limiter.RecordFailure(ctx, "ip:127.0.0.1", policy)
+		require.NoError(t, err)
+	}
+
+	/* Failures older than LockoutDuration are forgotten */
+	now = now.Add(2 * time.Minute)
+	result, err := limiter.RecordFailure(ctx, "ip:127.0.0.1", policy)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Failures)
+	require.False(t, result.LockedOut)
+}
+
+func TestLimiterReset(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	limiter := newTestLimiter(&now)
+	policy := Policy{MaxFailures: 1, BaseBackoff: time.Second, LockoutDuration: time.Minute}
+
+	result, err := limiter.RecordFailure(ctx, "username:testUser", policy)
+	require.NoError(t, err)
+	require.True(t, result.LockedOut)
+
+	err = limiter.Reset(ctx, "username:testUser")
+	require.NoError(t, err)
+
+	retryAfter, err := limiter.RetryAfter(ctx, "username:testUser")
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+}
+
+func TestPolicyBackoffIsCapped(t *testing.T) {
+	policy := Policy{MaxFailures: 100, BaseBackoff: time.Second, LockoutDuration: 10 * time.Second}
+	require.Equal(t, time.Second, policy.backoff(1))
+	require.Equal(t, 8*time.Second, policy.backoff(4))
+	require.Equal(t, 10*time.Second, policy.backoff(5))
+	require.Equal(t, 10*time.Second, policy.backoff(99))
+}
@@ -0,0 +1,81 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const maxMemoryStoreEntries = 100000
+
+/* MemoryStore keeps failure counters in memory, the counters are not shared between server instances */
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+/* NewMemoryStore creates a new in-memory store */
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		attempts: make(map[string]Attempt),
+	}
+}
+
+/* GetAttempt returns the counter of the key */
+func (store *MemoryStore) GetAttempt(ctx context.Context, key string) (Attempt, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.attempts[key], nil
+}
+
+/* RecordFailure increments the counter of the key */
+func (store *MemoryStore) RecordFailure(ctx context.Context, key string, failedAt time.Time, resetBefore time.Time) (Attempt, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if len(store.attempts) >= maxMemoryStoreEntries {
+		store.prune(failedAt, resetBefore)
+	}
+
+	attempt, ok := store.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(resetBefore) {
+		attempt = Attempt{Key: key, BlockedUntil: attempt.BlockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = failedAt
+	store.attempts[key] = attempt
+	return attempt, nil
+}
+
+/* Block blocks the key until the given time */
+func (store *MemoryStore) Block(ctx context.Context, key string, until time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	attempt, ok := store.attempts[key]
+	if !ok {
+		return nil
+	}
+	if until.After(attempt.BlockedUntil) {
+		attempt.BlockedUntil = until
+		store.attempts[key] = attempt
+	}
+	return nil
+}
+
+/* Reset deletes the counter of the key */
+func (store *MemoryStore) Reset(ctx context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.attempts, key)
+	return nil
+}
+
+/* prune deletes every counter that would start over and is no longer blocked */
+func (store *MemoryStore) prune(now time.Time, resetBefore time.Time) {
+	for key, attempt := range store.attempts {
+		if attempt.LastFailureAt.Before(resetBefore) && !attempt.BlockedUntil.After(now) {
+			delete(store.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+)
+
+/* PostgresStore keeps failure counters in the login_attempts table so that every server instance shares them */
+type PostgresStore struct {
+	querier db.Querier
+}
+
+/* NewPostgresStore creates a new Postgres store */
+func NewPostgresStore(querier db.Querier) *PostgresStore {
+	return &PostgresStore{
+		querier: querier,
+	}
+}
+
+/* GetAttempt returns the counter of the key */
+func (store *PostgresStore) GetAttempt(ctx context.Context, key string) (Attempt, error) {
+	loginAttempt, err := store.querier.GetLoginAttempt(ctx, key)
+	if err != nil {
+		if errors.Is(err, util.ErrRecordNotFound) {
+			return Attempt{}, nil
+		}
+		return Attempt{}, err
+	}
+	return newAttempt(loginAttempt), nil
+}
+
+/* RecordFailure increments the counter of the key with a single upsert */
+func (store *PostgresStore) RecordFailure(ctx context.Context, key string, failedAt time.Time, resetBefore time.Time) (Attempt, error) {
+	loginAttempt, err := store.querier.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+		Key:         key,
+		FailedAt:    failedAt,
+		ResetBefore: resetBefore,
+	})
+	if err != nil {
+		return Attempt{}, err
+	}
+	return newAttempt(loginAttempt), nil
+}
+
+/* Block blocks the key until the given time */
+func (store *PostgresStore) Block(ctx context.Context, key string, until time.Time) error {
+	return store.querier.BlockLoginAttempt(ctx, db.BlockLoginAttemptParams{
+		BlockedUntil: until,
+		Key:          key,
+	})
+}
+
+/* Reset deletes the counter of the key */
+func (store *PostgresStore) Reset(ctx context.Context, key string) error {
+	return store.querier.DeleteLoginAttempt(ctx, key)
+}
+
+func newAttempt(loginAttempt db.LoginAttempt) Attempt {
+	return Attempt{
+		Key:           loginAttempt.Key,
+		Failures:      int(loginAttempt.Failures),
+		LastFailureAt: loginAttempt.LastFailureAt,
+		BlockedUntil:  loginAttempt.BlockedUntil,
+	}
+}
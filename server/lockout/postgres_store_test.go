@@ -0,0 +1,70 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/Oabraham1/open-blogger/server/db/mock"
+	db "github.com/Oabraham1/open-blogger/server/db/sqlc"
+	"github.com/Oabraham1/open-blogger/server/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostgresStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Now()
+	querier := mockdb.NewMockStore(ctrl)
+	store := NewPostgresStore(querier)
+
+	/* Unknown keys have no failures */
+	querier.EXPECT().
+		GetLoginAttempt(gomock.Any(), gomock.Eq("username:unknown")).
+		Times(1).
+		Return(db.LoginAttempt{}, util.ErrRecordNotFound)
+	attempt, err := store.GetAttempt(ctx, "username:unknown")
+	require.NoError(t, err)
+	require.Zero(t, attempt.Failures)
+
+	querier.EXPECT().
+		GetLoginAttempt(gomock.Any(), gomock.Eq("username:broken")).
+		Times(1).
+		Return(db.LoginAttempt{}, sql.ErrConnDone)
+	_, err = store.GetAttempt(ctx, "username:broken")
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	querier.EXPECT().
+		RecordLoginFailure(gomock.Any(), gomock.Eq(db.RecordLoginFailureParams{
+			Key:         "username:testUser",
+			FailedAt:    now,
+			ResetBefore: now.Add(-time.Minute),
+		})).
+		Times(1).
+		Return(db.LoginAttempt{Key: "username:testUser", Failures: 3, LastFailureAt: now}, nil)
+	attempt, err = store.RecordFailure(ctx, "username:testUser", now, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 3, attempt.Failures)
+	require.Equal(t, now, attempt.LastFailureAt)
+
+	querier.EXPECT().
+		BlockLoginAttempt(gomock.Any(), gomock.Eq(db.BlockLoginAttemptParams{
+			BlockedUntil: now.Add(time.Minute),
+			Key:          "username:testUser",
+		})).
+		Times(1).
+		Return(nil)
+	err = store.Block(ctx, "username:testUser", now.Add(time.Minute))
+	require.NoError(t, err)
+
+	querier.EXPECT().
+		DeleteLoginAttempt(gomock.Any(), gomock.Eq("username:testUser")).
+		Times(1).
+		Return(nil)
+	err = store.Reset(ctx, "username:testUser")
+	require.NoError(t, err)
+}
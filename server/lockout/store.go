@@ -0,0 +1,32 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+/* Store types that can be selected with the LOGIN_ATTEMPT_STORE configuration */
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+/* Attempt is the failure counter of a key such as a username or a client IP */
+type Attempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  time.Time
+}
+
+/* Store is an interface for keeping failure counters */
+type Store interface {
+	/* GetAttempt returns the counter of the key, or a zero Attempt when the key has no failures */
+	GetAttempt(ctx context.Context, key string) (Attempt, error)
+	/* RecordFailure atomically increments the counter of the key, starting over when the last failure happened before resetBefore */
+	RecordFailure(ctx context.Context, key string, failedAt time.Time, resetBefore time.Time) (Attempt, error)
+	/* Block blocks the key until the given time unless it is already blocked for longer */
+	Block(ctx context.Context, key string, until time.Time) error
+	/* Reset deletes the counter of the key */
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/* FileMailer writes every email to a file in a directory, it is meant for development */
+type FileMailer struct {
+	Directory string
+	Sender    string
+}
+
+/* NewFileMailer creates a new file mailer and the directory the emails are written to */
+func NewFileMailer(directory string, sender string) (Mailer, error) {
+	if directory == "" {
+		return nil, fmt.Errorf("mail directory is required")
+	}
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	mailer := &FileMailer{
+		Directory: directory,
+		Sender:    sender,
+	}
+	return mailer, nil
+}
+
+/* SendEmail writes the email to a new .eml file */
+func (m *FileMailer) SendEmail(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.NewString())
+	return os.WriteFile(filepath.Join(m.Directory, name), email.message(m.Sender), 0o600)
+}
@@ -0,0 +1,29 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+/*
+LogMailer sends nothing, it logs the recipients and subject of every email so a server without a
+configured mailer shows what it did not send. The body is left out since it can hold secret links.
+*/
+type LogMailer struct {
+	Logger *log.Logger
+}
+
+/* NewLogMailer creates a new mailer that writes to logger */
+func NewLogMailer(logger *log.Logger) Mailer {
+	return &LogMailer{Logger: logger}
+}
+
+/* SendEmail logs the email without sending it */
+func (m *LogMailer) SendEmail(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.Logger.Printf("no mailer is configured, email %q to %s was not sent", email.Subject, strings.Join(email.To, ", "))
+	return nil
+}
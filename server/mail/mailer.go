@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+)
+
+/* Mailer types that can be selected with the MAILER configuration */
+const (
+	MailerSMTP   = "smtp"
+	MailerFile   = "file"
+	MailerMemory = "memory"
+	MailerLog    = "log"
+)
+
+/* Email is a plain text email */
+type Email struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+/* Mailer is an interface for sending emails */
+type Mailer interface {
+	SendEmail(ctx context.Context, email Email) error
+}
+
+/* message renders the email as an RFC 5322 message */
+func (email Email) message(from string) []byte {
+	var to string
+	for i, address := range email.To {
+		if i > 0 {
+			to += ", "
+		}
+		to += address
+	}
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=\"utf-8\"\r\n\r\n%s\r\n",
+		from, to, email.Subject, email.Body,
+	))
+}
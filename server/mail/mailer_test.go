@@ -0,0 +1,85 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testEmail() Email {
+	return Email{
+		To:      []string{"test@email.com", "other@email.com"},
+		Subject: "Test subject",
+		Body:    "Test body",
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	require.Empty(t, mailer.Emails())
+
+	err := mailer.SendEmail(context.Background(), testEmail())
+	require.NoError(t, err)
+	require.Equal(t, []Email{testEmail()}, mailer.Emails())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = mailer.SendEmail(ctx, testEmail())
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, mailer.Emails(), 1)
+}
+
+func TestLogMailer(t *testing.T) {
+	var out bytes.Buffer
+	mailer := NewLogMailer(log.New(&out, "", 0))
+
+	err := mailer.SendEmail(context.Background(), testEmail())
+	require.NoError(t, err)
+	require.Equal(t, "no mailer is configured, email \"Test subject\" to test@email.com, other@email.com was not sent\n", out.String())
+	require.NotContains(t, out.String(), testEmail().Body)
+}
+
+func TestFileMailer(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(directory, "noreply@open-blogger.com")
+	require.NoError(t, err)
+
+	err = mailer.SendEmail(context.Background(), testEmail())
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	data, err := os.ReadFile(filepath.Join(directory, files[0].Name()))
+	require.NoError(t, err)
+	message := string(data)
+	require.Contains(t, message, "From: noreply@open-blogger.com\r\n")
+	require.Contains(t, message, "To: test@email.com, other@email.com\r\n")
+	require.Contains(t, message, "Subject: Test subject\r\n")
+	require.Contains(t, message, "\r\n\r\nTest body")
+
+	_, err = NewFileMailer("", "noreply@open-blogger.com")
+	require.Error(t, err)
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	mailer, err := NewSMTPMailer("smtp.example.com", 587, "user", "password", "noreply@open-blogger.com")
+	require.NoError(t, err)
+	require.Equal(t, "smtp.example.com:587", mailer.(*SMTPMailer).Address)
+	require.NotNil(t, mailer.(*SMTPMailer).Auth)
+
+	mailer, err = NewSMTPMailer("localhost", 25, "", "", "noreply@open-blogger.com")
+	require.NoError(t, err)
+	require.Nil(t, mailer.(*SMTPMailer).Auth)
+
+	_, err = NewSMTPMailer("", 25, "", "", "noreply@open-blogger.com")
+	require.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"context"
+	"sync"
+)
+
+/* MemoryMailer keeps every email in memory, it is meant for tests */
+type MemoryMailer struct {
+	mu     sync.Mutex
+	emails []Email
+}
+
+/* NewMemoryMailer creates a new in-memory mailer */
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+/* SendEmail stores the email */
+func (m *MemoryMailer) SendEmail(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+/* Emails returns every email sent so far */
+func (m *MemoryMailer) Emails() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Email(nil), m.emails...)
+}
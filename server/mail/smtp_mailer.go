@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+)
+
+/* SMTPMailer sends emails through an SMTP server */
+type SMTPMailer struct {
+	Address string
+	Auth    smtp.Auth
+	Sender  string
+}
+
+/* NewSMTPMailer creates a new SMTP mailer, authentication is skipped when username is empty */
+func NewSMTPMailer(host string, port int, username string, password string, sender string) (Mailer, error) {
+	if host == "" || sender == "" {
+		return nil, fmt.Errorf("smtp host and sender are required")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	mailer := &SMTPMailer{
+		Address: fmt.Sprintf("%s:%d", host, port),
+		Auth:    auth,
+		Sender:  sender,
+	}
+	return mailer, nil
+}
+
+/* SendEmail sends an email */
+func (m *SMTPMailer) SendEmail(ctx context.Context, email Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(m.Address, m.Auth, m.Sender, email.To, email.message(m.Sender))
+}
@@ -0,0 +1,286 @@
+/*
+Package oidctest provides an in-process OpenID Connect issuer for tests.
+It implements discovery, the authorization endpoint, the token endpoint with PKCE and a JWKS endpoint.
+*/
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	signingKeyID    = "test-key"
+	idTokenDuration = 5 * time.Minute
+)
+
+/* User is the account that signs in at the issuer */
+type User struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	GivenName         string
+	FamilyName        string
+	PreferredUsername string
+}
+
+type authorization struct {
+	user          User
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+/* Issuer is a fake OpenID Connect provider served by an httptest server */
+type Issuer struct {
+	Server       *httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	// Nonce overrides the nonce put into ID tokens when it is not empty
+	Nonce string
+
+	privateKey *rsa.PrivateKey
+	mu         sync.Mutex
+	user       User
+	codes      map[string]authorization
+}
+
+/* NewIssuer starts a new issuer for the client, it must be closed by the caller */
+func NewIssuer(clientID string, clientSecret string) (*Issuer, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &Issuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		privateKey:   privateKey,
+		codes:        make(map[string]authorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.handleDiscovery)
+	mux.HandleFunc("/authorize", issuer.handleAuthorize)
+	mux.HandleFunc("/token", issuer.handleToken)
+	mux.HandleFunc("/jwks", issuer.handleJWKS)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer, nil
+}
+
+/* URL returns the issuer identifier */
+func (issuer *Issuer) URL() string {
+	return issuer.Server.URL
+}
+
+/* Close shuts the issuer down */
+func (issuer *Issuer) Close() {
+	issuer.Server.Close()
+}
+
+/* SetUser sets the account that signs in at the authorization endpoint */
+func (issuer *Issuer) SetUser(user User) {
+	issuer.mu.Lock()
+	defer issuer.mu.Unlock()
+	issuer.user = user
+}
+
+/*
+Authorize follows the authorization URL like a browser of a user that signs in and consents.
+It returns the authorization code and the state the issuer redirected back with.
+*/
+func (issuer *Issuer) Authorize(authorizationURL string) (code string, state string, err error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	response, err := client.Get(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return "", "", fmt.Errorf("authorization endpoint returned %d", response.StatusCode)
+	}
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	query := location.Query()
+	if query.Get("error") != "" {
+		return "", "", errors.New(query.Get("error"))
+	}
+	return query.Get("code"), query.Get("state"), nil
+}
+
+func (issuer *Issuer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                issuer.URL(),
+		"authorization_endpoint":                issuer.URL() + "/authorize",
+		"token_endpoint":                        issuer.URL() + "/token",
+		"jwks_uri":                              issuer.URL() + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (issuer *Issuer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	redirectQuery := redirectURI.Query()
+	redirectQuery.Set("state", query.Get("state"))
+	switch {
+	case query.Get("client_id") != issuer.ClientID:
+		redirectQuery.Set("error", "unauthorized_client")
+	case query.Get("response_type") != "code":
+		redirectQuery.Set("error", "unsupported_response_type")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		redirectQuery.Set("error", "invalid_request")
+	default:
+		code, err := randomString()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		issuer.mu.Lock()
+		issuer.codes[code] = authorization{
+			user:          issuer.user,
+			clientID:      query.Get("client_id"),
+			redirectURI:   query.Get("redirect_uri"),
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+		}
+		issuer.mu.Unlock()
+		redirectQuery.Set("code", code)
+	}
+
+	redirectURI.RawQuery = redirectQuery.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+func (issuer *Issuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "invalid_request"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// codes can only be redeemed once
+	issuer.mu.Lock()
+	auth, ok := issuer.codes[r.PostForm.Get("code")]
+	delete(issuer.codes, r.PostForm.Get("code"))
+	issuer.mu.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	case r.PostForm.Get("client_id") != issuer.ClientID || r.PostForm.Get("client_secret") != issuer.ClientSecret:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	case !ok || auth.clientID != r.PostForm.Get("client_id") || auth.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case codeChallengeS256(r.PostForm.Get("code_verifier")) != auth.codeChallenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	nonce := auth.nonce
+	if issuer.Nonce != "" {
+		nonce = issuer.Nonce
+	}
+	idToken, err := issuer.SignIDToken(auth.user, auth.clientID, nonce, time.Now().Add(idTokenDuration))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "test-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   int(idTokenDuration.Seconds()),
+		"id_token":     idToken,
+	})
+}
+
+/* SignIDToken signs an ID token for the user and audience with the key published by the issuer */
+func (issuer *Issuer) SignIDToken(user User, audience string, nonce string, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                issuer.URL(),
+		"sub":                user.Subject,
+		"aud":                audience,
+		"iat":                time.Now().Unix(),
+		"exp":                expiresAt.Unix(),
+		"nonce":              nonce,
+		"email":              user.Email,
+		"email_verified":     user.EmailVerified,
+		"given_name":         user.GivenName,
+		"family_name":        user.FamilyName,
+		"preferred_username": user.PreferredUsername,
+	})
+	token.Header["kid"] = signingKeyID
+	return token.SignedString(issuer.privateKey)
+}
+
+func (issuer *Issuer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	publicKey := issuer.privateKey.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": signingKeyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func codeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,24 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+const codeVerifierSize = 32
+
+/* GenerateCodeVerifier creates a random PKCE code verifier as defined by RFC 7636 */
+func GenerateCodeVerifier() (string, error) {
+	buffer := make([]byte, codeVerifierSize)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+/* CodeChallengeS256 derives the S256 code challenge that is sent with the authorization request */
+func CodeChallengeS256(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
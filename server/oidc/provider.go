@@ -0,0 +1,304 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultHTTPTimeout    = 10 * time.Second
+	maxResponseSize       = 1 << 20
+	minKeyRefreshInterval = time.Minute
+)
+
+/* DefaultScopes are requested when the provider config does not list any */
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+/* Errors returned by Provider */
+var (
+	ErrInvalidIDToken = errors.New("id token is invalid")
+	ErrNonceMismatch  = errors.New("id token nonce does not match")
+)
+
+/* Config contains the client registration of an OpenID Connect provider */
+type Config struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+/* IDTokenClaims are the verified claims of an ID token that are used to find or create an account */
+type IDTokenClaims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	GivenName         string
+	FamilyName        string
+	PreferredUsername string
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+/*
+Provider is an OpenID Connect relying party for the authorization code flow with PKCE.
+The discovery document and signing keys are fetched on first use and cached, unknown key ids trigger a refetch of the keys.
+*/
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+/* NewProvider creates a new provider, a nil http client falls back to a client with a timeout */
+func NewProvider(config Config, httpClient *http.Client) (*Provider, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("provider name is required")
+	}
+	if config.IssuerURL == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, fmt.Errorf("issuer url, client id and redirect url are required for provider %q", config.Name)
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	return &Provider{config: config, httpClient: httpClient}, nil
+}
+
+/* Name returns the name the provider is registered under */
+func (provider *Provider) Name() string {
+	return provider.config.Name
+}
+
+/* AuthCodeURL returns the URL of the authorization endpoint the user is sent to */
+func (provider *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.config.ClientID)
+	query.Set("redirect_uri", provider.config.RedirectURL)
+	query.Set("scope", strings.Join(provider.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+/* Exchange redeems the authorization code at the token endpoint and returns the verified claims of the ID token */
+func (provider *Provider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*IDTokenClaims, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectURL},
+		"client_id":     {provider.config.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+	if provider.config.ClientSecret != "" {
+		form.Set("client_secret", provider.config.ClientSecret)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	status, err := provider.doJSON(request, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d: %s %s", status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: token response has no id token", ErrInvalidIDToken)
+	}
+
+	return provider.verifyIDToken(ctx, token.IDToken, nonce)
+}
+
+/* verifyIDToken checks the signature, issuer, audience, expiry and nonce of the ID token */
+func (provider *Provider) verifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*IDTokenClaims, error) {
+	discovery, err := provider.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return provider.getKey(ctx, discovery.JWKSURI, keyID)
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(
+		rawIDToken,
+		claims,
+		keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.config.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	return &IDTokenClaims{
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		GivenName:         claims.GivenName,
+		FamilyName:        claims.FamilyName,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+func (provider *Provider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.discovery != nil {
+		return provider.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(provider.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery discoveryDocument
+	status, err := provider.doJSON(request, &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("discovery endpoint returned %d", status)
+	}
+	// the issuer must match exactly so that tokens of another issuer are never accepted
+	if discovery.Issuer != provider.config.IssuerURL {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", discovery.Issuer, provider.config.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %q is incomplete", provider.config.IssuerURL)
+	}
+
+	provider.discovery = &discovery
+	return provider.discovery, nil
+}
+
+func (provider *Provider) getKey(ctx context.Context, jwksURI string, keyID string) (interface{}, error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if key, ok := provider.keys[keyID]; ok {
+		return key, nil
+	}
+
+	// the provider may have rotated its keys, refetching is throttled so that forged key ids cannot flood the provider
+	if provider.keys != nil && time.Since(provider.keysFetchedAt) < minKeyRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", keyID)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jsonWebKeySet
+	status, err := provider.doJSON(request, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned %d", status)
+	}
+	provider.keys = set.publicKeys()
+	provider.keysFetchedAt = time.Now()
+
+	key, ok := provider.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", keyID)
+	}
+	return key, nil
+}
+
+/* doJSON sends the request and decodes the JSON response body, it returns the status code */
+func (provider *Provider) doJSON(request *http.Request, value interface{}) (int, error) {
+	response, err := provider.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		return response.StatusCode, fmt.Errorf("cannot decode response of %s: %w", request.URL, err)
+	}
+	return response.StatusCode, nil
+}
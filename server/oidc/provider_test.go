@@ -0,0 +1,153 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Oabraham1/open-blogger/server/oidc/oidctest"
+	"github.com/stretchr/testify/require"
+)
+
+const testRedirectURL = "http://localhost:3000/oidc/callback"
+
+var testUser = oidctest.User{
+	Subject:       "subject-1",
+	Email:         "oidc@email.com",
+	EmailVerified: true,
+	GivenName:     "oidc",
+	FamilyName:    "user",
+}
+
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	issuer, err := oidctest.NewIssuer("test-client", "test-secret")
+	require.NoError(t, err)
+	t.Cleanup(issuer.Close)
+	issuer.SetUser(testUser)
+
+	provider, err := NewProvider(Config{
+		Name:         "test",
+		IssuerURL:    issuer.URL(),
+		ClientID:     "test-client",
+		ClientSecret: "test-secret",
+		RedirectURL:  testRedirectURL,
+	}, nil)
+	require.NoError(t, err)
+	return provider, issuer
+}
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(Config{IssuerURL: "http://issuer", ClientID: "client", RedirectURL: testRedirectURL}, nil)
+	require.Error(t, err)
+
+	_, err = NewProvider(Config{Name: "test", ClientID: "client", RedirectURL: testRedirectURL}, nil)
+	require.Error(t, err)
+
+	provider, err := NewProvider(Config{Name: "test", IssuerURL: "http://issuer", ClientID: "client", RedirectURL: testRedirectURL}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "test", provider.Name())
+	require.Equal(t, DefaultScopes, provider.config.Scopes)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+
+	codeVerifier, err := GenerateCodeVerifier()
+	require.NoError(t, err)
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, "test-state", "test-nonce", CodeChallengeS256(codeVerifier))
+	require.NoError(t, err)
+
+	parsedURL, err := url.Parse(authorizationURL)
+	require.NoError(t, err)
+	require.Equal(t, "S256", parsedURL.Query().Get("code_challenge_method"))
+	require.Equal(t, "openid email profile", parsedURL.Query().Get("scope"))
+	require.Equal(t, testRedirectURL, parsedURL.Query().Get("redirect_uri"))
+
+	code, state, err := issuer.Authorize(authorizationURL)
+	require.NoError(t, err)
+	require.Equal(t, "test-state", state)
+
+	claims, err := provider.Exchange(ctx, code, codeVerifier, "test-nonce")
+	require.NoError(t, err)
+	require.Equal(t, testUser.Subject, claims.Subject)
+	require.Equal(t, testUser.Email, claims.Email)
+	require.True(t, claims.EmailVerified)
+	require.Equal(t, testUser.GivenName, claims.GivenName)
+
+	/* Codes can only be redeemed once */
+	_, err = provider.Exchange(ctx, code, codeVerifier, "test-nonce")
+	require.Error(t, err)
+}
+
+func TestExchangeWithWrongCodeVerifier(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+
+	codeVerifier, err := GenerateCodeVerifier()
+	require.NoError(t, err)
+	authorizationURL, err := provider.AuthCodeURL(ctx, "test-state", "test-nonce", CodeChallengeS256(codeVerifier))
+	require.NoError(t, err)
+	code, _, err := issuer.Authorize(authorizationURL)
+	require.NoError(t, err)
+
+	otherCodeVerifier, err := GenerateCodeVerifier()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, code, otherCodeVerifier, "test-nonce")
+	require.Error(t, err)
+}
+
+func TestExchangeWithWrongNonce(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+	issuer.Nonce = "replayed-nonce"
+
+	codeVerifier, err := GenerateCodeVerifier()
+	require.NoError(t, err)
+	authorizationURL, err := provider.AuthCodeURL(ctx, "test-state", "test-nonce", CodeChallengeS256(codeVerifier))
+	require.NoError(t, err)
+	code, _, err := issuer.Authorize(authorizationURL)
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, codeVerifier, "test-nonce")
+	require.ErrorIs(t, err, ErrNonceMismatch)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider, issuer := newTestProvider(t)
+	ctx := context.Background()
+
+	idToken, err := issuer.SignIDToken(testUser, "test-client", "test-nonce", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	claims, err := provider.verifyIDToken(ctx, idToken, "test-nonce")
+	require.NoError(t, err)
+	require.Equal(t, testUser.Subject, claims.Subject)
+
+	/* Expired tokens are rejected */
+	expiredIDToken, err := issuer.SignIDToken(testUser, "test-client", "test-nonce", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = provider.verifyIDToken(ctx, expiredIDToken, "test-nonce")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	/* Tokens signed by another issuer are rejected */
+	otherIssuer, err := oidctest.NewIssuer("test-client", "test-secret")
+	require.NoError(t, err)
+	defer otherIssuer.Close()
+	foreignIDToken, err := otherIssuer.SignIDToken(testUser, "test-client", "test-nonce", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, err = provider.verifyIDToken(ctx, foreignIDToken, "test-nonce")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	/* Tokens for another client are rejected */
+	otherClientIDToken, err := issuer.SignIDToken(testUser, "other-client", "test-nonce", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, err = provider.verifyIDToken(ctx, otherClientIDToken, "test-nonce")
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	/* Test vector of RFC 7636 appendix B */
+	require.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -11,13 +11,58 @@ Config stores all configuration of the application.
 The values are read by viper from a config file or environment variable.
 */
 type Config struct {
-	Environment          string        `mapstructure:"ENVIRONMENT"`
-	DB_URL               string        `mapstructure:"DB_URL"`
-	HTTPServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYM_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	MongoURI             string        `mapstructure:"MONGO_URI"`
+	Environment                     string        `mapstructure:"ENVIRONMENT"`
+	DB_URL                          string        `mapstructure:"DB_URL"`
+	HTTPServerAddress               string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	TokenFormat                     string        `mapstructure:"TOKEN_FORMAT"`
+	TokenSymmetricKey               string        `mapstructure:"TOKEN_SYM_KEY"`
+	TokenSigningKeyID               string        `mapstructure:"TOKEN_SIGNING_KEY_ID"`
+	TokenSigningKey                 string        `mapstructure:"TOKEN_SIGNING_KEY"`
+	TokenVerificationKeys           string        `mapstructure:"TOKEN_VERIFICATION_KEYS"`
+	TokenIssuer                     string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience                   string        `mapstructure:"TOKEN_AUDIENCE"`
+	AccessTokenDuration             time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration            time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	SessionCacheTTL                 time.Duration `mapstructure:"SESSION_CACHE_TTL"`
+	Argon2Memory                    uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations                uint32        `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism               uint8         `mapstructure:"ARGON2_PARALLELISM"`
+	PasswordMinLength               int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordResetTokenDuration      time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	PasswordResetRequestInterval    time.Duration `mapstructure:"PASSWORD_RESET_REQUEST_INTERVAL"`
+	PasswordResetMaxRequestsPerIP   int           `mapstructure:"PASSWORD_RESET_MAX_REQUESTS_PER_IP"`
+	RequireEmailVerification        bool          `mapstructure:"REQUIRE_EMAIL_VERIFICATION"`
+	EmailVerificationTokenDuration  time.Duration `mapstructure:"EMAIL_VERIFICATION_TOKEN_DURATION"`
+	EmailVerificationResendInterval time.Duration `mapstructure:"EMAIL_VERIFICATION_RESEND_INTERVAL"`
+	TOTPEncryptionKey               string        `mapstructure:"TOTP_ENCRYPTION_KEY"`
+	MFAChallengeDuration            time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	LoginAttemptStore               string        `mapstructure:"LOGIN_ATTEMPT_STORE"`
+	LoginMaxFailuresPerUsername     int           `mapstructure:"LOGIN_MAX_FAILURES_PER_USERNAME"`
+	LoginMaxFailuresPerIP           int           `mapstructure:"LOGIN_MAX_FAILURES_PER_IP"`
+	LoginBackoffBase                time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`
+	LoginLockoutDuration            time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	AuthCookieMode                  bool          `mapstructure:"AUTH_COOKIE_MODE"`
+	AuthCookieDomain                string        `mapstructure:"AUTH_COOKIE_DOMAIN"`
+	AuthCookieSameSite              string        `mapstructure:"AUTH_COOKIE_SAME_SITE"`
+	RegistrationMode                string        `mapstructure:"REGISTRATION_MODE"`
+	InviteDuration                  time.Duration `mapstructure:"INVITE_DURATION"`
+	InviteQuota                     int           `mapstructure:"INVITE_QUOTA"`
+	OIDCProviderName                string        `mapstructure:"OIDC_PROVIDER_NAME"`
+	OIDCIssuerURL                   string        `mapstructure:"OIDC_ISSUER_URL"`
+	OIDCClientID                    string        `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret                string        `mapstructure:"OIDC_CLIENT_SECRET"`
+	OIDCRedirectURL                 string        `mapstructure:"OIDC_REDIRECT_URL"`
+	OIDCScopes                      string        `mapstructure:"OIDC_SCOPES"`
+	OIDCLoginStateDuration          time.Duration `mapstructure:"OIDC_LOGIN_STATE_DURATION"`
+	AppBaseURL                      string        `mapstructure:"APP_BASE_URL"`
+	Mailer                          string        `mapstructure:"MAILER"`
+	MailSender                      string        `mapstructure:"MAIL_SENDER"`
+	MailDirectory                   string        `mapstructure:"MAIL_DIRECTORY"`
+	SMTPHost                        string        `mapstructure:"SMTP_HOST"`
+	SMTPPort                        int           `mapstructure:"SMTP_PORT"`
+	SMTPUsername                    string        `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword                    string        `mapstructure:"SMTP_PASSWORD"`
+	MongoURI                        string        `mapstructure:"MONGO_URI"`
 }
 
 /* LoadConfig reads configuration from file or environment variables. */
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+/* ErrInvalidCiphertext is returned by SecretCipher.Decrypt when the ciphertext cannot be authenticated */
+var ErrInvalidCiphertext = errors.New("ciphertext is invalid")
+
+/* SecretCipher encrypts secrets at rest with AES-256-GCM */
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+/* NewSecretCipher creates a new SecretCipher from a 32 byte key */
+func NewSecretCipher(key string) (*SecretCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", 32)
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+/* Encrypt encrypts the plaintext and prepends the random nonce to the ciphertext */
+func (secretCipher *SecretCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, secretCipher.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return secretCipher.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+/* Decrypt decrypts a ciphertext created by Encrypt */
+func (secretCipher *SecretCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := secretCipher.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := secretCipher.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCipher(t *testing.T) {
+	secretCipher, err := NewSecretCipher("01234567890123456789012345678901")
+	require.NoError(t, err)
+
+	ciphertext, err := secretCipher.Encrypt([]byte("testSecret"))
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), "testSecret")
+
+	/* Every encryption uses a fresh nonce */
+	otherCiphertext, err := secretCipher.Encrypt([]byte("testSecret"))
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, otherCiphertext)
+
+	plaintext, err := secretCipher.Decrypt(ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, "testSecret", string(plaintext))
+
+	/* Tampered ciphertexts are rejected */
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	_, err = secretCipher.Decrypt(ciphertext)
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = secretCipher.Decrypt([]byte("short"))
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	/* A different key cannot decrypt the secret */
+	otherCipher, err := NewSecretCipher("10987654321098765432109876543210")
+	require.NoError(t, err)
+	_, err = otherCipher.Decrypt(otherCiphertext)
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = NewSecretCipher("tooShort")
+	require.Error(t, err)
+}
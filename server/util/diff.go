@@ -0,0 +1,236 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+/* DiffOp is what happened to a piece of text between the old and the new version */
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+/* DiffChange is a run of text with the same DiffOp */
+type DiffChange struct {
+	Op   DiffOp
+	Text string
+}
+
+const (
+	unifiedDiffContext = 3
+
+	// limits that keep the time and memory of a diff bounded, see diffTokens
+	maxDiffTokens = 100000
+	maxDiffEdits  = 1000
+)
+
+var ErrDiffTooLarge = errors.New("the texts are too large or too different to compare")
+
+var wordTokens = regexp.MustCompile(`\s+|\S+`)
+
+/*
+WordDiff compares two texts word by word. Whitespace is kept as its own token, so joining the
+equal and deleted changes gives back a and joining the equal and inserted changes gives back b.
+It returns ErrDiffTooLarge when the texts are too large or too different to compare.
+*/
+func WordDiff(a, b string) ([]DiffChange, error) {
+	edits, err := diffTokens(wordTokens.FindAllString(a, -1), wordTokens.FindAllString(b, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []DiffChange{}
+	for _, edit := range edits {
+		if n := len(changes); n > 0 && changes[n-1].Op == edit.Op {
+			changes[n-1].Text += edit.Text
+			continue
+		}
+		changes = append(changes, edit)
+	}
+	return changes, nil
+}
+
+/*
+UnifiedDiff compares two texts line by line in the unified format of diff -u, with three lines
+of context around each hunk. It returns an empty string when the texts are the same and
+ErrDiffTooLarge when they are too large or too different to compare.
+*/
+func UnifiedDiff(fromName, toName, a, b string) (string, error) {
+	edits, err := diffTokens(diffLines(a), diffLines(b))
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// find the next change and extend the hunk while the changes are close enough to share context
+		first := start
+		for first < len(edits) && edits[first].Op == DiffEqual {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		last := first
+		for i := first; i < len(edits) && i-last <= 2*unifiedDiffContext+1; i++ {
+			if edits[i].Op != DiffEqual {
+				last = i
+			}
+		}
+
+		from := first - unifiedDiffContext
+		if from < start {
+			from = start
+		}
+		to := last + unifiedDiffContext + 1
+		if to > len(edits) {
+			to = len(edits)
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeUnifiedHunk(&out, edits, from, to)
+		start = to
+	}
+	return out.String(), nil
+}
+
+/* diffLines splits text into lines, a trailing newline ends the last line instead of starting an empty one */
+func diffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+/* writeUnifiedHunk writes the lines edits[from:to] with their @@ header */
+func writeUnifiedHunk(out *strings.Builder, edits []DiffChange, from, to int) {
+	oldLine, newLine := 0, 0
+	for _, edit := range edits[:from] {
+		if edit.Op != DiffInsert {
+			oldLine++
+		}
+		if edit.Op != DiffDelete {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	var lines strings.Builder
+	for _, edit := range edits[from:to] {
+		switch edit.Op {
+		case DiffEqual:
+			oldCount++
+			newCount++
+			lines.WriteString(" " + edit.Text + "\n")
+		case DiffDelete:
+			oldCount++
+			lines.WriteString("-" + edit.Text + "\n")
+		case DiffInsert:
+			newCount++
+			lines.WriteString("+" + edit.Text + "\n")
+		}
+	}
+
+	// an empty range starts at the line before it, as in diff -u
+	if oldCount > 0 {
+		oldLine++
+	}
+	if newCount > 0 {
+		newLine++
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+	out.WriteString(lines.String())
+}
+
+/*
+diffTokens returns the shortest edit script from a to b with one DiffChange per token,
+found with the O(ND) algorithm of Myers. Only the frontier of each step is kept for the
+backtrack, so the memory grows with the square of the number of edits and not the input.
+It gives up with ErrDiffTooLarge past maxDiffTokens tokens or maxDiffEdits edits, which
+bounds the memory of the trace and the time spent following the diagonals.
+*/
+func diffTokens(a, b []string) ([]DiffChange, error) {
+	n, m := len(a), len(b)
+	if n+m > maxDiffTokens {
+		return nil, ErrDiffTooLarge
+	}
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds v[k] for k in [-d-1, d+1] before step d
+	trace := [][]int{}
+	var x, y int
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return nil, ErrDiffTooLarge
+		}
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		done := false
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// walk back from the end, collecting the edits in reverse
+	edits := []DiffChange{}
+	x, y = n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d]
+		at := func(k int) int { return prev[k+d+1] }
+
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, DiffChange{Op: DiffEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, DiffChange{Op: DiffInsert, Text: b[prevY]})
+		} else {
+			edits = append(edits, DiffChange{Op: DiffDelete, Text: a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, DiffChange{Op: DiffEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits, nil
+}
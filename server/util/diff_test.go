@@ -0,0 +1,95 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordDiff(t *testing.T) {
+	changes, err := WordDiff("the quick brown fox", "the slow brown fox jumps")
+	require.NoError(t, err)
+	require.Equal(t, []DiffChange{
+		{Op: DiffEqual, Text: "the "},
+		{Op: DiffDelete, Text: "quick"},
+		{Op: DiffInsert, Text: "slow"},
+		{Op: DiffEqual, Text: " brown fox"},
+		{Op: DiffInsert, Text: " jumps"},
+	}, changes)
+
+	/* The changes rebuild both texts */
+	a, b := "one two  three\nfour", "zero two three\n\nfour five"
+	var oldText, newText strings.Builder
+	changes, err = WordDiff(a, b)
+	require.NoError(t, err)
+	for _, change := range changes {
+		if change.Op != DiffInsert {
+			oldText.WriteString(change.Text)
+		}
+		if change.Op != DiffDelete {
+			newText.WriteString(change.Text)
+		}
+	}
+	require.Equal(t, a, oldText.String())
+	require.Equal(t, b, newText.String())
+
+	changes, err = WordDiff("", "")
+	require.NoError(t, err)
+	require.Empty(t, changes)
+
+	changes, err = WordDiff("", "new")
+	require.NoError(t, err)
+	require.Equal(t, []DiffChange{{Op: DiffInsert, Text: "new"}}, changes)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	diff, err := UnifiedDiff("a", "b", "same\ntext\n", "same\ntext\n")
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	diff, err = UnifiedDiff("revision 1", "revision 2", "a\nb\nc\n", "a\nB\nc\nd\n")
+	require.NoError(t, err)
+	require.Equal(t, "--- revision 1\n+++ revision 2\n@@ -1,3 +1,4 @@\n a\n-b\n+B\n c\n+d\n", diff)
+
+	/* Changes far apart get their own hunk with three lines of context */
+	lines := []string{}
+	for i := 0; i < 20; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	changed := append([]string{}, lines...)
+	changed[1] = "B"
+	changed[18] = "S"
+	diff, err = UnifiedDiff("old", "new", strings.Join(lines, "\n"), strings.Join(changed, "\n"))
+	require.NoError(t, err)
+	require.Equal(t, "--- old\n+++ new\n"+
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n"+
+		"@@ -16,5 +16,5 @@\n p\n q\n r\n-s\n+S\n t\n", diff)
+
+	/* An empty side starts at line 0 */
+	diff, err = UnifiedDiff("old", "new", "", "first\n")
+	require.NoError(t, err)
+	require.Equal(t, "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+first\n", diff)
+}
+
+func TestDiffTooLarge(t *testing.T) {
+	/* Texts with more edits than the limit are not compared */
+	a := strings.Repeat("a\n", maxDiffEdits/2+1)
+	b := strings.Repeat("b\n", maxDiffEdits/2+1)
+	_, err := UnifiedDiff("old", "new", a, b)
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+
+	_, err = WordDiff(strings.Repeat("a ", maxDiffEdits/2+1), strings.Repeat("b ", maxDiffEdits/2+1))
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+
+	/* A single edit in a long text is within the limit */
+	changed := "c\n" + a[2:]
+	diff, err := UnifiedDiff("old", "new", a, changed)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+c\n")
+
+	/* Texts with more tokens than the limit are not compared even when they are the same */
+	long := strings.Repeat("a\n", maxDiffTokens/2+1)
+	_, err = UnifiedDiff("old", "new", long, long)
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+}
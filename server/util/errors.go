@@ -18,6 +18,10 @@ var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
 
+var ErrForeignKeyViolation = &pgconn.PgError{
+	Code: ForeignKeyViolation,
+}
+
 func ErrorCode(err error) string {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
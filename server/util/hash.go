@@ -1,23 +1,167 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-/* HashPassword hashes a password with bcrypt */
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		fmt.Println("Error:", err)
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+	// hashes asking for more memory are rejected rather than allowed to exhaust the server, in KiB
+	argon2idMaxMemory = 4 * 1024 * 1024
+)
+
+/* Errors returned by PasswordHasher.Verify */
+var (
+	ErrPasswordMismatch        = errors.New("password does not match")
+	ErrUnsupportedPasswordHash = errors.New("unsupported password hash")
+)
+
+/* Argon2idParams are the cost parameters of argon2id, Memory is in KiB */
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+/* DefaultArgon2idParams follows the second recommended option of RFC 9106 */
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+}
+
+/*
+PasswordHasher hashes passwords with argon2id and encodes them in the PHC string format,
+e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>. Legacy bcrypt hashes can still be verified.
+*/
+type PasswordHasher struct {
+	params Argon2idParams
+}
+
+var defaultPasswordHasher = NewPasswordHasher(DefaultArgon2idParams)
+
+/* NewPasswordHasher creates a new password hasher, unset parameters fall back to DefaultArgon2idParams */
+func NewPasswordHasher(params Argon2idParams) *PasswordHasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	return &PasswordHasher{params: params}
+}
+
+/* NewPasswordHasherFromConfig creates a new password hasher with the argon2id parameters of the config */
+func NewPasswordHasherFromConfig(config Config) *PasswordHasher {
+	return NewPasswordHasher(Argon2idParams{
+		Memory:      config.Argon2Memory,
+		Iterations:  config.Argon2Iterations,
+		Parallelism: config.Argon2Parallelism,
+	})
+}
+
+/* Hash hashes a password with argon2id */
+func (hasher *PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", err
 	}
 
-	return string(hashedPassword), nil
+	key := argon2.IDKey([]byte(password), salt, hasher.params.Iterations, hasher.params.Memory, hasher.params.Parallelism, argon2idKeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, hasher.params.Memory, hasher.params.Iterations, hasher.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+/*
+Verify checks a password against an argon2id or bcrypt hash. On success it reports whether the
+hash uses an outdated algorithm or parameters and should be replaced by a new Hash of the password.
+*/
+func (hasher *PasswordHasher) Verify(hashedPassword string, password string) (bool, error) {
+	if strings.HasPrefix(hashedPassword, "$2a$") || strings.HasPrefix(hashedPassword, "$2b$") || strings.HasPrefix(hashedPassword, "$2y$") {
+		err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, ErrPasswordMismatch
+			}
+			return false, err
+		}
+		return true, nil
+	}
+
+	if strings.HasPrefix(hashedPassword, "$argon2id$") {
+		params, version, salt, key, err := decodeArgon2idHash(hashedPassword)
+		if err != nil {
+			return false, err
+		}
+
+		otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+			return false, ErrPasswordMismatch
+		}
+
+		needsRehash := version != argon2.Version || params != hasher.params ||
+			len(salt) != argon2idSaltLength || len(key) != argon2idKeyLength
+		return needsRehash, nil
+	}
+
+	return false, ErrUnsupportedPasswordHash
+}
+
+/* decodeArgon2idHash parses an argon2id hash in the PHC string format */
+func decodeArgon2idHash(hashedPassword string) (Argon2idParams, int, []byte, []byte, error) {
+	var params Argon2idParams
+	var version int
+
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+	// argon2id needs at least 8 KiB per lane, argon2.IDKey would silently raise a lower memory cost
+	if params.Iterations < 1 || params.Parallelism < 1 ||
+		params.Memory < 8*uint32(params.Parallelism) || params.Memory > argon2idMaxMemory {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, 0, nil, nil, ErrUnsupportedPasswordHash
+	}
+	return params, version, salt, key, nil
+}
+
+/* HashPassword hashes a password with argon2id and the default parameters */
+func HashPassword(password string) (string, error) {
+	return defaultPasswordHasher.Hash(password)
 }
 
-/* VerifyPassword verifies a password with bcrypt */
+/* VerifyPassword verifies a password against an argon2id or legacy bcrypt hash */
 func VerifyPassword(hashedPassword string, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	_, err := defaultPasswordHasher.Verify(hashedPassword, password)
+	return err
 }
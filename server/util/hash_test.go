@@ -0,0 +1,104 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestPasswordHasher(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+
+	hashedPassword, err := hasher.Hash("testPassword")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashedPassword, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	/* Every hash uses a fresh salt */
+	otherHashedPassword, err := hasher.Hash("testPassword")
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword, otherHashedPassword)
+
+	needsRehash, err := hasher.Verify(hashedPassword, "testPassword")
+	require.NoError(t, err)
+	require.False(t, needsRehash)
+
+	_, err = hasher.Verify(hashedPassword, "wrongPassword")
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+}
+
+func TestPasswordHasherLongPasswords(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+	password := strings.Repeat("a", 100)
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+
+	/* Unlike bcrypt, bytes after the 72nd are not ignored */
+	_, err = hasher.Verify(hashedPassword, password[:72])
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+
+	_, err = hasher.Verify(hashedPassword, password)
+	require.NoError(t, err)
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+
+	/* Legacy bcrypt hashes are verified and flagged for a rehash */
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("testPassword"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	needsRehash, err := hasher.Verify(string(bcryptHash), "testPassword")
+	require.NoError(t, err)
+	require.True(t, needsRehash)
+
+	_, err = hasher.Verify(string(bcryptHash), "wrongPassword")
+	require.ErrorIs(t, err, ErrPasswordMismatch)
+
+	/* Hashes with outdated parameters are flagged for a rehash */
+	oldHasher := NewPasswordHasher(Argon2idParams{Memory: 512, Iterations: 1, Parallelism: 1})
+	oldHash, err := oldHasher.Hash("testPassword")
+	require.NoError(t, err)
+
+	needsRehash, err = hasher.Verify(oldHash, "testPassword")
+	require.NoError(t, err)
+	require.True(t, needsRehash)
+
+	needsRehash, err = oldHasher.Verify(oldHash, "testPassword")
+	require.NoError(t, err)
+	require.False(t, needsRehash)
+}
+
+func TestPasswordHasherInvalidHash(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2idParams)
+
+	for _, hashedPassword := range []string{
+		"",
+		"plainTextPassword",
+		"$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=0$c2FsdA$a2V5",
+		"$argon2id$v=19$m=7,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=255$c2FsdA$a2V5",
+		"$argon2id$v=19$m=4194305,t=1,p=1$c2FsdA$a2V5",
+	} {
+		_, err := hasher.Verify(hashedPassword, "testPassword")
+		require.ErrorIs(t, err, ErrUnsupportedPasswordHash, hashedPassword)
+	}
+}
+
+func TestNewPasswordHasherDefaults(t *testing.T) {
+	hasher := NewPasswordHasherFromConfig(Config{Argon2Iterations: 5})
+	require.Equal(t, Argon2idParams{
+		Memory:      DefaultArgon2idParams.Memory,
+		Iterations:  5,
+		Parallelism: DefaultArgon2idParams.Parallelism,
+	}, hasher.params)
+}
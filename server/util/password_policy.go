@@ -0,0 +1,79 @@
+package util
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const DefaultPasswordMinLength = 8
+
+/* MaxPasswordLength is the maximum length of a password in bytes, it keeps the cost of hashing a request bounded */
+const MaxPasswordLength = 1024
+
+/* Errors returned by PasswordPolicy.Validate */
+var (
+	ErrPasswordTooShort         = errors.New("password is too short")
+	ErrPasswordTooLong          = errors.New("password is too long")
+	ErrPasswordTooCommon        = errors.New("password is too common")
+	ErrPasswordContainsUsername = errors.New("password must not contain the username")
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = parseCommonPasswords(commonPasswordList)
+
+func parseCommonPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		password := strings.ToLower(strings.TrimSpace(line))
+		if password != "" {
+			passwords[password] = struct{}{}
+		}
+	}
+	return passwords
+}
+
+/*
+PasswordPolicy checks new passwords against a minimum and maximum length, a bundled list of common passwords
+and the username of the account.
+*/
+type PasswordPolicy struct {
+	minLength int
+}
+
+/* NewPasswordPolicy creates a new password policy, a non-positive minimum length falls back to DefaultPasswordMinLength */
+func NewPasswordPolicy(minLength int) *PasswordPolicy {
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	return &PasswordPolicy{minLength: minLength}
+}
+
+/* NewPasswordPolicyFromConfig creates a new password policy with the minimum length of the config */
+func NewPasswordPolicyFromConfig(config Config) *PasswordPolicy {
+	return NewPasswordPolicy(config.PasswordMinLength)
+}
+
+/* Validate returns an error describing the first rule the password breaks */
+func (policy *PasswordPolicy) Validate(password string, username string) error {
+	if utf8.RuneCountInString(password) < policy.minLength {
+		return fmt.Errorf("%w, it must be at least %d characters long", ErrPasswordTooShort, policy.minLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return fmt.Errorf("%w, it must be at most %d bytes long", ErrPasswordTooLong, MaxPasswordLength)
+	}
+
+	lowered := strings.ToLower(password)
+	if _, ok := commonPasswords[lowered]; ok {
+		return ErrPasswordTooCommon
+	}
+
+	if username != "" && strings.Contains(lowered, strings.ToLower(username)) {
+		return ErrPasswordContainsUsername
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := NewPasswordPolicy(10)
+
+	require.NoError(t, policy.Validate("correct horse battery", "testuser"))
+
+	err := policy.Validate("short1!", "testuser")
+	require.ErrorIs(t, err, ErrPasswordTooShort)
+	require.Contains(t, err.Error(), "10 characters")
+
+	/* The common password list is matched case-insensitively */
+	require.ErrorIs(t, policy.Validate("password1234", "testuser"), ErrPasswordTooCommon)
+	require.ErrorIs(t, policy.Validate("QwertyUIOP", "testuser"), ErrPasswordTooCommon)
+
+	/* The username is matched case-insensitively */
+	require.ErrorIs(t, policy.Validate("my-TestUser-password", "testuser"), ErrPasswordContainsUsername)
+	require.NoError(t, policy.Validate("my-TestUser-password", ""))
+
+	/* The maximum length is counted in bytes */
+	require.NoError(t, policy.Validate(strings.Repeat("a", MaxPasswordLength-2)+"é", ""))
+	err = policy.Validate(strings.Repeat("a", MaxPasswordLength-1)+"é", "")
+	require.ErrorIs(t, err, ErrPasswordTooLong)
+
+	/* Unset minimum length falls back to the default */
+	defaultPolicy := NewPasswordPolicyFromConfig(Config{})
+	require.ErrorIs(t, defaultPolicy.Validate("abcdefg", ""), ErrPasswordTooShort)
+	require.NoError(t, defaultPolicy.Validate("abcdefgh", ""))
+}
@@ -0,0 +1,14 @@
+package util
+
+import "strings"
+
+/*
+Slugify lowercases text and joins its runs of ASCII letters and digits with hyphens,
+everything else separates words. Migrations that slug existing rows use the same rule.
+*/
+func Slugify(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugify(t *testing.T) {
+	require.Equal(t, "golang", Slugify("golang"))
+	require.Equal(t, "web-development", Slugify("Web Development"))
+	require.Equal(t, "c-tips", Slugify("  C++ tips!  "))
+	require.Equal(t, "testcategory", Slugify("testCategory"))
+	require.Equal(t, "2024-recap", Slugify("2024--Recap"))
+	require.Empty(t, Slugify("---"))
+}
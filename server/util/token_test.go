@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomToken(t *testing.T) {
+	token, err := RandomToken(32)
+	require.NoError(t, err)
+	require.Len(t, token, 43)
+
+	otherToken, err := RandomToken(32)
+	require.NoError(t, err)
+	require.NotEqual(t, token, otherToken)
+}
+
+func TestHashToken(t *testing.T) {
+	hash := HashToken("testToken")
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashToken("testToken"))
+	require.NotEqual(t, hash, HashToken("otherToken"))
+}